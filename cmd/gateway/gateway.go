@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -14,6 +15,7 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
 )
 
@@ -32,6 +34,13 @@ func run() error {
 				UseProtoNames: false,
 			},
 		}),
+		// Account tokens are sent in a header rather than query strings
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if strings.EqualFold(key, middleware.ACCOUNT_AUTHORIZATION_HEADER) {
+				return key, true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
 	}
 
 	mux := runtime.NewServeMux(ropts...)
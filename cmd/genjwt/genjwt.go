@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -29,9 +30,13 @@ type Config struct {
 	oauth2Config oauth2.Config
 
 	SecretSeedEncoded string `mapstructure:"SECRET_SEED"`
-	jwtSecret         []byte
+	// Account tokens are signed by the session keyring of the pkv node
+	SessionSigningAlgorithm string        `mapstructure:"SESSION_SIGNING_ALGORITHM"`
+	SessionKeyGracePeriod   time.Duration `mapstructure:"SESSION_KEY_GRACE_PERIOD"`
 
-	QuotaAuthorityDid string `mapstructure:"QUOTA_AUTHORITY_DID"`
+	// Account tokens are verified by the pkv node itself, which must be both
+	// their issuer and audience
+	SelfIdentifier string `mapstructure:"SELF_IDENTIFIER"`
 
 	isTest bool `mapstructure:"IS_TEST"`
 }
@@ -43,6 +48,9 @@ func loadConfig(name string, path string) (config Config) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("SESSION_SIGNING_ALGORITHM", middleware.SESSION_SIGNING_ALGORITHM_HS256)
+	viper.SetDefault("SESSION_KEY_GRACE_PERIOD", "48h")
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("config: %v", err)
 	}
@@ -50,14 +58,6 @@ func loadConfig(name string, path string) (config Config) {
 		log.Fatalf("config: %v", err)
 	}
 	config.servicePort = viper.GetUint16("SERVICE_PORT")
-	seed, err := base64.StdEncoding.DecodeString(config.SecretSeedEncoded)
-	if err != nil {
-		log.Fatalf("config: failed to parse secret: %v", err)
-	}
-	config.jwtSecret, err = api.DeriveKey(seed, "JwtSecret")
-	if err != nil {
-		log.Fatalf("config: failed to derive key: %v", err)
-	}
 	config.isTest = viper.GetBool("IS_TEST")
 	config.redisHost = viper.GetString("REDIS_HOST")
 	config.redisPort = viper.GetUint16("REDIS_PORT")
@@ -78,13 +78,14 @@ func loadConfig(name string, path string) (config Config) {
 		Endpoint:     endpoint,
 	}
 
-	issuerDid = config.QuotaAuthorityDid
+	issuerDid = config.SelfIdentifier
 
 	return config
 }
 
 var config Config
 var redisClient *redis.Client
+var sessionKeys *middleware.SessionKeyring
 var issuerDid string
 
 func main() {
@@ -93,6 +94,11 @@ func main() {
 	redisClient = redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("%s:%d", config.redisHost, config.redisPort),
 	})
+	var err error
+	sessionKeys, err = newSessionKeyring(redisClient, config)
+	if err != nil {
+		log.Fatalf("failed to create session keyring: %v", err)
+	}
 
 	http.HandleFunc("/", handleHome)
 	http.HandleFunc("/v1/login", handleLogin)
@@ -102,6 +108,24 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.servicePort), nil))
 }
 
+// newSessionKeyring opens the session keyring of the pkv node, with the keys
+// it derives from the same secret seed.
+func newSessionKeyring(rdb *redis.Client, config Config) (*middleware.SessionKeyring, error) {
+	seed, err := base64.StdEncoding.DecodeString(config.SecretSeedEncoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secret: %v", err)
+	}
+	nodeConfig := api.Config{
+		SelfIdentifier:          config.SelfIdentifier,
+		SessionSigningAlgorithm: config.SessionSigningAlgorithm,
+		SessionKeyGracePeriod:   config.SessionKeyGracePeriod,
+	}
+	if err := nodeConfig.DeriveSecrets(seed); err != nil {
+		return nil, err
+	}
+	return api.NewSessionKeyring(rdb, &nodeConfig)
+}
+
 func handleHome(w http.ResponseWriter, r *http.Request) {
 	html := `<html><body><a href="/login">Login with Google</a></body></html>`
 	fmt.Fprint(w, html)
@@ -178,7 +202,7 @@ func handleCallback(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to get user sub: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	jwt, err := generateJwtHs256(subject)
+	jwt, err := generateJwt(ctx, subject)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -186,9 +210,9 @@ func handleCallback(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, jwt)
 }
 
-func generateJwtHs256(subject string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, getClaims(subject))
-	jwt, err := token.SignedString(config.jwtSecret)
+func generateJwt(ctx context.Context, subject string) (string, error) {
+	claims := getClaims(subject)
+	jwt, err := sessionKeys.Sign(ctx, &claims)
 	if err != nil {
 		log.Printf("failed to sign jwt: %v", err)
 		return "", err
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ = Describe("Session keyring", func() {
+	ctx := context.Background()
+	const seed = "fSWIg9naIcjkI1jb6E6cnOCirhqj+NLfzg+3VDmgDmg="
+
+	It("should sign account tokens with the keys of the node", func() {
+		rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+		DeferCleanup(rdb.Close)
+		conf := Config{
+			SecretSeedEncoded:       seed,
+			SessionSigningAlgorithm: middleware.SESSION_SIGNING_ALGORITHM_HS256,
+			SessionKeyGracePeriod:   time.Hour,
+			SelfIdentifier:          "did:example:pkv-" + uuid.NewString(),
+		}
+		keyringKey := "session-keyring:" + conf.SelfIdentifier
+		DeferCleanup(func() {
+			rdb.Del(ctx, keyringKey, keyringKey+":material")
+		})
+		keys, err := newSessionKeyring(rdb, conf)
+		Expect(err).To(BeNil())
+		_, err = keys.Rotate(ctx)
+		Expect(err).To(BeNil())
+		issuerDid = conf.SelfIdentifier
+		claims := getClaims("someone@example.com")
+		token, err := keys.Sign(ctx, &claims)
+		Expect(err).To(BeNil())
+
+		seedBytes, err := base64.StdEncoding.DecodeString(seed)
+		Expect(err).To(BeNil())
+		nodeConf := api.Config{
+			SelfIdentifier:          conf.SelfIdentifier,
+			SessionSigningAlgorithm: conf.SessionSigningAlgorithm,
+			SessionKeyGracePeriod:   conf.SessionKeyGracePeriod,
+		}
+		Expect(nodeConf.DeriveSecrets(seedBytes)).To(Succeed())
+		nodeKeys, err := api.NewSessionKeyring(rdb, &nodeConf)
+		Expect(err).To(BeNil())
+		parsed, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, nodeKeys.KeyFunc)
+		Expect(err).To(BeNil())
+		Expect(parsed.Claims.GetSubject()).To(Equal("someone@example.com"))
+	})
+})
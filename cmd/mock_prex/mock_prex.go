@@ -4,11 +4,14 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -19,6 +22,10 @@ type Config struct {
 	TokenSigningSeed       string `mapstructure:"TOKEN_SIGNING_SEED"`
 	TokenSigningPrivateKey ed25519.PrivateKey
 	QuotaAuthorityDid      string `mapstructure:"QUOTA_AUTHORITY_DID"`
+	// Pkv node the quota is minted for unless given by the audience query
+	AudienceDid string `mapstructure:"SELF_IDENTIFIER"`
+	// Exchange accounts of the pkv nodes whose refund vouchers are accepted
+	TrustedNodeAccounts []string `mapstructure:"TRUSTED_NODE_ACCOUNTS"`
 }
 
 func hexToBytes(hexStr string, arrayLength uint8) ([]byte, error) {
@@ -77,20 +84,27 @@ func main() {
 	config = loadConfig(".env", "/workspaces/pkv")
 
 	http.HandleFunc("/quota", handleQuota)
+	http.HandleFunc("POST /refund", handleRefund)
 
 	servicePort := 8100
-	fmt.Printf("Usage like: `curl http://localhost:%d/quota?max_size=99999&ttl=999999`\n", servicePort)
+	fmt.Printf("Usage like: `curl http://localhost:%d/quota?max_size=99999&ttl=999999&audience=did:example:pkv`\n", servicePort)
+	fmt.Printf("Refund like: `curl -X POST -d voucher=<voucher> http://localhost:%d/refund`\n", servicePort)
 	log.Printf("Server started at :%d\n", servicePort)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", servicePort), nil))
 }
 
 func handleQuota(w http.ResponseWriter, r *http.Request) {
-	claim := getClaims()
+	audience := r.URL.Query().Get("audience")
+	if audience == "" {
+		audience = config.AudienceDid
+	}
+	claim := getClaims(audience)
 	claims := QuotaClaims{
 		Usage:            pb.JwtUsage_JWT_USAGE_CREATE_SESSION,
 		Quantity:         1_000_000,
 		RegisteredClaims: &claim,
 	}
+	issuedQuotas.Store(claim.ID, claims.Quantity)
 
 	token := jwt.NewWithClaims(&jwt.SigningMethodEd25519{}, claims)
 	jwtSigned, err := token.SignedString(config.TokenSigningPrivateKey)
@@ -100,13 +114,55 @@ func handleQuota(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s", jwtSigned)
 }
 
+// issuedQuotas maps the ids of the quota tokens issued to their quantity,
+// which bounds what vouchers can refund.
+var issuedQuotas sync.Map
+
+// redeemedRefunds holds the quota token ids already refunded, so that a
+// voucher cannot be redeemed twice.
+var redeemedRefunds sync.Map
+
+func handleRefund(w http.ResponseWriter, r *http.Request) {
+	claims, err := middleware.VerifyRefundVoucher(
+		r.FormValue("voucher"), issuerDid, config.TrustedNodeAccounts, 30*time.Second,
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	issued, found := issuedQuotas.Load(claims.ID)
+	if !found {
+		http.Error(w, fmt.Sprintf("quota token %s was not issued here", claims.ID), http.StatusBadRequest)
+		return
+	}
+	if claims.Quantity > issued.(int64) {
+		http.Error(
+			w,
+			fmt.Sprintf("refund of %d exceeds the %d issued", claims.Quantity, issued.(int64)),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if _, loaded := redeemedRefunds.LoadOrStore(claims.ID, struct{}{}); loaded {
+		http.Error(w, fmt.Sprintf("quota token %s already refunded", claims.ID), http.StatusConflict)
+		return
+	}
+	log.Printf("refunded %d of quota token %s to %s", claims.Quantity, claims.ID, claims.Issuer)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"account":  claims.Issuer,
+		"jti":      claims.ID,
+		"quantity": claims.Quantity,
+	})
+}
+
 type QuotaClaims struct {
 	Usage    pb.JwtUsage `json:"usage"`
 	Quantity int64       `json:"quantity"`
 	*jwt.RegisteredClaims
 }
 
-func getClaims() jwt.RegisteredClaims {
+func getClaims(audience string) jwt.RegisteredClaims {
 	serviceIdentifier := issuerDid
 	return jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(4 * time.Minute)),
@@ -114,6 +170,6 @@ func getClaims() jwt.RegisteredClaims {
 		NotBefore: jwt.NewNumericDate(time.Now()),
 		Issuer:    serviceIdentifier,
 		ID:        uuid.NewString(),
-		Audience:  []string{serviceIdentifier},
+		Audience:  []string{audience},
 	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Refunds", func() {
+	var nodeKey ed25519.PrivateKey
+	var nodeDid string
+	BeforeEach(func() {
+		_, signingKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		nodeKey = privateKey
+		nodeDid = "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+		issuerDid = "did:example:exchange"
+		config = Config{
+			TokenSigningPrivateKey: signingKey,
+			AudienceDid:            "did:example:pkv",
+			TrustedNodeAccounts:    []string{nodeDid},
+		}
+	})
+
+	issueQuota := func() (string, int64) {
+		recorder := httptest.NewRecorder()
+		handleQuota(recorder, httptest.NewRequest(http.MethodGet, "/quota", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		claims := &QuotaClaims{}
+		_, _, err := jwt.NewParser().ParseUnverified(recorder.Body.String(), claims)
+		Expect(err).To(BeNil())
+		return claims.ID, claims.Quantity
+	}
+
+	refund := func(jti string, quantity int64) *httptest.ResponseRecorder {
+		voucher, err := middleware.SignRefundVoucher(&pb.SessionRefund{
+			SessionId: "session",
+			Issuer:    issuerDid,
+			Jti:       jti,
+			Balance:   quantity,
+		}, nodeDid, nodeKey, time.Hour)
+		Expect(err).To(BeNil())
+		req := httptest.NewRequest(
+			http.MethodPost, "/refund", strings.NewReader(url.Values{"voucher": {voucher}}.Encode()),
+		)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+		handleRefund(recorder, req)
+		return recorder
+	}
+
+	It("should refund quota tokens it issued once", func() {
+		jti, quantity := issueQuota()
+		Expect(refund(jti, quantity).Code).To(Equal(http.StatusOK))
+		Expect(refund(jti, quantity).Code).To(Equal(http.StatusConflict))
+	})
+
+	It("should refuse tokens it did not issue", func() {
+		Expect(refund(uuid.NewString(), 1).Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should refuse refunds above the quantity issued", func() {
+		jti, quantity := issueQuota()
+		Expect(refund(jti, quantity+1).Code).To(Equal(http.StatusBadRequest))
+		Expect(refund(jti, quantity).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should refuse vouchers of untrusted nodes", func() {
+		jti, quantity := issueQuota()
+		config.TrustedNodeAccounts = []string{"did:example:other-node"}
+		Expect(refund(jti, quantity).Code).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -3,19 +3,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
-	"buf.build/go/protovalidate"
-	"connectrpc.com/connect"
 	"connectrpc.com/grpcreflect"
 	"github.com/atticplaygroup/pkv/internal/api"
-	"github.com/atticplaygroup/pkv/pkg/middleware"
 	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -25,33 +25,64 @@ func main() {
 		log.Fatalf("cannot init server: %v", err)
 	}
 	defer server.GetRedisClient().Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	validator, err := protovalidate.New()
-	if err != nil {
-		log.Fatalf("failed to initialize validator: %s", err.Error())
+	if len(os.Args) > 1 && os.Args[1] == "rotate-session-key" {
+		key, err := server.RotateSessionKey(ctx)
+		if err != nil {
+			log.Fatalf("failed to rotate session key: %v", err)
+		}
+		log.Printf("signing session tokens with key %s of generation %d\n", key.Kid, key.Generation)
+		return
 	}
-	sessionManager := middleware.NewRedisSessionManager(server.GetRedisClient())
-	pricingManager := &middleware.PricingManager{}
-	authManager := server.GetAuthManager()
 
-	mux := http.NewServeMux()
-	interceptors := make([]connect.Interceptor, 0)
-	if !conf.DisableAuth {
-		interceptors = append(
-			interceptors,
-			middleware.NewConnectUnarySessionInterceptor(sessionManager, pricingManager, authManager),
-		)
+	if conf.ProbeInterval > 0 {
+		prober := api.NewHealthProber(&conf, server.GetRedisClient(), nil)
+		go prober.Run(ctx)
+	}
+	if conf.IpniPollInterval > 0 {
+		ingester := api.NewIpniIngester(&conf, server.GetRedisClient(), nil)
+		go ingester.Run(ctx)
+	}
+	if conf.ScrubInterval > 0 {
+		scrubber := api.NewScrubber(&conf, server.GetRedisClient())
+		go scrubber.Run(ctx)
 	}
-	interceptors = append(
-		interceptors,
-		middleware.NewConnectValidationInterceptor(validator),
-	)
 
-	path, handler := kvstoreconnect.NewKvStoreServiceHandler(
-		server,
-		connect.WithInterceptors(interceptors...),
-	)
+	path, handler, err := api.NewKvStoreHandler(server)
+	if err != nil {
+		log.Fatalf("failed to initialize handler: %v", err)
+	}
+	mux := http.NewServeMux()
 	mux.Handle(path, handler)
+	mux.Handle("/routing/v1/", server.RoutingHandler())
+	mux.Handle("/ipfs/", server.GatewayHandler())
+	mux.Handle("/admin/", server.AdminHandler())
+	mux.Handle("GET /.well-known/jwks.json", server.JwksHandler())
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if len(conf.Libp2pListenAddrs) > 0 {
+		h, err := api.NewLibp2pHost(&conf)
+		if err != nil {
+			log.Fatalf("failed to start libp2p host: %v", err)
+		}
+		defer h.Close()
+		libp2pServer := api.NewLibp2pServer(h, path, handler)
+		server.SetLibp2pServer(libp2pServer)
+		go func() {
+			if err := libp2pServer.Serve(); err != nil && err != http.ErrServerClosed {
+				log.Printf("libp2p server stopped: %v", err)
+			}
+		}()
+		if conf.BitswapEnabled {
+			bitswapServer := api.NewBitswapServer(ctx, h, server)
+			defer bitswapServer.Close()
+			server.SetBitswapServer(bitswapServer)
+		}
+		log.Printf("Serving over libp2p at %v\n", libp2pServer.Multiaddrs())
+	}
+
 	reflector := grpcreflect.NewStaticReflector(
 		kvstoreconnect.KvStoreServiceName,
 	)
@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Account token header", Label("account"), func() {
+	ctx := context.Background()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	accountToken := func(subject string) string {
+		now := time.Now()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Issuer:    Conf.SelfIdentifier,
+			Audience:  jwt.ClaimStrings{Conf.SelfIdentifier},
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		})
+		signed, err := token.SignedString(Conf.JwtSecret)
+		Expect(err).To(BeNil())
+		return signed
+	}
+
+	var sessionToken string
+	var owner, stream, valueName string
+	BeforeEach(func() {
+		sessionToken, _ = newSession(ctx)
+		owner = "did:example:owner-" + uuid.NewString()
+		stream = "accounts/" + owner + "/streams/inbox"
+
+		req := connect.NewRequest(&pb.CreateStreamValueRequest{
+			Parent: stream,
+			Value:  []byte("stream value"),
+		})
+		req.Header().Set("Authorization", "Bearer "+sessionToken)
+		created, err := client.CreateStreamValue(ctx, req)
+		Expect(err).To(BeNil())
+		valueName = created.Msg.GetName()
+	})
+
+	authorize := func(header http.Header, headerToken string) {
+		header.Set("Authorization", "Bearer "+sessionToken)
+		if headerToken != "" {
+			header.Set(middleware.ACCOUNT_AUTHORIZATION_HEADER, "Bearer "+headerToken)
+		}
+	}
+	list := func(headerToken string, bodyToken string) error {
+		req := connect.NewRequest(&pb.ListStreamValuesRequest{
+			Parent:    stream,
+			AuthToken: bodyToken,
+			PageSize:  10,
+			PageToken: "0-0",
+		})
+		authorize(req.Header(), headerToken)
+		_, err := client.ListStreamValues(ctx, req)
+		return err
+	}
+	get := func(headerToken string, bodyToken string) error {
+		req := connect.NewRequest(&pb.GetStreamValueRequest{
+			Name:      valueName,
+			AuthToken: bodyToken,
+		})
+		authorize(req.Header(), headerToken)
+		_, err := client.GetStreamValue(ctx, req)
+		return err
+	}
+
+	It("should authorize stream reads with the header", func() {
+		Expect(list(accountToken(owner), "")).To(Succeed())
+		Expect(get(accountToken(owner), "")).To(Succeed())
+		Expect(list(accountToken("did:example:other-"+uuid.NewString()), "")).To(Not(Succeed()))
+		Expect(get(accountToken("did:example:other-"+uuid.NewString()), "")).To(Not(Succeed()))
+	})
+
+	It("should prefer the header over the request body", func() {
+		Expect(list("invalid", accountToken(owner))).To(Not(Succeed()))
+		other := accountToken("did:example:other-" + uuid.NewString())
+		Expect(list(other, accountToken(owner))).To(Not(Succeed()))
+		Expect(list(accountToken(owner), other)).To(Succeed())
+	})
+
+	It("should still accept account tokens in the request body", func() {
+		Expect(list("", accountToken(owner))).To(Succeed())
+		Expect(get("", accountToken(owner))).To(Succeed())
+		Expect(list("", "")).To(Not(Succeed()))
+		Expect(get("", "")).To(Not(Succeed()))
+	})
+
+	It("should delegate with the header", func() {
+		delegate := "did:example:delegate-" + uuid.NewString()
+		req := connect.NewRequest(&pb.DelegateStreamAccessRequest{
+			Parent:     stream,
+			Delegate:   delegate,
+			Operations: []pb.StreamOperation{pb.StreamOperation_STREAM_OPERATION_GET},
+			Ttl:        durationpb.New(time.Hour),
+		})
+		authorize(req.Header(), accountToken(owner))
+		resp, err := client.DelegateStreamAccess(ctx, req)
+		Expect(err).To(BeNil())
+
+		getReq := connect.NewRequest(&pb.GetStreamValueRequest{
+			Name:            valueName,
+			DelegationToken: resp.Msg.GetDelegationToken(),
+		})
+		authorize(getReq.Header(), accountToken(delegate))
+		_, err = client.GetStreamValue(ctx, getReq)
+		Expect(err).To(BeNil())
+	})
+})
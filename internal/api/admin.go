@@ -0,0 +1,54 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// AdminHandler serves operator endpoints authenticated by the admin token,
+// refusing every request when no token is configured.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/v1/sessions/{sessionId}/revoke", s.handleAdminRevokeSession)
+	return mux
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	http.Error(w, status.Convert(err).Message(), runtime.HTTPStatusFromCode(status.Code(err)))
+}
+
+func (s *Server) authenticateAdmin(r *http.Request) error {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if s.config.AdminToken == "" || !found || !strings.EqualFold(scheme, "bearer") ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+		return status.Error(codes.PermissionDenied, "invalid admin token")
+	}
+	return nil
+}
+
+// handleAdminRevokeSession revokes a session reported as leaked, like
+// RevokeSession does for the session holder.
+func (s *Server) handleAdminRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if err := s.authenticateAdmin(r); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	refund, err := s.revokeSession(r.Context(), r.PathValue("sessionId"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	body, err := protojson.Marshal(refund)
+	if err != nil {
+		writeAdminError(w, status.Errorf(codes.Internal, "failed to encode refund: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -1,12 +1,14 @@
 package api_test
 
 import (
+	"fmt"
 	"log"
 	"testing"
 
 	"github.com/atticplaygroup/pkv/internal/api"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -16,6 +18,11 @@ func TestApi(t *testing.T) {
 	JwtSeed = "HbFdKCKTGkzcWKMPWmHKjW/Ii/wpcKTyD+8QIxw3Gc0="
 	conf := api.LoadConfig(".env", "../..")
 	JwtHS256Secret = conf.SecretSeedEncoded
+	Conf = &conf
+	RedisClient = redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("%s:%d", conf.RedisHost, conf.RedisPort),
+	})
+	defer RedisClient.Close()
 
 	conn, err := grpc.NewClient(
 		"localhost:50051",
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/ed25519"
 	"encoding/base64"
@@ -12,13 +13,21 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
 	"github.com/atticplaygroup/pkv/pkg/middleware"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
 	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -32,6 +41,8 @@ type MockJwtIssuer struct {
 var JwtSeed string
 var JwtHS256Secret string
 var GrpcClient *grpc.ClientConn
+var RedisClient *redis.Client
+var Conf *api.Config
 var sessionJwt string
 
 const MOCK_SELF_IDENTIFIER = "did:example:pkv"
@@ -71,6 +82,11 @@ func NewMockJwtIssuer() *MockJwtIssuer {
 }
 
 func (i *MockJwtIssuer) IssueQuotaToken(jti string) string {
+	return i.IssueQuotaTokenFor(jti, MOCK_SELF_IDENTIFIER)
+}
+
+// IssueQuotaTokenFor mints a quota token redeemable by the operator audience.
+func (i *MockJwtIssuer) IssueQuotaTokenFor(jti string, audience string) string {
 	claims := middleware.CreateSessionJwtClaims{
 		Quantity: 22222222,
 		SessionJwtClaims: &middleware.SessionJwtClaims{
@@ -81,7 +97,7 @@ func (i *MockJwtIssuer) IssueQuotaToken(jti string) string {
 				Issuer:    "did:key:z6MktULudTtAsAhRegYPiZ6631RV3viv12qd4GQF8z1xB22S",
 				// Subject:   "b83dd883-7d0b-452f-88be-ea5bb4cb6061",
 				ID:       jti,
-				Audience: jwt.ClaimStrings{MOCK_SELF_IDENTIFIER},
+				Audience: jwt.ClaimStrings{audience},
 			},
 			Usage: pb.JwtUsage_JWT_USAGE_CREATE_SESSION,
 		},
@@ -99,6 +115,27 @@ func (i *MockJwtIssuer) IssueQuotaToken(jti string) string {
 	return jwt
 }
 
+// testClient calls the test server the suite runs against.
+var testClient = kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+// newSession redeems a fresh quota token on the test server and returns the
+// session jwt and id.
+func newSession(ctx context.Context) (string, string) {
+	resp, err := testClient.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+		Jwt: NewMockJwtIssuer().IssueQuotaToken(uuid.NewString()),
+	}))
+	Expect(err).To(BeNil())
+	return resp.Msg.GetJwt(), resp.Msg.GetSession().GetSessionId()
+}
+
+// sessionBalance returns the balance left in a session of the test server.
+func sessionBalance(ctx context.Context, sessionId string) int64 {
+	sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+	session, err := sessionManager.GetSession(ctx, sessionId)
+	Expect(err).To(BeNil())
+	return session.GetBalance()
+}
+
 var _ = Describe("Store, fetch and prolong data", Label("kvstore"), Ordered, func() {
 	issuer := NewMockJwtIssuer()
 	var resourceName string
@@ -211,6 +248,45 @@ var _ = Describe("Store, fetch and prolong data", Label("kvstore"), Ordered, fun
 		})
 	})
 
+	When("user creates dag-cbor values hashed with blake3", func() {
+		It("should store them under blake3 cids", func() {
+			node, err := qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+				qp.MapEntry(ma, "name", qp.String("dag-cbor value"))
+			})
+			Expect(err).To(BeNil())
+			var buf bytes.Buffer
+			Expect(dagcbor.Encode(node, &buf)).To(Succeed())
+			req := pb.CreateValueRequest{
+				Codec:        pb.CreateValueRequest_CODEC_DAG_CBOR,
+				HashFunction: pb.CreateValueRequest_HASH_FUNCTION_BLAKE3,
+				Value:        buf.Bytes(),
+				Ttl:          durationpb.New(1000 * time.Second),
+			}
+			connectReq := connect.NewRequest(&req)
+			connectReq.Header().Set(
+				"authorization", "bearer "+sessionJwt,
+			)
+			resp, err := client.CreateValue(ctx, connectReq)
+			Expect(err).To(BeNil())
+			c, err := cid.Decode(strings.TrimPrefix(resp.Msg.GetName(), "values/"))
+			Expect(err).To(BeNil())
+			Expect(c.Type()).To(Equal(uint64(cid.DagCBOR)))
+			Expect(c.Prefix().MhType).To(Equal(uint64(multihash.BLAKE3)))
+
+			getReq := connect.NewRequest(&pb.GetValueRequest{Name: resp.Msg.GetName()})
+			getReq.Header().Set(
+				"authorization", "bearer "+sessionJwt,
+			)
+			getResp, err := client.GetValue(ctx, getReq)
+			Expect(err).To(BeNil())
+			Expect(getResp.Msg.GetValue()).To(Equal(buf.Bytes()))
+
+			req.Value = []byte("not cbor")
+			_, err = client.CreateValue(ctx, connectReq)
+			Expect(err).To(Not(BeNil()))
+		})
+	})
+
 	// TODO: test with another sessionJwt should success because it is public
 	When("user get the value", func() {
 		It("should success", func() {
@@ -280,6 +356,10 @@ var _ = Describe("Store, fetch and prolong data", Label("kvstore"), Ordered, fun
 			vsvcs := resp.Msg.GetVirtualServices()
 			Expect(len(vsvcs)).To(BeNumerically(">", 0))
 			Expect(vsvcs[0].GetVariantLink().GetName()).To(Equal(root))
+			proofs := resp.Msg.GetInclusionProofs()
+			Expect(proofs).To(HaveLen(len(vsvcs)))
+			Expect(merkle.VerifyInclusionProof(cid1, vsvcs[0], proofs[0])).To(Succeed())
+			Expect(merkle.VerifyInclusionProof(cid2, vsvcs[0], proofs[0])).To(Not(Succeed()))
 			instances := resp.Msg.GetStorageInstances()
 			Expect(instances).To(HaveLen(1))
 			Expect(instances[0].GetProviderInstance().GetDid()).To(Equal("did:example:pkv2"))
@@ -316,8 +396,11 @@ var _ = Describe("Store, fetch and prolong data", Label("kvstore"), Ordered, fun
 			connectReq.Header().Add("authorization", "bearer "+sessionJwt)
 			resp, err := client.DelegatedRouting(ctx, connectReq)
 			Expect(err).To(BeNil())
-			mhr := resp.Msg.GetProviders()
-			Expect(mhr).To(HaveLen(1))
+			providers := resp.Msg.GetProviders()
+			Expect(providers).To(HaveLen(1))
+			Expect(providers[0].GetSchema()).To(Equal(api.ROUTING_SCHEMA_PEER))
+			Expect(providers[0].GetId()).To(Equal(ad.GetProviderInstance().GetPeerId()))
+			Expect(providers[0].GetAddrs()).To(Equal(ad.GetProviderInstance().GetMultiaddrs()))
 		})
 	})
 })
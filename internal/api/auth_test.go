@@ -0,0 +1,32 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token audience", Label("auth"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	It("should refuse quota tokens minted for another operator", func() {
+		_, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaTokenFor(uuid.NewString(), "did:example:other-pkv"),
+		}))
+		Expect(err).To(Not(BeNil()))
+
+		resp, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaTokenFor(uuid.NewString(), Conf.SelfIdentifier),
+		}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetJwt()).To(Not(BeEmpty()))
+	})
+})
@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+)
+
+var _ = Describe("Batch value reads", Label("batch"), func() {
+	ctx := context.Background()
+
+	var token string
+	var sessionId string
+	var names []string
+	var values [][]byte
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+
+		values = [][]byte{
+			[]byte("first message " + uuid.NewString()),
+			[]byte("not stored " + uuid.NewString()),
+			[]byte("second message " + uuid.NewString()),
+		}
+		names = make([]string, 0, len(values))
+		for i, value := range values {
+			name := "values/" + api.HashRawBytes(value)
+			names = append(names, name)
+			if i != 1 {
+				Expect(RedisClient.Set(ctx, name, value, time.Minute).Err()).To(Succeed())
+			}
+		}
+	})
+
+	It("should return values in order and charge the bytes returned", func() {
+		before := sessionBalance(ctx, sessionId)
+		req := connect.NewRequest(&pb.BatchGetValuesRequest{Names: names})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.BatchGetValues(ctx, req)
+		Expect(err).To(BeNil())
+		results := resp.Msg.GetResults()
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].GetValue()).To(Equal(values[0]))
+		Expect(results[1].GetValue()).To(BeEmpty())
+		Expect(results[1].GetStatus().GetCode()).To(Equal(int32(codes.NotFound)))
+		Expect(results[2].GetValue()).To(Equal(values[2]))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - 1 - int64(len(values[0])+len(values[2]))))
+	})
+
+	It("should stat values without their bodies", func() {
+		before := sessionBalance(ctx, sessionId)
+		req := connect.NewRequest(&pb.StatValuesRequest{Names: names})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.StatValues(ctx, req)
+		Expect(err).To(BeNil())
+		results := resp.Msg.GetResults()
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].GetStat().GetSize()).To(Equal(int64(len(values[0]))))
+		Expect(results[0].GetStat().GetTtl().AsDuration()).To(BeNumerically("~", time.Minute, time.Second))
+		Expect(results[0].GetStat().GetCodec()).To(Equal(uint64(cid.Raw)))
+		Expect(results[1].GetStat()).To(BeNil())
+		Expect(results[1].GetStatus().GetCode()).To(Equal(int32(codes.NotFound)))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - 1))
+	})
+})
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	SERVE_SUBSET_BEHAVIOR_NAME    = "serve_subset"
+	SERVE_SUBSET_BEHAVIOR_VERSION = "v0.1.0"
+)
+
+// FileServingFactory builds a behavior processor. The behavior link it must
+// match is passed in so that operators choose the maintainer through config.
+type FileServingFactory func(behavior *pb.GlobalLink, redisClient *redis.Client) MerkleTreeFileServing
+
+type fileServingEntry struct {
+	version string
+	factory FileServingFactory
+}
+
+// FileServingRegistry holds the behaviors an index knows how to validate and
+// index. Which of them are served is decided by ENABLED_BEHAVIORS.
+type FileServingRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]fileServingEntry
+}
+
+func NewFileServingRegistry() *FileServingRegistry {
+	return &FileServingRegistry{
+		entries: make(map[string]fileServingEntry),
+	}
+}
+
+// DefaultFileServingRegistry has the built-in behaviors. Additional behaviors
+// like erasure coded or subscriber-only serving register here from their own
+// package init.
+var DefaultFileServingRegistry = NewFileServingRegistry()
+
+func init() {
+	DefaultFileServingRegistry.Register(
+		SERVE_ALL_BEHAVIOR_NAME,
+		SERVE_ALL_BEHAVIOR_VERSION,
+		func(behavior *pb.GlobalLink, redisClient *redis.Client) MerkleTreeFileServing {
+			return newServeAllFileServing(behavior, redisClient)
+		},
+	)
+	DefaultFileServingRegistry.Register(
+		SERVE_SUBSET_BEHAVIOR_NAME,
+		SERVE_SUBSET_BEHAVIOR_VERSION,
+		func(behavior *pb.GlobalLink, redisClient *redis.Client) MerkleTreeFileServing {
+			return NewServeSubsetFileServing(behavior, redisClient)
+		},
+	)
+}
+
+func (r *FileServingRegistry) Register(name string, version string, factory FileServingFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		panic(fmt.Sprintf("behavior %s registered twice", name))
+	}
+	r.entries[name] = fileServingEntry{
+		version: version,
+		factory: factory,
+	}
+}
+
+func (r *FileServingRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build instantiates the enabled behaviors, all maintained by maintainer.
+func (r *FileServingRegistry) Build(
+	enabled []string, maintainer string, redisClient *redis.Client,
+) ([]MerkleTreeFileServing, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ret := make([]MerkleTreeFileServing, 0, len(enabled))
+	for _, name := range enabled {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		entry, ok := r.entries[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown behavior %s, expected one of %v", name, r.Names())
+		}
+		ret = append(ret, entry.factory(&pb.GlobalLink{
+			Name:       name,
+			Maintainer: maintainer,
+			Version:    entry.version,
+		}, redisClient))
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no behavior enabled")
+	}
+	return ret, nil
+}
+
+// ServeSubsetFileServing indexes instances serving only part of a virtual
+// service. The variant is still identified by the merkle root of the full CID
+// set, while each advertisement lists the CIDs that instance actually serves.
+type ServeSubsetFileServing struct {
+	*ServeAllFileServing
+}
+
+func NewServeSubsetFileServing(behavior *pb.GlobalLink, redisClient *redis.Client) MerkleTreeFileServing {
+	return &ServeSubsetFileServing{
+		ServeAllFileServing: newServeAllFileServing(behavior, redisClient),
+	}
+}
+
+// ServesSubset has SearchCid return instances only for the CIDs they
+// advertised.
+func (m *ServeSubsetFileServing) ServesSubset() bool {
+	return true
+}
+
+// Register indexes the advertised CIDs with their inclusion proofs instead of
+// the merkle leaves, since a subset is not enough to rebuild the variant tree.
+func (m *ServeSubsetFileServing) Register(
+	ctx context.Context, advertisement *pb.ProviderAdvertise,
+) error {
+	if err := m.registerVirtualService(ctx, advertisement); err != nil {
+		return err
+	}
+	if err := m.registerCid(ctx, advertisement); err != nil {
+		return err
+	}
+	return m.registerProofs(ctx, advertisement)
+}
+
+// registerProofs keeps the verified proofs to be returned at search time.
+func (m *ServeSubsetFileServing) registerProofs(
+	ctx context.Context,
+	advertisement *pb.ProviderAdvertise,
+) error {
+	virtualServiceHash, err := HashMessage(advertisement.GetVirtualService())
+	if err != nil {
+		return err
+	}
+	proofs := make(map[string]interface{}, len(advertisement.GetCids()))
+	for i, cid := range advertisement.GetCids() {
+		proofs[cid] = advertisement.GetInclusionProofs()[i]
+	}
+	proofsKey := fmt.Sprintf("vsvc:proofs:%s", virtualServiceHash)
+	pipe := m.redisClient.TxPipeline()
+	pipe.HSet(ctx, proofsKey, proofs)
+	pipe.Expire(ctx, proofsKey, DEFAULT_TTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// EnsureAdvertisementValid checks the inclusion proof of every served CID
+// against the variant root, since the subset cannot rebuild the tree.
+func (m *ServeSubsetFileServing) EnsureAdvertisementValid(advertisement *pb.ProviderAdvertise) error {
+	variant := advertisement.GetVirtualService().GetVariantLink()
+	root, err := cid.Decode(variant.GetName())
+	if err != nil {
+		return fmt.Errorf("variant name %s is not a cid: %v", variant.GetName(), err)
+	}
+	decoded, err := multihash.Decode(root.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to decode multihash of variant %s: %v", variant.GetName(), err)
+	}
+	if decoded.Code != multihash.KECCAK_256 {
+		return fmt.Errorf("variant %s is not a keccak-256 merkle root", variant.GetName())
+	}
+	proofs := advertisement.GetInclusionProofs()
+	if len(proofs) != len(advertisement.GetCids()) {
+		return fmt.Errorf(
+			"expected %d inclusion proofs but got %d", len(advertisement.GetCids()), len(proofs),
+		)
+	}
+	for i, cidString := range advertisement.GetCids() {
+		c, err := cid.Decode(cidString)
+		if err != nil {
+			return fmt.Errorf("failed to parse cid %s: %v", cidString, err)
+		}
+		if c.Version() != 1 {
+			return fmt.Errorf("expected cid v1 but got %s", cidString)
+		}
+		if err := merkle.VerifyInclusionProof(cidString, advertisement.GetVirtualService(), proofs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
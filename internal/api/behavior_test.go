@@ -0,0 +1,188 @@
+package api_test
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Build enabled behaviors", Label("behavior"), func() {
+	ctx := context.Background()
+	maintainer := "did:example:maintainer"
+
+	It("should reject unknown behaviors", func() {
+		_, err := api.DefaultFileServingRegistry.Build(
+			[]string{"serve_nothing"}, maintainer, RedisClient,
+		)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should match behavior links with the configured maintainer", func() {
+		processors, err := api.DefaultFileServingRegistry.Build(
+			[]string{api.SERVE_ALL_BEHAVIOR_NAME, api.SERVE_SUBSET_BEHAVIOR_NAME}, maintainer, RedisClient,
+		)
+		Expect(err).To(BeNil())
+		Expect(processors).To(HaveLen(2))
+
+		matching, err := processors[0].IsBehaviorMatching(api.ServeAllBehavior)
+		Expect(err).To(BeNil())
+		Expect(matching).To(BeFalse())
+		matching, err = processors[1].IsBehaviorMatching(&pb.GlobalLink{
+			Name:       api.SERVE_SUBSET_BEHAVIOR_NAME,
+			Maintainer: maintainer,
+			Version:    api.SERVE_SUBSET_BEHAVIOR_VERSION,
+		})
+		Expect(err).To(BeNil())
+		Expect(matching).To(BeTrue())
+	})
+
+	It("should accept a subset of the variant in serve_subset", func() {
+		subset := api.NewServeSubsetFileServing(&pb.GlobalLink{
+			Name:       api.SERVE_SUBSET_BEHAVIOR_NAME,
+			Maintainer: maintainer,
+			Version:    api.SERVE_SUBSET_BEHAVIOR_VERSION,
+		}, RedisClient)
+		cids := []string{
+			api.HashRawBytes([]byte("hello")),
+			api.HashRawBytes([]byte("world")),
+		}
+		mt, err := merkle.NewCidTree(cids)
+		Expect(err).To(BeNil())
+		root, err := merkle.RootCid(mt)
+		Expect(err).To(BeNil())
+		proof, err := merkle.GenerateInclusionProof(mt, cids[0])
+		Expect(err).To(BeNil())
+		ad := &pb.ProviderAdvertise{
+			VirtualService: &pb.VirtualService{
+				VariantLink: &pb.GlobalLink{Name: root},
+			},
+			Cids:            cids[:1],
+			InclusionProofs: []*pb.MerkleInclusionProof{proof},
+		}
+		Expect(subset.EnsureAdvertisementValid(ad)).To(Succeed())
+
+		ad.InclusionProofs = nil
+		Expect(subset.EnsureAdvertisementValid(ad)).To(Not(Succeed()))
+
+		ad.InclusionProofs = []*pb.MerkleInclusionProof{proof}
+		ad.VirtualService.VariantLink.Name = cids[1]
+		Expect(subset.EnsureAdvertisementValid(ad)).To(Not(Succeed()))
+	})
+
+	It("should reject cids outside the variant root in serve_subset", func() {
+		subset := api.NewServeSubsetFileServing(&pb.GlobalLink{
+			Name:       api.SERVE_SUBSET_BEHAVIOR_NAME,
+			Maintainer: maintainer,
+			Version:    api.SERVE_SUBSET_BEHAVIOR_VERSION,
+		}, RedisClient)
+		cids := []string{
+			api.HashRawBytes([]byte("hello")),
+			api.HashRawBytes([]byte("world")),
+		}
+		mt, err := merkle.NewCidTree(cids)
+		Expect(err).To(BeNil())
+		root, err := merkle.RootCid(mt)
+		Expect(err).To(BeNil())
+		proof, err := merkle.GenerateInclusionProof(mt, cids[0])
+		Expect(err).To(BeNil())
+
+		outside := api.HashRawBytes([]byte("not in the variant"))
+		ad := &pb.ProviderAdvertise{
+			VirtualService: &pb.VirtualService{
+				VariantLink: &pb.GlobalLink{Name: root},
+			},
+			Cids:            []string{outside},
+			InclusionProofs: []*pb.MerkleInclusionProof{proof},
+		}
+		Expect(subset.EnsureAdvertisementValid(ad)).To(Not(Succeed()))
+	})
+	It("should return the recorded proofs of serve_subset from SearchCid", func() {
+		conf := *Conf
+		conf.EnabledBehaviors = []string{api.SERVE_SUBSET_BEHAVIOR_NAME}
+		conf.BehaviorMaintainer = maintainer
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+
+		cids := []string{
+			api.HashRawBytes([]byte("subset " + uuid.NewString())),
+			api.HashRawBytes([]byte("subset " + uuid.NewString())),
+			api.HashRawBytes([]byte("subset " + uuid.NewString())),
+		}
+		mt, err := merkle.NewCidTree(cids)
+		Expect(err).To(BeNil())
+		root, err := merkle.RootCid(mt)
+		Expect(err).To(BeNil())
+		proof, err := merkle.GenerateInclusionProof(mt, cids[1])
+		Expect(err).To(BeNil())
+		_, err = server.RegisterInstance(ctx, connect.NewRequest(&pb.RegisterInstanceRequest{
+			Advertisement: &pb.ProviderAdvertise{
+				ProviderInstance: &pb.Instance{Did: "did:example:subset-" + uuid.NewString()},
+				VirtualService: &pb.VirtualService{
+					BehaviorLink: &pb.GlobalLink{
+						Name:       api.SERVE_SUBSET_BEHAVIOR_NAME,
+						Maintainer: maintainer,
+						Version:    api.SERVE_SUBSET_BEHAVIOR_VERSION,
+					},
+					VariantLink: &pb.GlobalLink{Name: root},
+				},
+				Cids:            cids[1:2],
+				InclusionProofs: []*pb.MerkleInclusionProof{proof},
+			},
+		}))
+		Expect(err).To(BeNil())
+
+		resp, err := server.SearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{Cid: cids[1]}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetVirtualServices()).To(HaveLen(1))
+		Expect(resp.Msg.GetInclusionProofs()).To(HaveLen(1))
+		Expect(merkle.VerifyInclusionProof(
+			cids[1], resp.Msg.GetVirtualServices()[0], resp.Msg.GetInclusionProofs()[0],
+		)).To(Succeed())
+	})
+
+	It("should find an instance registered in several virtual services in each", func() {
+		conf := *Conf
+		conf.EnabledBehaviors = []string{api.SERVE_ALL_BEHAVIOR_NAME}
+		conf.BehaviorMaintainer = api.SERVE_ALL_BEHAVIOR_MAINTAINER
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+
+		did := "did:example:both-" + uuid.NewString()
+		register := func() []string {
+			cids := []string{
+				api.HashRawBytes([]byte("vsvc " + uuid.NewString())),
+				api.HashRawBytes([]byte("vsvc " + uuid.NewString())),
+			}
+			root, err := api.CalculateMerkleRoot(cids)
+			Expect(err).To(BeNil())
+			_, err = server.RegisterInstance(ctx, connect.NewRequest(&pb.RegisterInstanceRequest{
+				Advertisement: &pb.ProviderAdvertise{
+					ProviderInstance: &pb.Instance{Did: did},
+					VirtualService: &pb.VirtualService{
+						BehaviorLink: api.ServeAllBehavior,
+						VariantLink:  &pb.GlobalLink{Name: root},
+					},
+					Cids: cids,
+				},
+			}))
+			Expect(err).To(BeNil())
+			return cids
+		}
+		first := register()
+		second := register()
+
+		for _, c := range []string{first[0], second[0]} {
+			resp, err := server.SearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{Cid: c}))
+			Expect(err).To(BeNil())
+			Expect(resp.Msg.GetStorageInstances()).To(HaveLen(1))
+			Expect(resp.Msg.GetStorageInstances()[0].GetProviderInstance().GetDid()).To(Equal(did))
+			Expect(resp.Msg.GetStorageInstances()[0].GetCids()).To(ContainElement(c))
+		}
+	})
+})
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	bsmsg "github.com/ipfs/boxo/bitswap/message"
+	bsnetwork "github.com/ipfs/boxo/bitswap/network"
+	bsnet "github.com/ipfs/boxo/bitswap/network/bsnet"
+	bsserver "github.com/ipfs/boxo/bitswap/server"
+	"github.com/ipfs/boxo/blockstore"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Pkv extension for peers to bind a session token to their peer id, so
+	// that blocks sent to them over bitswap are charged to the session
+	PKV_BITSWAP_SESSION_PROTOCOL = "/pkv/bitswap-session/1.0.0"
+
+	bitswapSessionMaxTokenSize = 4096
+	bitswapSessionTimeout      = 10 * time.Second
+	// A block paid for is not charged again to the peer within the window
+	bitswapPaidWantWindow = time.Minute
+)
+
+var errReadOnlyBlockstore = fmt.Errorf("value store is read only over bitswap")
+
+// RedisBlockstore exposes the values as blocks, looking them up by their
+// CIDv1 so that CIDv0 wants are served as well. Writes are rejected since
+// values are only created through the paid API.
+type RedisBlockstore struct {
+	redisClient *redis.Client
+	hashOnRead  bool
+}
+
+func NewRedisBlockstore(redisClient *redis.Client) *RedisBlockstore {
+	return &RedisBlockstore{
+		redisClient: redisClient,
+	}
+}
+
+// HashOnRead makes Get rehash blocks and fail with ErrHashMismatch on
+// corrupted ones.
+func (b *RedisBlockstore) HashOnRead(enabled bool) {
+	b.hashOnRead = enabled
+}
+
+// newBlockstore returns the blockstore of the values honoring VERIFY_ON_READ.
+func (s *Server) newBlockstore() *RedisBlockstore {
+	b := NewRedisBlockstore(s.redisClient)
+	b.HashOnRead(s.config.VerifyOnRead)
+	return b
+}
+
+func valueKey(c cid.Cid) (string, error) {
+	cidV1, err := NormalizeCidToV1(c.String())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("values/%s", cidV1), nil
+}
+
+func (b *RedisBlockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	key, err := valueKey(c)
+	if err != nil {
+		return false, nil
+	}
+	count, err := b.redisClient.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (b *RedisBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	key, err := valueKey(c)
+	if err != nil {
+		return nil, ipld.ErrNotFound{Cid: c}
+	}
+	value, err := getValue(ctx, b.redisClient, key)
+	if err == redis.Nil {
+		return nil, ipld.ErrNotFound{Cid: c}
+	} else if err != nil {
+		return nil, err
+	}
+	if b.hashOnRead {
+		if err := verifyValue(key, value); err != nil {
+			corruptedValuesDetected.WithLabelValues(CORRUPTION_SOURCE_READ).Inc()
+			log.Printf("corrupted block detected on read: %v", err)
+			return nil, blockstore.ErrHashMismatch
+		}
+	}
+	return blocks.NewBlockWithCid(value, c)
+}
+
+func (b *RedisBlockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	key, err := valueKey(c)
+	if err != nil {
+		return -1, ipld.ErrNotFound{Cid: c}
+	}
+	pipe := b.redisClient.Pipeline()
+	metadata := queueValueMetadata(ctx, pipe, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return -1, err
+	}
+	ret, err := metadata()
+	if status.Code(err) == codes.NotFound {
+		return -1, ipld.ErrNotFound{Cid: c}
+	} else if err != nil {
+		return -1, err
+	}
+	return int(ret.GetSize()), nil
+}
+
+func (b *RedisBlockstore) DeleteBlock(context.Context, cid.Cid) error {
+	return errReadOnlyBlockstore
+}
+
+func (b *RedisBlockstore) Put(context.Context, blocks.Block) error {
+	return errReadOnlyBlockstore
+}
+
+func (b *RedisBlockstore) PutMany(context.Context, []blocks.Block) error {
+	return errReadOnlyBlockstore
+}
+
+func (b *RedisBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	out := make(chan cid.Cid)
+	go func() {
+		defer close(out)
+		iter := b.redisClient.Scan(ctx, 0, "values/*", 0).Iterator()
+		for iter.Next(ctx) {
+			c, err := cid.Decode(strings.TrimPrefix(iter.Val(), "values/"))
+			if err != nil {
+				continue
+			}
+			select {
+			case out <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// BitswapServer answers the wants of IPFS peers from the value store. Peers
+// that bound a session through PKV_BITSWAP_SESSION_PROTOCOL are charged for
+// every block wanted before it is sent. The others share a small free allowance or are
+// refused.
+type BitswapServer struct {
+	host                host.Host
+	network             bsnetwork.BitSwapNetwork
+	server              *bsserver.Server
+	blockstore          *RedisBlockstore
+	redisClient         *redis.Client
+	authManager         middleware.IAuthManager
+	sessionManager      middleware.ISessionManager
+	blockPrice          int64
+	freeBlocksPerMinute int64
+}
+
+func NewBitswapServer(ctx context.Context, h host.Host, s *Server) *BitswapServer {
+	b := &BitswapServer{
+		host:                h,
+		network:             bsnet.NewFromIpfsHost(h),
+		blockstore:          s.newBlockstore(),
+		redisClient:         s.redisClient,
+		authManager:         s.authmanager,
+		sessionManager:      s.sessionManager,
+		blockPrice:          s.config.BitswapBlockPrice,
+		freeBlocksPerMinute: s.config.BitswapFreeBlocksPerMinute,
+	}
+	b.server = bsserver.New(
+		ctx,
+		b.network,
+		b.blockstore,
+		bsserver.WithPeerBlockRequestFilter(b.allowRequest),
+	)
+	h.SetStreamHandler(PKV_BITSWAP_SESSION_PROTOCOL, b.handleSessionStream)
+	b.network.Start(b)
+	return b
+}
+
+func (b *BitswapServer) Close() {
+	b.host.RemoveStreamHandler(PKV_BITSWAP_SESSION_PROTOCOL)
+	b.network.Stop()
+	b.server.Close()
+}
+
+func bitswapSessionKey(p peer.ID) string {
+	return fmt.Sprintf("bitswap:session:%s", p)
+}
+
+func bitswapPaidWantKey(p peer.ID, c cid.Cid) string {
+	return fmt.Sprintf("bitswap:paid:%s:%s", p, c.Hash().B58String())
+}
+
+func bitswapFreeQuotaKey(p peer.ID, window int64) string {
+	return fmt.Sprintf("bitswap:free:%s:%d", p, window)
+}
+
+// BindSession verifies a session token and charges the blocks later sent to
+// the peer to it until the token expires.
+func (b *BitswapServer) BindSession(ctx context.Context, p peer.ID, token string) error {
+	sessionClaims, err := middleware.VerifySessionToken(b.authManager, token)
+	if err != nil {
+		return err
+	}
+	if sessionClaims.ExpiresAt == nil {
+		return fmt.Errorf("session token does not expire")
+	}
+	if _, err := b.sessionManager.GetSession(ctx, sessionClaims.Subject); err != nil {
+		return err
+	}
+	return b.redisClient.Set(
+		ctx,
+		bitswapSessionKey(p),
+		sessionClaims.Subject,
+		time.Until(sessionClaims.ExpiresAt.Time),
+	).Err()
+}
+
+// The peer writes its session token on a line and reads back "ok" or the
+// reason it was rejected.
+func (b *BitswapServer) handleSessionStream(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(bitswapSessionTimeout))
+	reader := bufio.NewReaderSize(stream, bitswapSessionMaxTokenSize)
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		stream.Reset()
+		return
+	}
+	token := strings.TrimSpace(string(line))
+	if err := b.BindSession(context.Background(), stream.Conn().RemotePeer(), token); err != nil {
+		fmt.Fprintf(stream, "error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(stream, "ok")
+}
+
+func (b *BitswapServer) sessionOf(ctx context.Context, p peer.ID) (string, error) {
+	return b.redisClient.Get(ctx, bitswapSessionKey(p)).Result()
+}
+
+// allowRequest is consulted for every wanted CID before it is queued. Peers
+// with a bound session pay for the block here, once per
+// bitswapPaidWantWindow so that the want-have and want-block of a block are
+// charged once, and are refused when the session cannot pay. Other peers fall
+// back to the free allowance.
+func (b *BitswapServer) allowRequest(p peer.ID, c cid.Cid) bool {
+	ctx := context.Background()
+	if sessionId, err := b.sessionOf(ctx, p); err == nil {
+		return b.chargeWant(ctx, p, sessionId, c)
+	} else if err != redis.Nil {
+		log.Printf("failed to get bitswap session of %s: %v", p, err)
+		return false
+	}
+	if b.freeBlocksPerMinute <= 0 {
+		return false
+	}
+	key := bitswapFreeQuotaKey(p, time.Now().Unix()/60)
+	pipe := b.redisClient.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("failed to count free bitswap request of %s: %v", p, err)
+		return false
+	}
+	return count.Val() <= b.freeBlocksPerMinute
+}
+
+// chargeWant deducts the block price from the session unless the block was
+// paid for recently or is not stored, in which case only a DONT_HAVE is sent.
+func (b *BitswapServer) chargeWant(ctx context.Context, p peer.ID, sessionId string, c cid.Cid) bool {
+	if b.blockPrice <= 0 {
+		return true
+	}
+	has, err := b.blockstore.Has(ctx, c)
+	if err != nil {
+		log.Printf("failed to check bitswap block %s: %v", c, err)
+		return false
+	}
+	if !has {
+		return true
+	}
+	paidKey := bitswapPaidWantKey(p, c)
+	fresh, err := b.redisClient.SetNX(ctx, paidKey, sessionId, bitswapPaidWantWindow).Result()
+	if err != nil {
+		log.Printf("failed to reserve bitswap block %s for %s: %v", c, p, err)
+		return false
+	}
+	if !fresh {
+		return true
+	}
+	if _, err := b.sessionManager.DeductSessionBalance(ctx, sessionId, b.blockPrice); err != nil {
+		b.redisClient.Del(ctx, paidKey)
+		log.Printf("refusing bitswap block %s to %s: %v", c, p, err)
+		return false
+	}
+	return true
+}
+
+func (b *BitswapServer) ReceiveMessage(ctx context.Context, p peer.ID, incoming bsmsg.BitSwapMessage) {
+	b.server.ReceiveMessage(ctx, p, incoming)
+}
+
+func (b *BitswapServer) ReceiveError(err error) {
+	log.Printf("bitswap: %v", err)
+}
+
+func (b *BitswapServer) PeerConnected(p peer.ID) {
+	b.server.PeerConnected(p)
+}
+
+func (b *BitswapServer) PeerDisconnected(p peer.ID) {
+	b.server.PeerDisconnected(p)
+}
+
+// BindBitswapSession presents a session token to the server peer so that
+// its blocks are paid from the session.
+func BindBitswapSession(ctx context.Context, h host.Host, server peer.ID, token string) error {
+	stream, err := h.NewStream(ctx, server, PKV_BITSWAP_SESSION_PROTOCOL)
+	if err != nil {
+		return fmt.Errorf("failed to open session stream: %v", err)
+	}
+	defer stream.Close()
+	if _, err := fmt.Fprintln(stream, token); err != nil {
+		return fmt.Errorf("failed to send token: %v", err)
+	}
+	reply, err := bufio.NewReader(stream).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read reply: %v", err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return fmt.Errorf("session rejected: %s", reply)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	bsclient "github.com/ipfs/boxo/bitswap/client"
+	bsnet "github.com/ipfs/boxo/bitswap/network/bsnet"
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/host"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serve blocks over bitswap", Label("bitswap"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+
+	var server *api.Server
+	var serverHost, clientHost host.Host
+	var client *bsclient.Client
+	var blockCid cid.Cid
+	value := []byte("served over bitswap " + uuid.NewString())
+
+	start := func(conf *api.Config) {
+		var err error
+		server, err = api.NewServer(conf)
+		Expect(err).To(BeNil())
+
+		mn := mocknet.New()
+		DeferCleanup(mn.Close)
+		serverHost, err = mn.GenPeer()
+		Expect(err).To(BeNil())
+		clientHost, err = mn.GenPeer()
+		Expect(err).To(BeNil())
+		Expect(mn.LinkAll()).To(Succeed())
+
+		bitswapServer := api.NewBitswapServer(ctx, serverHost, server)
+		DeferCleanup(bitswapServer.Close)
+		clientNet := bsnet.NewFromIpfsHost(clientHost)
+		client = bsclient.New(
+			ctx, clientNet, nil,
+			blockstore.NewBlockstore(dssync.MutexWrap(datastore.NewMapDatastore())),
+		)
+		clientNet.Start(client)
+		DeferCleanup(client.Close)
+		Expect(mn.ConnectAllButSelf()).To(Succeed())
+	}
+
+	getBlock := func() ([]byte, error) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		block, err := client.GetBlock(timeoutCtx, blockCid)
+		if err != nil {
+			return nil, err
+		}
+		return block.RawData(), nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		blockCid, err = cid.Decode(api.HashRawBytes(value))
+		Expect(err).To(BeNil())
+		Expect(RedisClient.Set(ctx, "values/"+blockCid.String(), value, time.Minute).Err()).To(Succeed())
+	})
+
+	It("should charge blocks to the bound session", func() {
+		start(Conf)
+		_, err := getBlock()
+		Expect(err).To(Not(BeNil()))
+
+		Expect(api.BindBitswapSession(ctx, clientHost, serverHost.ID(), "not a token")).To(Not(Succeed()))
+
+		sessionResp, err := server.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaToken(uuid.NewString()),
+		}))
+		Expect(err).To(BeNil())
+		sessionId := sessionResp.Msg.GetSession().GetSessionId()
+		Expect(api.BindBitswapSession(ctx, clientHost, serverHost.ID(), sessionResp.Msg.GetJwt())).To(Succeed())
+
+		data, err := getBlock()
+		Expect(err).To(BeNil())
+		Expect(data).To(Equal(value))
+
+		sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		Eventually(func() int64 {
+			session, err := sessionManager.GetSession(ctx, sessionId)
+			Expect(err).To(BeNil())
+			return session.GetBalance()
+		}).Should(Equal(sessionResp.Msg.GetSession().GetBalance() - Conf.BitswapBlockPrice))
+	})
+
+	It("should refuse blocks the bound session cannot pay for", func() {
+		start(Conf)
+		sessionResp, err := server.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaToken(uuid.NewString()),
+		}))
+		Expect(err).To(BeNil())
+		session := sessionResp.Msg.GetSession()
+		Expect(api.BindBitswapSession(ctx, clientHost, serverHost.ID(), sessionResp.Msg.GetJwt())).To(Succeed())
+
+		sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		_, err = sessionManager.DeductSessionBalance(ctx, session.GetSessionId(), session.GetBalance())
+		Expect(err).To(BeNil())
+		_, err = getBlock()
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should serve unpaid peers within the free allowance", func() {
+		conf := *Conf
+		conf.BitswapFreeBlocksPerMinute = 1
+		start(&conf)
+		data, err := getBlock()
+		Expect(err).To(BeNil())
+		Expect(data).To(Equal(value))
+	})
+
+	It("should serve CIDv0 wants of dag-pb values", func() {
+		conf := *Conf
+		conf.BitswapFreeBlocksPerMinute = 1
+		start(&conf)
+		node := merkledag.NodeWithData([]byte("dag-pb over bitswap " + uuid.NewString()))
+		cidV1 := cid.NewCidV1(cid.DagProtobuf, node.Cid().Hash())
+		Expect(RedisClient.Set(ctx, "values/"+cidV1.String(), node.RawData(), time.Minute).Err()).To(Succeed())
+
+		blockCid = node.Cid()
+		Expect(blockCid.Version()).To(Equal(uint64(0)))
+		data, err := getBlock()
+		Expect(err).To(BeNil())
+		Expect(data).To(Equal(node.RawData()))
+	})
+})
@@ -0,0 +1,362 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// Size of the chunks ExportCar streams
+	carExportChunkSize = 1 << 20
+	// Pragma and fixed size header preceding the CARv1 payload of a CARv2
+	carV2HeaderSize = 40
+	// Sections hold a single block, larger ones are rejected
+	carMaxSectionSize = 8 << 20
+)
+
+// A CARv2 starts with this CARv1 header of version 2
+var carV2Pragma = []byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02}
+
+// chargeSession deducts price from the session of the call, authenticated
+// by the session interceptors or authenticateHttpSession. Nothing is charged
+// when auth is disabled, and sessions that cannot pay are denied.
+func (s *Server) chargeSession(ctx context.Context, price int64) error {
+	session, ok := ctx.Value(middleware.KeySession).(*pb.Session)
+	if !ok {
+		return nil
+	}
+	if price <= 0 {
+		return nil
+	}
+	if _, err := s.sessionManager.DeductSessionBalance(ctx, session.GetSessionId(), price); errors.Is(err, middleware.ErrInsufficientBalance) {
+		return status.Errorf(codes.PermissionDenied, "failed to deduct: %v", err)
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "failed to deduct: %v", err)
+	}
+	middleware.RecordCharge(ctx, price)
+	return nil
+}
+
+// authenticateHttpSession authenticates the session token in the
+// Authorization header of plain HTTP endpoints and leaves the session in the
+// returned context for chargeSession, like the Connect interceptors do.
+func (s *Server) authenticateHttpSession(r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+	if s.config.DisableAuth {
+		return ctx, nil
+	}
+	authString := r.Header.Get("Authorization")
+	if authString == "" {
+		return nil, status.Error(codes.Unauthenticated, "session bearer token required")
+	}
+	claims, err := middleware.AuthenticateSession(s.authmanager, authString)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.sessionManager.IsSessionRevoked(ctx, claims.Subject)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check session revocation: %v", err)
+	}
+	if revoked {
+		return nil, status.Error(codes.PermissionDenied, "session revoked")
+	}
+	return context.WithValue(ctx, middleware.KeySession, &pb.Session{SessionId: claims.Subject}), nil
+}
+
+// billedSize picks the size storage is priced by.
+func (s *Server) billedSize(size int64, storedSize int64) int64 {
+	if s.config.PricingSizeBasis == middleware.PRICING_SIZE_BASIS_STORED {
+		return storedSize
+	}
+	return size
+}
+
+// chargeStream deducts the price of a streaming call, or of a value priced by
+// its stored size, once the size is known.
+func (s *Server) chargeStream(ctx context.Context, procedure string, size int64) error {
+	price, err := s.pricingManager.GetStreamPrice(procedure, size)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get price: %v", err)
+	}
+	return s.chargeSession(ctx, price)
+}
+
+// importCarReader concatenates the chunks of an ImportCar stream.
+type importCarReader struct {
+	stream  *connect.ClientStream[pb.ImportCarRequest]
+	ttl     *durationpb.Duration
+	pending []byte
+	read    int64
+	maxSize int64
+	// Set when the stream itself is rejected rather than the car content
+	err error
+}
+
+func (r *importCarReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if !r.stream.Receive() {
+			if err := r.stream.Err(); err != nil {
+				r.err = err
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		if r.ttl == nil {
+			r.ttl = r.stream.Msg().GetTtl()
+			if r.ttl.AsDuration() <= 0 {
+				r.err = status.Error(codes.InvalidArgument, "ttl must be set in the first message")
+				return 0, r.err
+			}
+		}
+		r.pending = r.stream.Msg().GetChunk()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	r.read += int64(n)
+	if r.read > r.maxSize {
+		r.err = status.Errorf(codes.ResourceExhausted, "car exceeds %d bytes", r.maxSize)
+		return n, r.err
+	}
+	return n, nil
+}
+
+// carV1Payload returns the CARv1 stream in r, skipping the CARv2 header and
+// ignoring the index that may follow the payload.
+func carV1Payload(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	pragma, err := br.Peek(len(carV2Pragma))
+	if err != nil || !bytes.Equal(pragma, carV2Pragma) {
+		return br, nil
+	}
+	if _, err := br.Discard(len(carV2Pragma)); err != nil {
+		return nil, err
+	}
+	header := make([]byte, carV2HeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read carv2 header: %v", err)
+	}
+	dataOffset := binary.LittleEndian.Uint64(header[16:24])
+	dataSize := binary.LittleEndian.Uint64(header[24:32])
+	consumed := uint64(len(carV2Pragma) + carV2HeaderSize)
+	if dataOffset < consumed {
+		return nil, fmt.Errorf("invalid carv2 data offset %d", dataOffset)
+	}
+	if _, err := br.Discard(int(dataOffset - consumed)); err != nil {
+		return nil, fmt.Errorf("failed to skip to carv2 payload: %v", err)
+	}
+	return io.LimitReader(br, int64(dataSize)), nil
+}
+
+// readCarV1Header returns the roots of a CARv1 header.
+func readCarV1Header(br *bufio.Reader) ([]cid.Cid, error) {
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header size: %v", err)
+	}
+	if size == 0 || size > carMaxSectionSize {
+		return nil, fmt.Errorf("invalid header size %d", size)
+	}
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := dagcbor.Decode(nb, io.LimitReader(br, int64(size))); err != nil {
+		return nil, fmt.Errorf("failed to decode header: %v", err)
+	}
+	header := nb.Build()
+	versionNode, err := header.LookupByString("version")
+	if err != nil {
+		return nil, fmt.Errorf("header without version: %v", err)
+	}
+	if version, err := versionNode.AsInt(); err != nil || version != 1 {
+		return nil, fmt.Errorf("unsupported car version")
+	}
+	rootsNode, err := header.LookupByString("roots")
+	if err != nil {
+		return nil, fmt.Errorf("header without roots: %v", err)
+	}
+	roots := make([]cid.Cid, 0, rootsNode.Length())
+	iter := rootsNode.ListIterator()
+	for iter != nil && !iter.Done() {
+		_, rootNode, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		link, err := rootNode.AsLink()
+		if err != nil {
+			return nil, fmt.Errorf("invalid root: %v", err)
+		}
+		roots = append(roots, link.(cidlink.Link).Cid)
+	}
+	return roots, nil
+}
+
+// readCarBlocks verifies every block against its CID and accepts the codecs
+// CreateValue accepts.
+func readCarBlocks(r io.Reader) ([]cid.Cid, []blocks.Block, error) {
+	payload, err := carV1Payload(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(payload)
+	roots, err := readCarV1Header(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	ret := make([]blocks.Block, 0)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read section size: %v", err)
+		}
+		if size == 0 || size > carMaxSectionSize {
+			return nil, nil, fmt.Errorf("invalid section size %d", size)
+		}
+		section := make([]byte, size)
+		if _, err := io.ReadFull(br, section); err != nil {
+			return nil, nil, fmt.Errorf("failed to read section: %v", err)
+		}
+		cidSize, c, err := cid.CidFromBytes(section)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cid: %v", err)
+		}
+		block, err := blocks.NewBlockWithCid(section[cidSize:], c)
+		if err != nil {
+			return nil, nil, err
+		}
+		computed, err := c.Prefix().Sum(block.RawData())
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %s: %v", c, err)
+		}
+		if !computed.Equals(c) {
+			return nil, nil, fmt.Errorf("block %s does not match its content", c)
+		}
+		if err := validateBlock(c, block.RawData()); err != nil {
+			return nil, nil, fmt.Errorf("block %s: %v", c, err)
+		}
+		ret = append(ret, block)
+	}
+	return roots, ret, nil
+}
+
+func (s *Server) ImportCar(
+	ctx context.Context, stream *connect.ClientStream[pb.ImportCarRequest],
+) (*connect.Response[pb.ImportCarResponse], error) {
+	reader := &importCarReader{
+		stream:  stream,
+		maxSize: s.config.CarImportMaxSize,
+	}
+	roots, blks, err := readCarBlocks(reader)
+	if reader.err != nil {
+		return nil, reader.err
+	} else if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to read car: %v", err)
+	}
+
+	totalSize := int64(0)
+	totalStoredSize := int64(0)
+	pipe := s.redisClient.Pipeline()
+	for _, block := range blks {
+		key, err := valueKey(block.Cid())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cid %s: %v", block.Cid(), err)
+		}
+		storedSize, _, _ := s.storeValue(ctx, pipe, key, block.RawData(), reader.ttl.AsDuration())
+		totalSize += int64(len(block.RawData()))
+		totalStoredSize += storedSize
+	}
+	if err := s.chargeStream(
+		ctx,
+		kvstoreconnect.KvStoreServiceImportCarProcedure,
+		s.billedSize(totalSize, totalStoredSize),
+	); err != nil {
+		return nil, err
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, status.Error(codes.Internal, "failed to set values")
+	}
+
+	rootNames := make([]string, 0, len(roots))
+	for _, root := range roots {
+		key, err := valueKey(root)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid root %s: %v", root, err)
+		}
+		rootNames = append(rootNames, key)
+	}
+	return connect.NewResponse(&pb.ImportCarResponse{
+		Roots:      rootNames,
+		BlockCount: int64(len(blks)),
+		TotalSize:  totalSize,
+		Ttl:        reader.ttl,
+	}), nil
+}
+
+// exportCarWriter sends everything written as ExportCar chunks.
+type exportCarWriter struct {
+	stream *connect.ServerStream[pb.ExportCarResponse]
+}
+
+func (w *exportCarWriter) Write(p []byte) (int, error) {
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	if err := w.stream.Send(&pb.ExportCarResponse{Chunk: chunk}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *Server) ExportCar(
+	ctx context.Context,
+	connectReq *connect.Request[pb.ExportCarRequest],
+	stream *connect.ServerStream[pb.ExportCarResponse],
+) error {
+	req := connectReq.Msg
+	root, err := cid.Decode(req.GetName()[len("values/"):])
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid cid: %v", err)
+	}
+	blockstore := s.newBlockstore()
+	if found, err := blockstore.Has(ctx, root); err != nil {
+		return status.Error(codes.Internal, "failed to get value")
+	} else if !found {
+		return status.Error(codes.NotFound, "resource not found")
+	}
+	w := bufio.NewWriterSize(&exportCarWriter{stream: stream}, carExportChunkSize)
+	if err := writeCarV1Header(w, root); err != nil {
+		return status.Errorf(codes.Internal, "failed to write car: %v", err)
+	}
+	// Every block is charged by its size before it is written. Blocks
+	// missing below the root or a session running out of balance end the
+	// stream with an error after the blocks already paid for.
+	walkErr := walkDag(ctx, blockstore, root, GATEWAY_DAG_SCOPE_ALL, func(block blocks.Block) error {
+		if err := s.chargeStream(
+			ctx, kvstoreconnect.KvStoreServiceExportCarProcedure, int64(len(block.RawData())),
+		); err != nil {
+			return err
+		}
+		return writeCarV1Block(w, block)
+	}, nil)
+	if err := w.Flush(); err != nil {
+		return status.Errorf(codes.Internal, "failed to send car: %v", err)
+	}
+	return walkErr
+}
@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// carV2Of wraps a CARv1 payload into a CARv2 without index.
+func carV2Of(payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02})
+	header := make([]byte, 40)
+	binary.LittleEndian.PutUint64(header[16:24], 51)
+	binary.LittleEndian.PutUint64(header[24:32], uint64(len(payload)))
+	buf.Write(header)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+var _ = Describe("Import and export car files", Label("car"), func() {
+	ctx := context.Background()
+	sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+
+	var token string
+	var sessionId string
+	var rootCid, leafCid cid.Cid
+	var carFile []byte
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+
+		leaf := merkledag.NewRawNode([]byte("car leaf " + uuid.NewString()))
+		leafCid = leaf.Cid()
+		root := merkledag.NodeWithData([]byte("car root"))
+		Expect(root.AddNodeLink("leaf", leaf)).To(Succeed())
+		rootCid = cid.NewCidV1(cid.DagProtobuf, root.Cid().Hash())
+		rootBlock, err := blocks.NewBlockWithCid(root.RawData(), rootCid)
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(api.WriteCarV1(&buf, rootCid, []blocks.Block{rootBlock, leaf})).To(Succeed())
+		carFile = buf.Bytes()
+	})
+
+	importCar := func(chunks [][]byte, ttl time.Duration) (*pb.ImportCarResponse, error) {
+		stream := testClient.ImportCar(ctx)
+		stream.RequestHeader().Set("Authorization", "Bearer "+token)
+		for i, chunk := range chunks {
+			req := &pb.ImportCarRequest{Chunk: chunk}
+			if i == 0 && ttl > 0 {
+				req.Ttl = durationpb.New(ttl)
+			}
+			if err := stream.Send(req); err != nil {
+				break
+			}
+		}
+		resp, err := stream.CloseAndReceive()
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	exportCar := func(name string) ([]byte, error) {
+		req := connect.NewRequest(&pb.ExportCarRequest{Name: name})
+		req.Header().Set("Authorization", "Bearer "+token)
+		stream, err := testClient.ExportCar(ctx, req)
+		Expect(err).To(BeNil())
+		defer stream.Close()
+		var buf bytes.Buffer
+		for stream.Receive() {
+			buf.Write(stream.Msg().GetChunk())
+		}
+		return buf.Bytes(), stream.Err()
+	}
+
+	It("should store every block and charge the total size", func() {
+		before := sessionBalance(ctx, sessionId)
+
+		resp, err := importCar([][]byte{carFile[:10], carFile[10:]}, time.Minute)
+		Expect(err).To(BeNil())
+		Expect(resp.GetRoots()).To(Equal([]string{"values/" + rootCid.String()}))
+		Expect(resp.GetBlockCount()).To(Equal(int64(2)))
+
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - resp.GetTotalSize()))
+
+		getReq := connect.NewRequest(&pb.GetValueRequest{Name: "values/" + leafCid.String()})
+		getReq.Header().Set("Authorization", "Bearer "+token)
+		_, err = testClient.GetValue(ctx, getReq)
+		Expect(err).To(BeNil())
+
+		before = sessionBalance(ctx, sessionId)
+		exported, err := exportCar("values/" + rootCid.String())
+		Expect(err).To(BeNil())
+		Expect(readCarV1(bytes.NewReader(exported))).To(Equal([]cid.Cid{rootCid, leafCid}))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - resp.GetTotalSize()))
+	})
+
+	It("should stop exporting when the session runs out of balance", func() {
+		_, err := importCar([][]byte{carFile}, time.Minute)
+		Expect(err).To(BeNil())
+		_, err = sessionManager.DeductSessionBalance(ctx, sessionId, sessionBalance(ctx, sessionId))
+		Expect(err).To(BeNil())
+
+		exported, err := exportCar("values/" + rootCid.String())
+		Expect(err).To(Not(BeNil()))
+		Expect(readCarV1(bytes.NewReader(exported))).To(BeEmpty())
+	})
+
+	It("should accept carv2 files", func() {
+		resp, err := importCar([][]byte{carV2Of(carFile)}, time.Minute)
+		Expect(err).To(BeNil())
+		Expect(resp.GetBlockCount()).To(Equal(int64(2)))
+	})
+
+	It("should reject invalid imports", func() {
+		_, err := importCar([][]byte{carFile}, 0)
+		Expect(err).To(Not(BeNil()))
+
+		tampered := bytes.Clone(carFile)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err = importCar([][]byte{tampered}, time.Minute)
+		Expect(err).To(Not(BeNil()))
+
+		token = "invalid"
+		_, err = importCar([][]byte{carFile}, time.Minute)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should fail exports of missing values", func() {
+		_, err := exportCar("values/" + api.HashRawBytes([]byte("not stored "+uuid.NewString())))
+		Expect(err).To(Not(BeNil()))
+	})
+})
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// challengeMinNonceSize keeps nonces hard enough to guess that digests cannot
+// be prepared before the value is dropped.
+const challengeMinNonceSize = 16
+
+// ChallengeValue proves a value is still stored without downloading it. The
+// answer is computed over the value as stored now, so a client checking it
+// against what it uploaded notices values lost or altered by the operator.
+func (s *Server) ChallengeValue(
+	ctx context.Context, connectReq *connect.Request[pb.ChallengeValueRequest],
+) (*connect.Response[pb.ChallengeValueResponse], error) {
+	req := connectReq.Msg
+	if len(req.GetOffsets()) == 0 && len(req.GetChunkIndices()) == 0 {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"expected offsets and/or chunk indices to challenge",
+		)
+	}
+	if len(req.GetOffsets()) > 0 {
+		if len(req.GetNonce()) < challengeMinNonceSize {
+			return nil, status.Errorf(
+				codes.InvalidArgument,
+				"nonce must be at least %d bytes",
+				challengeMinNonceSize,
+			)
+		}
+		if req.GetSegmentSize() <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "segment size must be positive")
+		}
+	}
+	key, err := normalizeValueName(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	value, err := getValue(ctx, s.redisClient, key)
+	if err == redis.Nil {
+		return nil, status.Error(
+			codes.NotFound,
+			"resource not found",
+		)
+	} else if err != nil {
+		return nil, status.Errorf(
+			codes.DataLoss,
+			"failed to get value: %v",
+			err,
+		)
+	}
+	chunkRoot, err := s.redisClient.HGet(ctx, valueMetadataKey(key), valueMetadataChunkRoot).Bytes()
+	if err != nil && err != redis.Nil {
+		return nil, status.Error(codes.Internal, "failed to get metadata")
+	}
+
+	ret := &pb.ChallengeValueResponse{
+		Name:        key,
+		ChunkRoot:   chunkRoot,
+		ChunkProofs: make([]*pb.ChunkProof, 0, len(req.GetChunkIndices())),
+	}
+	if len(req.GetOffsets()) > 0 {
+		digest, err := merkle.SegmentsDigest(value, req.GetNonce(), req.GetOffsets(), req.GetSegmentSize())
+		if err != nil {
+			return nil, status.Errorf(codes.OutOfRange, "failed to hash segments: %v", err)
+		}
+		ret.SegmentsDigest = digest
+	}
+	if len(req.GetChunkIndices()) > 0 {
+		if len(chunkRoot) == 0 {
+			return nil, status.Error(
+				codes.FailedPrecondition,
+				"no chunk root recorded for the value",
+			)
+		}
+		mt, err := merkle.NewChunkTree(value)
+		if err != nil {
+			return nil, status.Errorf(codes.DataLoss, "failed to build chunk tree: %v", err)
+		}
+		for _, index := range req.GetChunkIndices() {
+			proof, err := merkle.GenerateChunkProof(mt, value, index)
+			if err != nil {
+				return nil, status.Errorf(codes.OutOfRange, "failed to prove chunk: %v", err)
+			}
+			ret.ChunkProofs = append(ret.ChunkProofs, proof)
+		}
+	}
+	return connect.NewResponse(ret), nil
+}
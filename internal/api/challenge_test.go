@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Storage challenges", Label("challenge"), func() {
+	ctx := context.Background()
+
+	var token string
+	var sessionId string
+	var name string
+	var value, root []byte
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+
+		value = make([]byte, 3*merkle.CHUNK_SIZE+100)
+		_, err := rand.Read(value)
+		Expect(err).To(BeNil())
+		root, err = merkle.ChunkRoot(value)
+		Expect(err).To(BeNil())
+		req := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Minute),
+		})
+		req.Header().Set("Authorization", "Bearer "+token)
+		created, err := testClient.CreateValue(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(created.Msg.GetChunkRoot()).To(Equal(root))
+		name = created.Msg.GetName()
+	})
+
+	challenge := func(msg *pb.ChallengeValueRequest) (*pb.ChallengeValueResponse, error) {
+		msg.Name = name
+		req := connect.NewRequest(msg)
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.ChallengeValue(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	It("should answer segment digests salted by the nonce", func() {
+		nonce := []byte(uuid.NewString())
+		offsets := []int64{0, 1500, int64(len(value) - 10)}
+		resp, err := challenge(&pb.ChallengeValueRequest{
+			Nonce:       nonce,
+			Offsets:     offsets,
+			SegmentSize: 64,
+		})
+		Expect(err).To(BeNil())
+		Expect(merkle.VerifySegmentsDigest(value, nonce, offsets, 64, resp.GetSegmentsDigest())).To(Succeed())
+		Expect(merkle.VerifySegmentsDigest(value, []byte(uuid.NewString()), offsets, 64, resp.GetSegmentsDigest())).
+			To(Not(Succeed()))
+
+		_, err = challenge(&pb.ChallengeValueRequest{
+			Nonce:       nonce,
+			Offsets:     []int64{int64(len(value))},
+			SegmentSize: 64,
+		})
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should prove chunks against the root kept at upload and bill them", func() {
+		before := sessionBalance(ctx, sessionId)
+
+		resp, err := challenge(&pb.ChallengeValueRequest{ChunkIndices: []uint64{1, 3}})
+		Expect(err).To(BeNil())
+		Expect(resp.GetChunkRoot()).To(Equal(root))
+		Expect(resp.GetChunkProofs()).To(HaveLen(2))
+		for i, proof := range resp.GetChunkProofs() {
+			Expect(merkle.VerifyChunkProof(root, proof, []uint64{1, 3}[i], int64(len(value)))).To(Succeed())
+		}
+		Expect(resp.GetChunkProofs()[1].GetChunk()).To(Equal(value[3*merkle.CHUNK_SIZE:]))
+
+		// One unit per chunk and one per byte returned
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - 2 - merkle.CHUNK_SIZE - 100))
+
+		tampered := resp.GetChunkProofs()[0]
+		tampered.Chunk = append([]byte{}, tampered.GetChunk()...)
+		tampered.Chunk[0] ^= 0xff
+		Expect(merkle.VerifyChunkProof(root, tampered, 1, int64(len(value)))).To(Not(Succeed()))
+
+		_, err = challenge(&pb.ChallengeValueRequest{ChunkIndices: []uint64{4}})
+		Expect(err).To(Not(BeNil()))
+	})
+})
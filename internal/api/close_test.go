@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session close", Label("close"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+	sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+	nodeAccounts := []string{api.Ed25519DidKey(Conf.ExchangeAccountPrivateKey.Public().(ed25519.PublicKey))}
+
+	It("should return the balance left as a voucher for the quota authority", func() {
+		jti := uuid.NewString()
+		created, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaToken(jti),
+		}))
+		Expect(err).To(BeNil())
+		sessionId := created.Msg.GetSession().GetSessionId()
+		before, err := sessionManager.GetSession(ctx, sessionId)
+		Expect(err).To(BeNil())
+
+		req := connect.NewRequest(&pb.CloseSessionRequest{})
+		req.Header().Set("Authorization", "Bearer "+created.Msg.GetJwt())
+		resp, err := client.CloseSession(ctx, req)
+		Expect(err).To(BeNil())
+		// Closing is free so that drained sessions can be closed too
+		Expect(resp.Msg.GetRefund().GetBalance()).To(Equal(before.GetBalance()))
+
+		claims, err := middleware.VerifyRefundVoucher(resp.Msg.GetVoucher(), Conf.QuotaAuthorityDid, nodeAccounts, 0)
+		Expect(err).To(BeNil())
+		Expect(claims.ID).To(Equal(jti))
+		Expect(claims.Subject).To(Equal(sessionId))
+		Expect(claims.Quantity).To(Equal(resp.Msg.GetRefund().GetBalance()))
+		Expect(claims.Issuer).To(Equal(nodeAccounts[0]))
+
+		_, err = sessionManager.GetSession(ctx, sessionId)
+		Expect(err).To(Not(BeNil()))
+		// Closing again returns the same refund, which the exchange redeems once
+		again, err := client.CloseSession(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(again.Msg.GetRefund().GetBalance()).To(Equal(resp.Msg.GetRefund().GetBalance()))
+		Expect(again.Msg.GetRefund().GetJti()).To(Equal(jti))
+	})
+
+	It("should close sessions without balance left", func() {
+		token, sessionId := newSession(ctx)
+		session, err := sessionManager.GetSession(ctx, sessionId)
+		Expect(err).To(BeNil())
+		_, err = sessionManager.DeductSessionBalance(ctx, sessionId, session.GetBalance())
+		Expect(err).To(BeNil())
+
+		req := connect.NewRequest(&pb.CloseSessionRequest{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := client.CloseSession(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetRefund().GetBalance()).To(Equal(int64(0)))
+		Expect(resp.Msg.GetVoucher()).To(BeEmpty())
+	})
+})
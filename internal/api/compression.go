@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	VALUE_ENCODING_NONE   = "none"
+	VALUE_ENCODING_ZSTD   = "zstd"
+	VALUE_ENCODING_SNAPPY = "snappy"
+)
+
+// Values stored before compression was configured decode with the same
+// decoder as any other zstd value, whatever the current algorithm is.
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// Compressor decides per value whether it is worth compressing. Small values
+// and values with high entropy, like media or encrypted data, are stored
+// verbatim.
+type Compressor struct {
+	algorithm   string
+	minSize     int
+	maxEntropy  float64
+	zstdEncoder *zstd.Encoder
+}
+
+func NewCompressor(conf *Config) (*Compressor, error) {
+	c := &Compressor{
+		algorithm:  conf.CompressionAlgorithm,
+		minSize:    conf.CompressionMinSize,
+		maxEntropy: conf.CompressionMaxEntropy,
+	}
+	switch c.algorithm {
+	case "", VALUE_ENCODING_NONE:
+		c.algorithm = VALUE_ENCODING_NONE
+	case VALUE_ENCODING_SNAPPY:
+	case VALUE_ENCODING_ZSTD:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %v", err)
+		}
+		c.zstdEncoder = encoder
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %s", c.algorithm)
+	}
+	return c, nil
+}
+
+// shannonEntropy returns the entropy of value in bits per byte.
+func shannonEntropy(value []byte) float64 {
+	var counts [256]int
+	for _, b := range value {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(value))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Encode returns the bytes to store for value and their encoding. The
+// compressed form is only kept when it is smaller.
+func (c *Compressor) Encode(value []byte) ([]byte, string) {
+	if c.algorithm == VALUE_ENCODING_NONE || len(value) < c.minSize {
+		return value, VALUE_ENCODING_NONE
+	}
+	if shannonEntropy(value) > c.maxEntropy {
+		return value, VALUE_ENCODING_NONE
+	}
+	var encoded []byte
+	switch c.algorithm {
+	case VALUE_ENCODING_ZSTD:
+		encoded = c.zstdEncoder.EncodeAll(value, nil)
+	case VALUE_ENCODING_SNAPPY:
+		encoded = snappy.Encode(nil, value)
+	}
+	if len(encoded) >= len(value) {
+		return value, VALUE_ENCODING_NONE
+	}
+	return encoded, c.algorithm
+}
+
+// decodeValue returns the original bytes of a value stored with encoding.
+func decodeValue(stored []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", VALUE_ENCODING_NONE:
+		return stored, nil
+	case VALUE_ENCODING_ZSTD:
+		return zstdDecoder.DecodeAll(stored, nil)
+	case VALUE_ENCODING_SNAPPY:
+		return snappy.Decode(nil, stored)
+	default:
+		return nil, fmt.Errorf("unknown value encoding %s", encoding)
+	}
+}
+
+// acceptsEncoding tells whether a client listing accepted can decode values
+// stored with encoding itself.
+func acceptsEncoding(accepted []string, encoding string) bool {
+	return encoding != "" && encoding != VALUE_ENCODING_NONE && slices.Contains(accepted, encoding)
+}
@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Value compression", Label("compression"), func() {
+	ctx := context.Background()
+
+	newServer := func(algorithm string) *api.Server {
+		conf := *Conf
+		conf.CompressionAlgorithm = algorithm
+		conf.CompressionMinSize = 64
+		conf.CompressionMaxEntropy = 7.5
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		return server
+	}
+
+	createValue := func(server *api.Server, value []byte) *pb.CreateValueResponse {
+		resp, err := server.CreateValue(ctx, connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Minute),
+		}))
+		Expect(err).To(BeNil())
+		return resp.Msg
+	}
+
+	compressible := []byte(strings.Repeat("Subject: hello\nBody: compressible mail "+uuid.NewString()+"\n", 50))
+
+	for _, algorithm := range []string{api.VALUE_ENCODING_ZSTD, api.VALUE_ENCODING_SNAPPY} {
+		It("should store compressible values with "+algorithm+" and return them transparently", func() {
+			server := newServer(algorithm)
+			value := append(bytes.Clone(compressible), []byte(algorithm)...)
+			created := createValue(server, value)
+			Expect(created.GetEncoding()).To(Equal(algorithm))
+			Expect(created.GetStoredSize()).To(BeNumerically("<", len(value)))
+			Expect(RedisClient.StrLen(ctx, created.GetName()).Val()).To(Equal(created.GetStoredSize()))
+
+			resp, err := server.GetValue(ctx, connect.NewRequest(&pb.GetValueRequest{Name: created.GetName()}))
+			Expect(err).To(BeNil())
+			Expect(resp.Msg.GetValue()).To(Equal(value))
+			Expect(resp.Msg.GetEncoding()).To(BeEmpty())
+
+			metadata, err := server.GetValueMetadata(ctx, connect.NewRequest(&pb.GetValueMetadataRequest{
+				Name: created.GetName(),
+			}))
+			Expect(err).To(BeNil())
+			Expect(metadata.Msg.GetSize()).To(Equal(int64(len(value))))
+			Expect(metadata.Msg.GetStoredSize()).To(Equal(created.GetStoredSize()))
+
+			c, err := cid.Decode(strings.TrimPrefix(created.GetName(), "values/"))
+			Expect(err).To(BeNil())
+			block, err := api.NewRedisBlockstore(RedisClient).Get(ctx, c)
+			Expect(err).To(BeNil())
+			Expect(block.RawData()).To(Equal(value))
+		})
+	}
+
+	It("should pass compressed bytes to clients accepting the encoding", func() {
+		server := newServer(api.VALUE_ENCODING_ZSTD)
+		created := createValue(server, compressible)
+		resp, err := server.GetValue(ctx, connect.NewRequest(&pb.GetValueRequest{
+			Name:              created.GetName(),
+			AcceptedEncodings: []string{api.VALUE_ENCODING_ZSTD},
+		}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetEncoding()).To(Equal(api.VALUE_ENCODING_ZSTD))
+		decoder, err := zstd.NewReader(nil)
+		Expect(err).To(BeNil())
+		defer decoder.Close()
+		decoded, err := decoder.DecodeAll(resp.Msg.GetValue(), nil)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(compressible))
+	})
+
+	It("should store small and high entropy values verbatim", func() {
+		server := newServer(api.VALUE_ENCODING_ZSTD)
+		random := make([]byte, 4096)
+		_, err := rand.Read(random)
+		Expect(err).To(BeNil())
+		for _, value := range [][]byte{[]byte("short " + uuid.NewString()), random} {
+			created := createValue(server, value)
+			Expect(created.GetEncoding()).To(Equal(api.VALUE_ENCODING_NONE))
+			Expect(created.GetStoredSize()).To(Equal(int64(len(value))))
+		}
+	})
+
+	It("should charge the stored size before writing with the stored size basis", func() {
+		conf := *Conf
+		conf.CompressionAlgorithm = api.VALUE_ENCODING_ZSTD
+		conf.CompressionMinSize = 64
+		conf.CompressionMaxEntropy = 7.5
+		conf.PricingSizeBasis = middleware.PRICING_SIZE_BASIS_STORED
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		_, sessionId := newSession(ctx)
+		sessionCtx := context.WithValue(ctx, middleware.KeySession, &pb.Session{SessionId: sessionId})
+		session, err := sessionManager.GetSession(ctx, sessionId)
+		Expect(err).To(BeNil())
+
+		value := append(bytes.Clone(compressible), []byte("stored basis")...)
+		resp, err := server.CreateValue(sessionCtx, connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Minute),
+		}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetStoredSize()).To(BeNumerically("<", len(value)))
+		charged, err := sessionManager.GetSession(ctx, sessionId)
+		Expect(err).To(BeNil())
+		Expect(charged.GetBalance()).To(Equal(session.GetBalance() - resp.Msg.GetStoredSize()))
+
+		_, err = sessionManager.DeductSessionBalance(ctx, sessionId, charged.GetBalance())
+		Expect(err).To(BeNil())
+		unpaid := append(bytes.Clone(compressible), []byte("unpaid")...)
+		_, err = server.CreateValue(sessionCtx, connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: unpaid,
+			Ttl:   durationpb.New(time.Minute),
+		}))
+		Expect(err).To(Not(BeNil()))
+		Expect(RedisClient.Exists(ctx, "values/"+api.HashRawBytes(unpaid)).Val()).To(Equal(int64(0)))
+	})
+})
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/mr-tron/base58"
 	"github.com/spf13/viper"
@@ -22,32 +23,111 @@ type Config struct {
 	RedisPort uint16 `mapstructure:"REDIS_PORT"`
 	GrpcPort  uint16 `mapstructure:"GRPC_PORT"`
 
-	DisableAuth       bool          `mapstructure:"DISABLE_AUTH"`
-	TokenTtl          time.Duration `mapstructure:"TOKEN_TTL"`
-	SelfIdentifier    string        `mapstructure:"SELF_IDENTIFIER"`
-	TokenSigningKeyId string
+	DisableAuth    bool          `mapstructure:"DISABLE_AUTH"`
+	TokenTtl       time.Duration `mapstructure:"TOKEN_TTL"`
+	SelfIdentifier string        `mapstructure:"SELF_IDENTIFIER"`
+	// Clock skew tolerated when checking exp, nbf and iat of tokens, which
+	// must all have this node as audience
+	JwtLeeway time.Duration `mapstructure:"JWT_LEEWAY"`
+	// Bearer token of the operator endpoints under /admin/, disabled when empty
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+	// Algorithm of session tokens, and how long tokens signed by a rotated
+	// out key keep verifying
+	SessionSigningAlgorithm string        `mapstructure:"SESSION_SIGNING_ALGORITHM"`
+	SessionKeyGracePeriod   time.Duration `mapstructure:"SESSION_KEY_GRACE_PERIOD"`
 
 	SecretSeedEncoded         string `mapstructure:"SECRET_SEED"`
 	ExchangeAccountPrivateKey ed25519.PrivateKey
 	Libp2pPrivateKey          crypto.PrivKey
 	JwtSecret                 []byte
+	SessionSalt               []byte
+	SessionKeyringSealKey     []byte
 	QuotaAuthorityDid         string `mapstructure:"QUOTA_AUTHORITY_DID"`
 	QuotaAuthorityPublicKey   []byte
+	// Further exchanges whose quota tokens are accepted, as a did:key, a
+	// did:web or a DID and the JWKS URL of its keys separated by "="
+	TrustedExchanges []string `mapstructure:"TRUSTED_EXCHANGES"`
+	// How long resolved exchange keys are cached, and how often unknown key
+	// ids may trigger an early refetch
+	ExchangeKeyCacheTtl           time.Duration `mapstructure:"EXCHANGE_KEY_CACHE_TTL"`
+	ExchangeKeyMinRefreshInterval time.Duration `mapstructure:"EXCHANGE_KEY_MIN_REFRESH_INTERVAL"`
+
+	// Virtual service behaviors this index accepts registrations for
+	EnabledBehaviors   []string `mapstructure:"ENABLED_BEHAVIORS"`
+	BehaviorMaintainer string   `mapstructure:"BEHAVIOR_MAINTAINER"`
+
+	// Health probing of indexed storage instances. Disabled when interval is 0.
+	ProbeInterval       time.Duration `mapstructure:"PROBE_INTERVAL"`
+	ProbeTimeout        time.Duration `mapstructure:"PROBE_TIMEOUT"`
+	ProbeBudget         int64         `mapstructure:"PROBE_BUDGET"`
+	ProbeMinSamples     int64         `mapstructure:"PROBE_MIN_SAMPLES"`
+	ProbeMaxFailureRate float64       `mapstructure:"PROBE_MAX_FAILURE_RATE"`
+	ProbeSessionToken   string        `mapstructure:"PROBE_SESSION_TOKEN"`
+
+	// IPNI publishers to follow advertisement chains from. Disabled when
+	// interval is 0.
+	IpniPublishers   []string      `mapstructure:"IPNI_PUBLISHERS"`
+	IpniPollInterval time.Duration `mapstructure:"IPNI_POLL_INTERVAL"`
+	IpniMaxEntries   int           `mapstructure:"IPNI_MAX_ENTRIES"`
+
+	// libp2p host serving the KvStoreService, disabled when no listen address
+	Libp2pListenAddrs []string `mapstructure:"LIBP2P_LISTEN_ADDRS"`
+	// Public HTTP multiaddrs of this node put into its advertised instance
+	AdvertisedMultiaddrs []string `mapstructure:"ADVERTISED_MULTIADDRS"`
+
+	// Bitswap responder on the libp2p host serving blocks from the value store
+	BitswapEnabled    bool  `mapstructure:"BITSWAP_ENABLED"`
+	BitswapBlockPrice int64 `mapstructure:"BITSWAP_BLOCK_PRICE"`
+	// Wanted blocks a peer without a paid session may ask for each minute
+	BitswapFreeBlocksPerMinute int64 `mapstructure:"BITSWAP_FREE_BLOCKS_PER_MINUTE"`
+
+	// Price of each block sent by the trustless gateway under /ipfs/
+	IpfsGatewayBlockPrice int64 `mapstructure:"IPFS_GATEWAY_BLOCK_PRICE"`
+
+	// Largest CAR file accepted by ImportCar in bytes
+	CarImportMaxSize int64 `mapstructure:"CAR_IMPORT_MAX_SIZE"`
+
+	// Rehash values before returning them
+	VerifyOnRead bool `mapstructure:"VERIFY_ON_READ"`
+	// Background rehashing of stored values. Disabled when interval is 0.
+	ScrubInterval        time.Duration `mapstructure:"SCRUB_INTERVAL"`
+	ScrubBudget          int64         `mapstructure:"SCRUB_BUDGET"`
+	ScrubDeleteCorrupted bool          `mapstructure:"SCRUB_DELETE_CORRUPTED"`
+
+	// Compression of stored values, none, zstd or snappy. Values smaller than
+	// the min size or with more entropy in bits per byte are stored verbatim.
+	CompressionAlgorithm  string  `mapstructure:"COMPRESSION_ALGORITHM"`
+	CompressionMinSize    int     `mapstructure:"COMPRESSION_MIN_SIZE"`
+	CompressionMaxEntropy float64 `mapstructure:"COMPRESSION_MAX_ENTROPY"`
+	// Whether storage is priced by the logical or the stored size of values
+	PricingSizeBasis string `mapstructure:"PRICING_SIZE_BASIS"`
 }
 
-func mustParseEd25519DidKey(didString string) []byte {
+func ParseEd25519DidKey(didString string) ([]byte, error) {
 	base58Str, found := strings.CutPrefix(didString, "did:key:z")
 	if !found {
-		log.Fatalf("invalid did key: %s", didString)
+		return nil, fmt.Errorf("invalid did key: %s", didString)
 	}
 	didBytes, err := base58.Decode(base58Str)
 	if err != nil {
-		log.Fatalf("failed to decode: %s", err.Error())
+		return nil, fmt.Errorf("failed to decode: %v", err)
 	}
 	if len(didBytes) != 34 || didBytes[0] != 0xed || didBytes[1] != 0x01 {
-		log.Fatalf("did is not ed25519: %v", didBytes)
+		return nil, fmt.Errorf("did is not ed25519: %v", didBytes)
+	}
+	return didBytes[2:], nil
+}
+
+func Ed25519DidKey(publicKey []byte) string {
+	return "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+}
+
+func mustParseEd25519DidKey(didString string) []byte {
+	publicKey, err := ParseEd25519DidKey(didString)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
-	return didBytes[2:]
+	return publicKey
 }
 
 func DeriveKey(seed []byte, info string) ([]byte, error) {
@@ -67,6 +147,40 @@ func LoadConfig(name string, path string) (config Config) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("JWT_LEEWAY", "30s")
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("SESSION_SIGNING_ALGORITHM", middleware.SESSION_SIGNING_ALGORITHM_HS256)
+	viper.SetDefault("SESSION_KEY_GRACE_PERIOD", "48h")
+	viper.SetDefault("TRUSTED_EXCHANGES", "")
+	viper.SetDefault("EXCHANGE_KEY_CACHE_TTL", "10m")
+	viper.SetDefault("EXCHANGE_KEY_MIN_REFRESH_INTERVAL", "30s")
+	viper.SetDefault("ENABLED_BEHAVIORS", SERVE_ALL_BEHAVIOR_NAME)
+	viper.SetDefault("BEHAVIOR_MAINTAINER", SERVE_ALL_BEHAVIOR_MAINTAINER)
+	viper.SetDefault("PROBE_INTERVAL", "0s")
+	viper.SetDefault("PROBE_TIMEOUT", "10s")
+	viper.SetDefault("PROBE_BUDGET", 20)
+	viper.SetDefault("PROBE_MIN_SAMPLES", 5)
+	viper.SetDefault("PROBE_MAX_FAILURE_RATE", 0.5)
+	viper.SetDefault("PROBE_SESSION_TOKEN", "")
+	viper.SetDefault("IPNI_PUBLISHERS", "")
+	viper.SetDefault("IPNI_POLL_INTERVAL", "0s")
+	viper.SetDefault("IPNI_MAX_ENTRIES", 100000)
+	viper.SetDefault("LIBP2P_LISTEN_ADDRS", "")
+	viper.SetDefault("ADVERTISED_MULTIADDRS", "")
+	viper.SetDefault("BITSWAP_ENABLED", false)
+	viper.SetDefault("BITSWAP_BLOCK_PRICE", 1)
+	viper.SetDefault("BITSWAP_FREE_BLOCKS_PER_MINUTE", 0)
+	viper.SetDefault("IPFS_GATEWAY_BLOCK_PRICE", 1)
+	viper.SetDefault("CAR_IMPORT_MAX_SIZE", 64<<20)
+	viper.SetDefault("VERIFY_ON_READ", false)
+	viper.SetDefault("SCRUB_INTERVAL", "0s")
+	viper.SetDefault("SCRUB_BUDGET", 1000)
+	viper.SetDefault("SCRUB_DELETE_CORRUPTED", false)
+	viper.SetDefault("COMPRESSION_ALGORITHM", VALUE_ENCODING_NONE)
+	viper.SetDefault("COMPRESSION_MIN_SIZE", 256)
+	viper.SetDefault("COMPRESSION_MAX_ENTROPY", 7.5)
+	viper.SetDefault("PRICING_SIZE_BASIS", middleware.PRICING_SIZE_BASIS_LOGICAL)
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("config: %v", err)
 	}
@@ -77,24 +191,41 @@ func LoadConfig(name string, path string) (config Config) {
 	if err != nil {
 		log.Fatalf("config: failed to parse secret: %v", err)
 	}
+	if err := config.DeriveSecrets(seed); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	config.QuotaAuthorityPublicKey = mustParseEd25519DidKey(config.QuotaAuthorityDid)
+	return
+}
+
+// DeriveSecrets sets the keys derived from the secret seed, also for tools
+// like genjwt signing with the keys of the node.
+func (config *Config) DeriveSecrets(seed []byte) error {
+	var err error
 	config.JwtSecret, err = DeriveKey(seed, "JwtSecret")
 	if err != nil {
-		log.Fatalf("config: failed to derive key: %v", err)
+		return fmt.Errorf("failed to derive key: %v", err)
+	}
+	config.SessionSalt, err = DeriveKey(seed, "SessionSalt")
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %v", err)
+	}
+	config.SessionKeyringSealKey, err = DeriveKey(seed, "SessionKeyringSealKey")
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %v", err)
 	}
-	config.TokenSigningKeyId = base58.Encode(sha256.New().Sum(config.JwtSecret))
 	exchangeKeySeed, err := DeriveKey(seed, "ExchangeKey")
 	if err != nil {
-		log.Fatalf("config: failed to derive key: %v", err)
+		return fmt.Errorf("failed to derive key: %v", err)
 	}
 	config.ExchangeAccountPrivateKey = ed25519.NewKeyFromSeed(exchangeKeySeed)
 	libp2pSeed, err := DeriveKey(seed, "Libp2p")
 	if err != nil {
-		log.Fatalf("config: failed to derive key: %v", err)
+		return fmt.Errorf("failed to derive key: %v", err)
 	}
 	config.Libp2pPrivateKey, _, err = crypto.GenerateEd25519Key(bytes.NewReader(libp2pSeed))
 	if err != nil {
-		log.Fatalf("config: failed to generate libp2p key: %v", err)
+		return fmt.Errorf("failed to generate libp2p key: %v", err)
 	}
-	config.QuotaAuthorityPublicKey = mustParseEd25519DidKey(config.QuotaAuthorityDid)
-	return
+	return nil
 }
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProlongDag extends the ttl of every value reachable from a root. Values
+// gone missing below the root are reported rather than failing the call, so
+// that clients notice a partially expired DAG while the rest is kept alive.
+func (s *Server) ProlongDag(
+	ctx context.Context, connectReq *connect.Request[pb.ProlongDagRequest],
+) (*connect.Response[pb.ProlongDagResponse], error) {
+	req := connectReq.Msg
+	ttl := req.GetTtl().AsDuration()
+	if ttl <= 0 {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"ttl and/or maxSize missing or corrupted",
+		)
+	}
+	root, err := cid.Decode(req.GetName()[len("values/"):])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cid: %v", err)
+	}
+	blockstore := s.newBlockstore()
+	if found, err := blockstore.Has(ctx, root); err != nil {
+		return nil, status.Error(codes.Internal, "failed to get value")
+	} else if !found {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+
+	keys := make([]string, 0)
+	sizes := make([]int64, 0)
+	missing := make([]string, 0)
+	if err := walkDag(ctx, blockstore, root, GATEWAY_DAG_SCOPE_ALL, func(block blocks.Block) error {
+		if len(keys) >= maxDagBlocks {
+			return status.Errorf(
+				codes.ResourceExhausted,
+				"dag has more than %d blocks",
+				maxDagBlocks,
+			)
+		}
+		key, err := valueKey(block.Cid())
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid cid %s: %v", block.Cid(), err)
+		}
+		keys = append(keys, key)
+		sizes = append(sizes, int64(len(block.RawData())))
+		return nil
+	}, func(c cid.Cid) error {
+		key, err := valueKey(c)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid cid %s: %v", c, err)
+		}
+		missing = append(missing, key)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	ttlCmds := make([]*redis.DurationCmd, 0, len(keys))
+	storedSizeCmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, key := range keys {
+		ttlCmds = append(ttlCmds, pipe.TTL(ctx, key))
+		storedSizeCmds = append(storedSizeCmds, pipe.StrLen(ctx, key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, status.Error(codes.Internal, "failed to get ttl")
+	}
+	prolonged := make(map[string]time.Duration, len(keys))
+	totalSize := int64(0)
+	totalStoredSize := int64(0)
+	for i, cmd := range ttlCmds {
+		switch oldTtl := cmd.Val(); {
+		case oldTtl == -2:
+			// Expired since it was read
+			missing = append(missing, keys[i])
+		case oldTtl < 0:
+			// Not expiring, nothing to prolong
+		default:
+			prolonged[keys[i]] = oldTtl + ttl
+			totalSize += sizes[i]
+			totalStoredSize += storedSizeCmds[i].Val()
+		}
+	}
+	billedSize := s.billedSize(totalSize, totalStoredSize)
+	if billedSize > req.GetMaxSize() {
+		return nil, status.Error(
+			codes.PermissionDenied,
+			"value size exceeded",
+		)
+	}
+
+	price, err := s.pricingManager.GetStoragePrice(billedSize, ttl)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to get price: %v", err)
+	}
+	if err := s.chargeSession(ctx, price); err != nil {
+		return nil, err
+	}
+	pipe = s.redisClient.Pipeline()
+	for key, newTtl := range prolonged {
+		pipe.Expire(ctx, key, newTtl)
+		pipe.Expire(ctx, valueMetadataKey(key), newTtl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, status.Error(
+			codes.Internal,
+			"failed to set ttl",
+		)
+	}
+	return connect.NewResponse(&pb.ProlongDagResponse{
+		Name:       req.GetName(),
+		BlockCount: int64(len(prolonged)),
+		TotalSize:  totalSize,
+		Missing:    missing,
+	}), nil
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Prolong whole dags", Label("dag"), func() {
+	ctx := context.Background()
+
+	var token string
+	var sessionId string
+	var rootName, storedName, missingName string
+	var storedSize int64
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+
+		stored := merkledag.NewRawNode([]byte("stored leaf " + uuid.NewString()))
+		missing := merkledag.NewRawNode([]byte("missing leaf " + uuid.NewString()))
+		root := merkledag.NodeWithData([]byte("dag root"))
+		Expect(root.AddNodeLink("stored", stored)).To(Succeed())
+		Expect(root.AddNodeLink("missing", missing)).To(Succeed())
+		rootName = "values/" + cid.NewCidV1(cid.DagProtobuf, root.Cid().Hash()).String()
+		storedName = "values/" + stored.Cid().String()
+		missingName = "values/" + missing.Cid().String()
+		storedSize = int64(len(root.RawData()) + len(stored.RawData()))
+		Expect(RedisClient.Set(ctx, rootName, root.RawData(), time.Minute).Err()).To(Succeed())
+		Expect(RedisClient.Set(ctx, storedName, stored.RawData(), time.Minute).Err()).To(Succeed())
+	})
+
+	prolongDag := func(maxSize int64) (*pb.ProlongDagResponse, error) {
+		req := connect.NewRequest(&pb.ProlongDagRequest{
+			Name:    rootName,
+			Ttl:     durationpb.New(24 * time.Hour),
+			MaxSize: maxSize,
+		})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.ProlongDag(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	It("should prolong every stored block and report missing ones", func() {
+		before := sessionBalance(ctx, sessionId)
+
+		resp, err := prolongDag(1 << 20)
+		Expect(err).To(BeNil())
+		Expect(resp.GetBlockCount()).To(Equal(int64(2)))
+		Expect(resp.GetTotalSize()).To(Equal(storedSize))
+		Expect(resp.GetMissing()).To(Equal([]string{missingName}))
+
+		ttl, err := RedisClient.TTL(ctx, storedName).Result()
+		Expect(err).To(BeNil())
+		Expect(ttl).To(BeNumerically(">", 24*time.Hour))
+
+		// One unit for the call and one per byte-day
+		price, err := (&middleware.PricingManager{}).GetStoragePrice(storedSize, 24*time.Hour)
+		Expect(err).To(BeNil())
+		Expect(price).To(Equal(storedSize))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - 1 - price))
+	})
+
+	It("should refuse dags larger than max size", func() {
+		_, err := prolongDag(storedSize - 1)
+		Expect(err).To(Not(BeNil()))
+		ttl, err := RedisClient.TTL(ctx, storedName).Result()
+		Expect(err).To(BeNil())
+		Expect(ttl).To(BeNumerically("<=", time.Minute))
+	})
+})
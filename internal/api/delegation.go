@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxDelegationDepth bounds how many times access to a stream can be
+// delegated further, which also bounds the size of delegation tokens.
+const maxDelegationDepth = 4
+
+// StreamDelegationClaims grant the subject the operations on a stream. The
+// delegator is the stream owner, or the subject of the delegation token in
+// proof, which the grant cannot exceed.
+type StreamDelegationClaims struct {
+	*middleware.SessionJwtClaims
+	Delegator  string               `json:"delegator"`
+	Stream     string               `json:"stream"`
+	Operations []pb.StreamOperation `json:"ops"`
+	Proof      string               `json:"prf,omitempty"`
+}
+
+func streamName(owner string, streamId string) string {
+	return fmt.Sprintf("accounts/%s/streams/%s", owner, streamId)
+}
+
+// authenticateAccount returns the subject of the account token verified by
+// the account interceptor, or else of the deprecated one in the request body.
+func (s *Server) authenticateAccount(ctx context.Context, bodyAuthToken string) (string, error) {
+	if claims, ok := ctx.Value(middleware.KeyAuthClaims).(*middleware.SessionJwtClaims); ok {
+		return claims.Subject, nil
+	}
+	if bodyAuthToken == "" {
+		return "", status.Errorf(
+			codes.Unauthenticated,
+			"expected account token in %s header",
+			middleware.ACCOUNT_AUTHORIZATION_HEADER,
+		)
+	}
+	claims, err := middleware.VerifyAccountToken(s.authmanager, bodyAuthToken)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// verifyDelegation verifies a delegation token at depth of a chain and the
+// tokens it was delegated from up to the stream owner.
+func (s *Server) verifyDelegation(delegationToken string, depth int) (*StreamDelegationClaims, error) {
+	if depth > maxDelegationDepth {
+		return nil, fmt.Errorf("delegation chain longer than %d", maxDelegationDepth)
+	}
+	parsed, err := s.authmanager.VerifyAndParseJwt(delegationToken, &StreamDelegationClaims{}, true)
+	if err != nil {
+		return nil, err
+	}
+	claims := parsed.(*StreamDelegationClaims)
+	if claims.SessionJwtClaims == nil || claims.Usage != pb.JwtUsage_JWT_USAGE_DELEGATE_STREAM {
+		return nil, fmt.Errorf("not a delegation token")
+	}
+	fields, err := middleware.ParseResourceName(claims.Stream, []string{"accounts", "streams"})
+	if err != nil {
+		return nil, fmt.Errorf("invalid delegated stream: %v", err)
+	}
+	if claims.Proof == "" {
+		if claims.Delegator != fields[0] {
+			return nil, fmt.Errorf("delegator %s does not own %s", claims.Delegator, claims.Stream)
+		}
+		return claims, nil
+	}
+	parent, err := s.verifyDelegation(claims.Proof, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureAttenuated(parent, claims.Delegator, claims.Stream, claims.Operations); err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt.After(parent.ExpiresAt.Time) {
+		return nil, fmt.Errorf("delegation outlives its proof")
+	}
+	return claims, nil
+}
+
+// ensureAttenuated checks that delegator holds parent and delegates no more
+// than parent grants.
+func ensureAttenuated(
+	parent *StreamDelegationClaims, delegator string, stream string, operations []pb.StreamOperation,
+) error {
+	if parent.Subject != delegator {
+		return fmt.Errorf("proof delegated to %s instead of %s", parent.Subject, delegator)
+	}
+	if parent.Stream != stream {
+		return fmt.Errorf("proof delegates %s instead of %s", parent.Stream, stream)
+	}
+	for _, operation := range operations {
+		if !slices.Contains(parent.Operations, operation) {
+			return fmt.Errorf("proof does not grant %s", operation)
+		}
+	}
+	return nil
+}
+
+// authorizeStreamAccess lets the account of the call do operation on a
+// stream it owns, or was delegated by delegationToken.
+func (s *Server) authorizeStreamAccess(
+	ctx context.Context,
+	bodyAuthToken string,
+	delegationToken string,
+	owner string,
+	streamId string,
+	operation pb.StreamOperation,
+) error {
+	subject, err := s.authenticateAccount(ctx, bodyAuthToken)
+	if err != nil {
+		return err
+	}
+	if delegationToken == "" {
+		if subject != owner {
+			return status.Errorf(
+				codes.PermissionDenied,
+				"auth token subject %s not matching resource owner %s",
+				subject,
+				owner,
+			)
+		}
+		return nil
+	}
+	claims, err := s.verifyDelegation(delegationToken, 1)
+	if err != nil {
+		return status.Errorf(
+			codes.PermissionDenied,
+			"invalid delegation token: %v",
+			err,
+		)
+	}
+	if claims.Subject != subject {
+		return status.Errorf(
+			codes.PermissionDenied,
+			"stream delegated to %s instead of %s",
+			claims.Subject,
+			subject,
+		)
+	}
+	if claims.Stream != streamName(owner, streamId) || !slices.Contains(claims.Operations, operation) {
+		return status.Errorf(
+			codes.PermissionDenied,
+			"delegation does not grant %s on %s",
+			operation,
+			streamName(owner, streamId),
+		)
+	}
+	return nil
+}
+
+func (s *Server) DelegateStreamAccess(
+	ctx context.Context, connectReq *connect.Request[pb.DelegateStreamAccessRequest],
+) (*connect.Response[pb.DelegateStreamAccessResponse], error) {
+	req := connectReq.Msg
+	fields, err := middleware.ParseResourceName(req.GetParent(), []string{
+		"accounts", "streams",
+	})
+	if err != nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"failed to parse resource name: %v",
+			err,
+		)
+	}
+	stream := streamName(fields[0], fields[1])
+	delegator, err := s.authenticateAccount(ctx, req.GetAuthToken())
+	if err != nil {
+		return nil, err
+	}
+	expireAt := time.Now().Add(req.GetTtl().AsDuration())
+	if req.GetProof() == "" {
+		if delegator != fields[0] {
+			return nil, status.Errorf(
+				codes.PermissionDenied,
+				"auth token subject %s not matching resource owner %s",
+				delegator,
+				fields[0],
+			)
+		}
+	} else {
+		// The token issued will be one link further down the chain
+		parent, err := s.verifyDelegation(req.GetProof(), 2)
+		if err != nil {
+			return nil, status.Errorf(
+				codes.PermissionDenied,
+				"invalid proof: %v",
+				err,
+			)
+		}
+		if err := ensureAttenuated(parent, delegator, stream, req.GetOperations()); err != nil {
+			return nil, status.Errorf(
+				codes.PermissionDenied,
+				"invalid proof: %v",
+				err,
+			)
+		}
+		if expireAt.After(parent.ExpiresAt.Time) {
+			expireAt = parent.ExpiresAt.Time
+		}
+	}
+
+	now := time.Now()
+	claims := &StreamDelegationClaims{
+		SessionJwtClaims: &middleware.SessionJwtClaims{
+			RegisteredClaims: &jwt.RegisteredClaims{
+				Issuer:    s.config.SelfIdentifier,
+				Audience:  jwt.ClaimStrings{s.config.SelfIdentifier},
+				Subject:   req.GetDelegate(),
+				ExpiresAt: jwt.NewNumericDate(expireAt),
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ID:        uuid.NewString(),
+			},
+			Usage: pb.JwtUsage_JWT_USAGE_DELEGATE_STREAM,
+		},
+		Delegator:  delegator,
+		Stream:     stream,
+		Operations: req.GetOperations(),
+		Proof:      req.GetProof(),
+	}
+	delegationToken, err := s.sessionKeys.Sign(ctx, claims)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Internal,
+			"failed to sign delegation token: %v",
+			err,
+		)
+	}
+	return connect.NewResponse(&pb.DelegateStreamAccessResponse{
+		DelegationToken: delegationToken,
+		ExpireTime:      timestamppb.New(claims.ExpiresAt.Time),
+	}), nil
+}
@@ -0,0 +1,167 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Stream access delegation", Label("delegation"), func() {
+	ctx := context.Background()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	// accountToken mints the identity token of an account, like genjwt
+	accountToken := func(subject string) string {
+		now := time.Now()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Issuer:    Conf.SelfIdentifier,
+			Audience:  jwt.ClaimStrings{Conf.SelfIdentifier},
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		})
+		signed, err := token.SignedString(Conf.JwtSecret)
+		Expect(err).To(BeNil())
+		return signed
+	}
+
+	var sessionToken string
+	var owner, helper, device string
+	var stream, valueName string
+	BeforeEach(func() {
+		sessionToken, _ = newSession(ctx)
+		owner = "did:example:owner-" + uuid.NewString()
+		helper = "did:example:helper-" + uuid.NewString()
+		device = "did:example:device-" + uuid.NewString()
+		stream = "accounts/" + owner + "/streams/inbox"
+
+		req := connect.NewRequest(&pb.CreateStreamValueRequest{
+			Parent: stream,
+			Value:  []byte("stream value"),
+		})
+		req.Header().Set("Authorization", "Bearer "+sessionToken)
+		created, err := client.CreateStreamValue(ctx, req)
+		Expect(err).To(BeNil())
+		valueName = created.Msg.GetName()
+	})
+
+	list := func(authToken string, delegationToken string) error {
+		req := connect.NewRequest(&pb.ListStreamValuesRequest{
+			Parent:          stream,
+			AuthToken:       authToken,
+			DelegationToken: delegationToken,
+			PageSize:        10,
+			PageToken:       "0-0",
+		})
+		req.Header().Set("Authorization", "Bearer "+sessionToken)
+		_, err := client.ListStreamValues(ctx, req)
+		return err
+	}
+	get := func(authToken string, delegationToken string) error {
+		req := connect.NewRequest(&pb.GetStreamValueRequest{
+			Name:            valueName,
+			AuthToken:       authToken,
+			DelegationToken: delegationToken,
+		})
+		req.Header().Set("Authorization", "Bearer "+sessionToken)
+		_, err := client.GetStreamValue(ctx, req)
+		return err
+	}
+	delegate := func(
+		authToken string, delegate string, proof string, ttl time.Duration, operations ...pb.StreamOperation,
+	) (*pb.DelegateStreamAccessResponse, error) {
+		req := connect.NewRequest(&pb.DelegateStreamAccessRequest{
+			Parent:     stream,
+			AuthToken:  authToken,
+			Delegate:   delegate,
+			Operations: operations,
+			Ttl:        durationpb.New(ttl),
+			Proof:      proof,
+		})
+		req.Header().Set("Authorization", "Bearer "+sessionToken)
+		resp, err := client.DelegateStreamAccess(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	It("should only let the delegate do the operations granted", func() {
+		Expect(list(accountToken(owner), "")).To(Succeed())
+		Expect(list(accountToken(helper), "")).To(Not(Succeed()))
+
+		_, err := delegate(accountToken(helper), helper, "", time.Hour, pb.StreamOperation_STREAM_OPERATION_LIST)
+		Expect(err).To(Not(BeNil()))
+		granted, err := delegate(accountToken(owner), helper, "", time.Hour, pb.StreamOperation_STREAM_OPERATION_LIST)
+		Expect(err).To(BeNil())
+		delegationToken := granted.GetDelegationToken()
+
+		Expect(list(accountToken(helper), delegationToken)).To(Succeed())
+		Expect(get(accountToken(helper), delegationToken)).To(Not(Succeed()))
+		Expect(list(accountToken(device), delegationToken)).To(Not(Succeed()))
+		// A delegation token does not stand for the identity of the delegate
+		Expect(list(delegationToken, delegationToken)).To(Not(Succeed()))
+		Expect(list(delegationToken, "")).To(Not(Succeed()))
+	})
+
+	It("should verify chains of delegations attenuating the grant", func() {
+		granted, err := delegate(
+			accountToken(owner), helper, "", time.Hour,
+			pb.StreamOperation_STREAM_OPERATION_GET, pb.StreamOperation_STREAM_OPERATION_LIST,
+		)
+		Expect(err).To(BeNil())
+
+		redelegated, err := delegate(
+			accountToken(helper), device, granted.GetDelegationToken(), 2*time.Hour,
+			pb.StreamOperation_STREAM_OPERATION_GET,
+		)
+		Expect(err).To(BeNil())
+		Expect(redelegated.GetExpireTime().AsTime()).To(Equal(granted.GetExpireTime().AsTime()))
+		Expect(get(accountToken(device), redelegated.GetDelegationToken())).To(Succeed())
+		Expect(list(accountToken(device), redelegated.GetDelegationToken())).To(Not(Succeed()))
+
+		// Only the delegate can delegate further, no more than it was granted
+		_, err = delegate(
+			accountToken(device), helper, redelegated.GetDelegationToken(), time.Hour,
+			pb.StreamOperation_STREAM_OPERATION_LIST,
+		)
+		Expect(err).To(Not(BeNil()))
+		_, err = delegate(
+			accountToken(device), device, granted.GetDelegationToken(), time.Hour,
+			pb.StreamOperation_STREAM_OPERATION_GET,
+		)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should bound the length of delegation chains", func() {
+		granted, err := delegate(accountToken(owner), "did:example:delegate-1", "", time.Hour, pb.StreamOperation_STREAM_OPERATION_GET)
+		Expect(err).To(BeNil())
+		proof := granted.GetDelegationToken()
+		for depth := 2; depth <= 4; depth++ {
+			granted, err = delegate(
+				accountToken(fmt.Sprintf("did:example:delegate-%d", depth-1)),
+				fmt.Sprintf("did:example:delegate-%d", depth),
+				proof, time.Hour, pb.StreamOperation_STREAM_OPERATION_GET,
+			)
+			Expect(err).To(BeNil())
+			proof = granted.GetDelegationToken()
+		}
+		Expect(get(accountToken("did:example:delegate-4"), proof)).To(Succeed())
+		_, err = delegate(
+			accountToken("did:example:delegate-4"), "did:example:delegate-5",
+			proof, time.Hour, pb.StreamOperation_STREAM_OPERATION_GET,
+		)
+		Expect(err).To(Not(BeNil()))
+	})
+})
@@ -0,0 +1,419 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	bstore "github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multicodec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	mediaTypeIpldRaw = "application/vnd.ipld.raw"
+	mediaTypeIpldCar = "application/vnd.ipld.car"
+
+	GATEWAY_DAG_SCOPE_ALL    = "all"
+	GATEWAY_DAG_SCOPE_ENTITY = "entity"
+	GATEWAY_DAG_SCOPE_BLOCK  = "block"
+
+	// Responses are addressed by CID so they never change
+	gatewayImmutableCacheControl = "public, max-age=29030400, immutable"
+	// Blocks a single DAG operation like ProlongDag may walk
+	maxDagBlocks = 10000
+)
+
+// GatewayHandler serves stored values under /ipfs/{cid} following the
+// trustless gateway spec, so that browsers and IPFS HTTP retrieval clients
+// can fetch and verify them. Each block sent is charged to the session in the
+// Authorization header.
+func (s *Server) GatewayHandler() http.Handler {
+	mux := http.NewServeMux()
+	// GET patterns match HEAD requests as well
+	mux.HandleFunc("GET /ipfs/{cid}", s.handleGateway)
+	return mux
+}
+
+func writeGatewayError(w http.ResponseWriter, err error) {
+	httpStatus := runtime.HTTPStatusFromCode(status.Code(err))
+	if httpStatus == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	http.Error(w, status.Convert(err).Message(), httpStatus)
+}
+
+// gatewayResponseFormat picks raw or car from the format query parameter or
+// the Accept header. Clients without a preference, like those accepting any
+// media type, get the raw block.
+func gatewayResponseFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "raw":
+		return mediaTypeIpldRaw, nil
+	case "car":
+		return mediaTypeIpldCar, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported format %s", format)
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, item := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil {
+				continue
+			}
+			switch mediaType {
+			case mediaTypeIpldRaw, "*/*", "application/*":
+				return mediaTypeIpldRaw, nil
+			case mediaTypeIpldCar:
+				if version, ok := params["version"]; ok && version != "1" {
+					return "", fmt.Errorf("unsupported car version %s", version)
+				}
+				if order, ok := params["order"]; ok && order != "dfs" && order != "unk" {
+					return "", fmt.Errorf("unsupported car order %s", order)
+				}
+				return mediaTypeIpldCar, nil
+			}
+		}
+	}
+	if len(r.Header.Values("Accept")) > 0 {
+		return "", fmt.Errorf("unsupported media types %s", strings.Join(r.Header.Values("Accept"), ", "))
+	}
+	return mediaTypeIpldRaw, nil
+}
+
+func gatewayDagScope(r *http.Request) (string, error) {
+	scope := r.URL.Query().Get("dag-scope")
+	if scope == "" {
+		return GATEWAY_DAG_SCOPE_ALL, nil
+	}
+	if !slices.Contains([]string{
+		GATEWAY_DAG_SCOPE_ALL, GATEWAY_DAG_SCOPE_ENTITY, GATEWAY_DAG_SCOPE_BLOCK,
+	}, scope) {
+		return "", fmt.Errorf("unsupported dag-scope %s", scope)
+	}
+	return scope, nil
+}
+
+// blockLinks returns the links of a block in order. Raw blocks have none.
+func blockLinks(block blocks.Block) ([]cid.Cid, error) {
+	switch block.Cid().Type() {
+	case cid.DagProtobuf:
+		node, err := merkledag.DecodeProtobuf(block.RawData())
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]cid.Cid, 0, len(node.Links()))
+		for _, link := range node.Links() {
+			ret = append(ret, link.Cid)
+		}
+		return ret, nil
+	case cid.DagCBOR, cid.DagJSON:
+		node, err := decodeIpldBlock(block.Cid(), block.RawData())
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]cid.Cid, 0)
+		return ret, appendIpldLinks(node, &ret)
+	default:
+		return nil, nil
+	}
+}
+
+func appendIpldLinks(node datamodel.Node, links *[]cid.Cid) error {
+	switch node.Kind() {
+	case datamodel.Kind_Link:
+		link, err := node.AsLink()
+		if err != nil {
+			return err
+		}
+		if cl, ok := link.(cidlink.Link); ok {
+			*links = append(*links, cl.Cid)
+		}
+	case datamodel.Kind_Map:
+		iter := node.MapIterator()
+		for !iter.Done() {
+			_, value, err := iter.Next()
+			if err != nil {
+				return err
+			}
+			if err := appendIpldLinks(value, links); err != nil {
+				return err
+			}
+		}
+	case datamodel.Kind_List:
+		iter := node.ListIterator()
+		for !iter.Done() {
+			_, value, err := iter.Next()
+			if err != nil {
+				return err
+			}
+			if err := appendIpldLinks(value, links); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkDag visits the links below root depth first without duplicates, the
+// block order of CAR responses. Raw blocks are leaves. The entity scope is
+// served like all, which is a superset of its blocks. Missing blocks end the
+// walk with NotFound unless onMissing is set, which is then called instead.
+func walkDag(
+	ctx context.Context,
+	blockstore *RedisBlockstore,
+	root cid.Cid,
+	scope string,
+	visit func(blocks.Block) error,
+	onMissing func(cid.Cid) error,
+) error {
+	visited := cid.NewSet()
+	stack := []cid.Cid{root}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !visited.Visit(c) {
+			continue
+		}
+		block, err := blockstore.Get(ctx, c)
+		if ipld.IsNotFound(err) {
+			if onMissing == nil {
+				return status.Errorf(codes.NotFound, "block %s not found", c)
+			}
+			if err := onMissing(c); err != nil {
+				return err
+			}
+			continue
+		} else if errors.Is(err, bstore.ErrHashMismatch) {
+			return status.Errorf(codes.DataLoss, "block %s does not match its cid", c)
+		} else if err != nil {
+			return status.Errorf(codes.Internal, "failed to get block %s: %v", c, err)
+		}
+		if err := visit(block); err != nil {
+			return err
+		}
+		if scope == GATEWAY_DAG_SCOPE_BLOCK {
+			continue
+		}
+		links, err := blockLinks(block)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to decode block %s: %v", c, err)
+		}
+		for i := len(links) - 1; i >= 0; i-- {
+			stack = append(stack, links[i])
+		}
+	}
+	return nil
+}
+
+func writeUvarintPrefixed(w io.Writer, chunks ...[]byte) error {
+	size := 0
+	for _, chunk := range chunks {
+		size += len(chunk)
+	}
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(size))); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCarV1Header(w io.Writer, root cid.Cid) error {
+	header, err := qp.BuildMap(basicnode.Prototype.Any, 2, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "roots", qp.List(1, func(la datamodel.ListAssembler) {
+			qp.ListEntry(la, qp.Link(cidlink.Link{Cid: root}))
+		}))
+		qp.MapEntry(ma, "version", qp.Int(1))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build car header: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := dagcbor.Encode(header, &buf); err != nil {
+		return fmt.Errorf("failed to encode car header: %v", err)
+	}
+	return writeUvarintPrefixed(w, buf.Bytes())
+}
+
+func writeCarV1Block(w io.Writer, block blocks.Block) error {
+	return writeUvarintPrefixed(w, block.Cid().Bytes(), block.RawData())
+}
+
+// WriteCarV1 writes the blocks as a CARv1 stream with a single root.
+func WriteCarV1(w io.Writer, root cid.Cid, blks []blocks.Block) error {
+	if err := writeCarV1Header(w, root); err != nil {
+		return err
+	}
+	for _, block := range blks {
+		if err := writeCarV1Block(w, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setValueMetadataHeaders exposes the metadata of the root value, so that
+// clients can decide when to prolong it from a HEAD request.
+func setValueMetadataHeaders(h http.Header, metadata *pb.ValueMetadata) {
+	h.Set("X-Pkv-Size", strconv.FormatInt(metadata.GetSize(), 10))
+	h.Set("X-Pkv-Codec", multicodec.Code(metadata.GetCodec()).String())
+	if metadata.GetExpireTime() != nil {
+		h.Set("X-Pkv-Expire-Time", metadata.GetExpireTime().AsTime().UTC().Format(time.RFC3339))
+	}
+	if metadata.GetCreateTime() != nil {
+		h.Set("Last-Modified", metadata.GetCreateTime().AsTime().UTC().Format(http.TimeFormat))
+	}
+}
+
+func etagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleGateway(w http.ResponseWriter, r *http.Request) {
+	root, err := cid.Decode(r.PathValue("cid"))
+	if err != nil {
+		writeGatewayError(w, status.Errorf(codes.InvalidArgument, "invalid cid: %v", err))
+		return
+	}
+	format, err := gatewayResponseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	scope, err := gatewayDagScope(r)
+	if err != nil {
+		writeGatewayError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	ctx, err := s.authenticateHttpSession(r)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	rootKey, err := valueKey(root)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+	metadata, err := s.getValueMetadata(ctx, rootKey)
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+	setValueMetadataHeaders(w.Header(), metadata)
+
+	etag := fmt.Sprintf(`"%s.raw"`, root)
+	if format == mediaTypeIpldCar {
+		etag = fmt.Sprintf(`"%s.car.%s"`, root, scope)
+	}
+	extension := map[string]string{mediaTypeIpldRaw: "bin", mediaTypeIpldCar: "car"}[format]
+	w.Header().Set("Etag", etag)
+	w.Header().Set("Cache-Control", gatewayImmutableCacheControl)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, root, extension))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Ipfs-Path", "/ipfs/"+root.String())
+	w.Header().Set("X-Ipfs-Roots", root.String())
+	w.Header().Add("Vary", "Accept")
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if format == mediaTypeIpldRaw {
+		s.serveGatewayBlock(ctx, w, r, root)
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypeIpldCar+"; version=1; order=dfs; dups=n")
+	w.Header().Set("Accept-Ranges", "none")
+	// The size of a CAR is only known by walking the DAG, which HEAD does not
+	if r.Method == http.MethodHead {
+		return
+	}
+	s.serveGatewayCar(ctx, w, root, scope)
+}
+
+// serveGatewayBlock charges and serves the root block, honoring Range and
+// HEAD requests. Only responses with a body are charged.
+func (s *Server) serveGatewayBlock(ctx context.Context, w http.ResponseWriter, r *http.Request, root cid.Cid) {
+	block, err := s.newBlockstore().Get(ctx, root)
+	if ipld.IsNotFound(err) {
+		writeGatewayError(w, status.Errorf(codes.NotFound, "block %s not found", root))
+		return
+	} else if errors.Is(err, bstore.ErrHashMismatch) {
+		writeGatewayError(w, status.Errorf(codes.DataLoss, "block %s does not match its cid", root))
+		return
+	} else if err != nil {
+		writeGatewayError(w, status.Errorf(codes.Internal, "failed to get block %s: %v", root, err))
+		return
+	}
+	if r.Method != http.MethodHead {
+		if err := s.chargeSession(ctx, s.config.IpfsGatewayBlockPrice); err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", mediaTypeIpldRaw)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(block.RawData()))
+}
+
+// serveGatewayCar streams the blocks of the DAG as they are walked, charging
+// each before it is written. Once the response has started, failures like
+// missing blocks or a session running out of balance abort it, so that
+// clients see a truncated CAR rather than a complete one.
+func (s *Server) serveGatewayCar(ctx context.Context, w http.ResponseWriter, root cid.Cid, scope string) {
+	started := false
+	err := walkDag(ctx, s.newBlockstore(), root, scope, func(block blocks.Block) error {
+		if err := s.chargeSession(ctx, s.config.IpfsGatewayBlockPrice); err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			if err := writeCarV1Header(w, root); err != nil {
+				return err
+			}
+		}
+		return writeCarV1Block(w, block)
+	}, nil)
+	if err == nil {
+		return
+	}
+	if !started {
+		writeGatewayError(w, err)
+		return
+	}
+	log.Printf("aborting car of %s: %v", root, err)
+	panic(http.ErrAbortHandler)
+}
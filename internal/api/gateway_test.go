@@ -0,0 +1,185 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const gatewayBaseUrl = "http://127.0.0.1:50051/ipfs/"
+
+// readCarV1 returns the cids of the blocks in a CARv1 stream after checking
+// that each block matches its cid.
+func readCarV1(r io.Reader) []cid.Cid {
+	reader := bufio.NewReader(r)
+	headerSize, err := binary.ReadUvarint(reader)
+	Expect(err).To(BeNil())
+	_, err = reader.Discard(int(headerSize))
+	Expect(err).To(BeNil())
+	ret := make([]cid.Cid, 0)
+	for {
+		size, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return ret
+		}
+		Expect(err).To(BeNil())
+		section := make([]byte, size)
+		_, err = io.ReadFull(reader, section)
+		Expect(err).To(BeNil())
+		cidSize, c, err := cid.CidFromBytes(section)
+		Expect(err).To(BeNil())
+		computed, err := c.Prefix().Sum(section[cidSize:])
+		Expect(err).To(BeNil())
+		Expect(computed.Equals(c)).To(BeTrue())
+		ret = append(ret, c)
+	}
+}
+
+var _ = Describe("Trustless gateway", Label("gateway"), func() {
+	ctx := context.Background()
+	sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+
+	var token string
+	var sessionId string
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+	})
+
+	get := func(c string, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, gatewayBaseUrl+c, nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+token)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		return resp
+	}
+
+	It("should serve raw blocks with caching headers and ranges", func() {
+		value := []byte("gateway value " + uuid.NewString())
+		rawCid := api.HashRawBytes(value)
+		Expect(RedisClient.Set(ctx, "values/"+rawCid, value, time.Minute).Err()).To(Succeed())
+
+		req, err := http.NewRequest(http.MethodGet, gatewayBaseUrl+rawCid, nil)
+		Expect(err).To(BeNil())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+		before := sessionBalance(ctx, sessionId)
+		resp = get(rawCid, map[string]string{"Accept": "application/vnd.ipld.raw"})
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/vnd.ipld.raw"))
+		Expect(resp.Header.Get("Etag")).To(Equal(`"` + rawCid + `.raw"`))
+		Expect(resp.Header.Get("Cache-Control")).To(ContainSubstring("immutable"))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(body).To(Equal(value))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - Conf.IpfsGatewayBlockPrice))
+
+		resp = get(rawCid, map[string]string{"Range": "bytes=0-6"})
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusPartialContent))
+		body, err = io.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(body).To(Equal(value[:7]))
+
+		before = sessionBalance(ctx, sessionId)
+		resp = get(rawCid, map[string]string{"If-None-Match": `"` + rawCid + `.raw"`})
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotModified))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before))
+	})
+
+	It("should serve dags as car files", func() {
+		leafValue := []byte("gateway leaf " + uuid.NewString())
+		leafCid, err := cid.Decode(api.HashRawBytes(leafValue))
+		Expect(err).To(BeNil())
+		root := merkledag.NodeWithData([]byte("gateway root"))
+		Expect(root.AddNodeLink("leaf", merkledag.NewRawNode(leafValue))).To(Succeed())
+		rootCid := cid.NewCidV1(cid.DagProtobuf, root.Cid().Hash())
+		Expect(RedisClient.Set(ctx, "values/"+rootCid.String(), root.RawData(), time.Minute).Err()).To(Succeed())
+
+		// Blocks are streamed as the dag is walked, so a missing block
+		// aborts the car
+		req, err := http.NewRequest(http.MethodGet, gatewayBaseUrl+rootCid.String()+"?format=car", nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		Expect(err).To(Not(BeNil()))
+
+		Expect(RedisClient.Set(ctx, "values/"+leafCid.String(), leafValue, time.Minute).Err()).To(Succeed())
+		before := sessionBalance(ctx, sessionId)
+		resp = get(rootCid.String(), map[string]string{"Accept": "application/vnd.ipld.car"})
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(HavePrefix("application/vnd.ipld.car"))
+		Expect(readCarV1(resp.Body)).To(Equal([]cid.Cid{rootCid, leafCid}))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before - 2*Conf.IpfsGatewayBlockPrice))
+
+		resp = get(rootCid.String()+"?format=car&dag-scope=block", nil)
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(readCarV1(resp.Body)).To(Equal([]cid.Cid{rootCid}))
+
+		resp = get(rootCid.String(), map[string]string{"Accept": "application/vnd.ipld.car; version=2"})
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotAcceptable))
+
+		resp = get(rootCid.String(), map[string]string{"Accept": "text/html"})
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotAcceptable))
+	})
+
+	It("should answer HEAD requests for cars without charging", func() {
+		root := merkledag.NodeWithData([]byte("gateway head " + uuid.NewString()))
+		Expect(root.AddNodeLink("missing", merkledag.NewRawNode([]byte("never stored")))).To(Succeed())
+		rootCid := cid.NewCidV1(cid.DagProtobuf, root.Cid().Hash())
+		Expect(RedisClient.Set(ctx, "values/"+rootCid.String(), root.RawData(), time.Minute).Err()).To(Succeed())
+
+		before := sessionBalance(ctx, sessionId)
+		req, err := http.NewRequest(http.MethodHead, gatewayBaseUrl+rootCid.String()+"?format=car", nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(HavePrefix("application/vnd.ipld.car"))
+		Expect(sessionBalance(ctx, sessionId)).To(Equal(before))
+	})
+
+	It("should deny sessions out of balance", func() {
+		value := []byte("gateway unpaid " + uuid.NewString())
+		rawCid := api.HashRawBytes(value)
+		Expect(RedisClient.Set(ctx, "values/"+rawCid, value, time.Minute).Err()).To(Succeed())
+		_, err := sessionManager.DeductSessionBalance(ctx, sessionId, sessionBalance(ctx, sessionId))
+		Expect(err).To(BeNil())
+
+		resp := get(rawCid, map[string]string{"Accept": "application/vnd.ipld.raw"})
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		resp = get(rawCid+"?format=car", nil)
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+	})
+})
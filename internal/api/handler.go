@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"bytes"
 	"crypto/sha256"
 	"io"
 
 	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
 	"github.com/ipfs/boxo/ipld/merkledag"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc/codes"
@@ -18,8 +21,14 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
+	_ "github.com/multiformats/go-multihash/register/blake2"
+	_ "github.com/multiformats/go-multihash/register/blake3"
 )
 
 func HashRawBytes(inputBytes []byte) string {
@@ -42,26 +51,84 @@ func (s *Server) Ping(
 	}), nil
 }
 
-func calculateCid(req *pb.CreateValueRequest) (string, error) {
-	switch req.GetCodec() {
-	case pb.CreateValueRequest_CODEC_DAG_PB:
-		protoNode, err := merkledag.DecodeProtobuf(req.GetValue())
-		if err != nil {
-			return "", err
+// valueCodecs are the codecs values can be stored with.
+var valueCodecs = map[pb.CreateValueRequest_Codec]uint64{
+	pb.CreateValueRequest_CODEC_RAW:      cid.Raw,
+	pb.CreateValueRequest_CODEC_DAG_PB:   cid.DagProtobuf,
+	pb.CreateValueRequest_CODEC_DAG_CBOR: cid.DagCBOR,
+	pb.CreateValueRequest_CODEC_DAG_JSON: cid.DagJSON,
+}
+
+var valueHashFunctions = map[pb.CreateValueRequest_HashFunction]uint64{
+	pb.CreateValueRequest_HASH_FUNCTION_UNSPECIFIED: multihash.SHA2_256,
+	pb.CreateValueRequest_HASH_FUNCTION_SHA2_256:    multihash.SHA2_256,
+	pb.CreateValueRequest_HASH_FUNCTION_SHA2_512:    multihash.SHA2_512,
+	pb.CreateValueRequest_HASH_FUNCTION_BLAKE2B_256: uint64(multicodec.Blake2b256),
+	pb.CreateValueRequest_HASH_FUNCTION_BLAKE3:      multihash.BLAKE3,
+}
+
+// validateBlock checks that data decodes with the codec of c, so that only
+// well formed blocks are stored and later traversed.
+func validateBlock(c cid.Cid, data []byte) error {
+	switch c.Type() {
+	case cid.Raw:
+		return nil
+	case cid.DagProtobuf:
+		if _, err := merkledag.DecodeProtobuf(data); err != nil {
+			return fmt.Errorf("invalid dag-pb block: %v", err)
 		}
-		if protoNode.Cid().Prefix().Codec != cid.DagProtobuf {
-			return "", fmt.Errorf(
-				"expected codec to be dag-pb (%d) but got %d",
-				cid.DagProtobuf,
-				protoNode.Cid().Prefix().Codec,
-			)
+		return nil
+	case cid.DagCBOR, cid.DagJSON:
+		if _, err := decodeIpldBlock(c, data); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("only raw, dag-pb, dag-cbor and dag-json codec are allowed")
+	}
+}
+
+// decodeIpldBlock decodes a dag-cbor or dag-json block into a data model node.
+func decodeIpldBlock(c cid.Cid, data []byte) (datamodel.Node, error) {
+	nb := basicnode.Prototype.Any.NewBuilder()
+	switch c.Type() {
+	case cid.DagCBOR:
+		if err := dagcbor.Decode(nb, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("invalid dag-cbor block: %v", err)
+		}
+	case cid.DagJSON:
+		if err := dagjson.Decode(nb, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("invalid dag-json block: %v", err)
 		}
-		return cid.NewCidV1(cid.DagProtobuf, protoNode.Cid().Hash()).String(), nil
-	case pb.CreateValueRequest_CODEC_RAW:
-		return HashRawBytes(req.GetValue()), nil
 	default:
-		return "", fmt.Errorf("only raw and dag-pb codec are allowed")
+		return nil, fmt.Errorf("codec %d is not dag-cbor or dag-json", c.Type())
+	}
+	return nb.Build(), nil
+}
+
+func calculateCid(req *pb.CreateValueRequest) (string, error) {
+	codec, ok := valueCodecs[req.GetCodec()]
+	if !ok {
+		return "", fmt.Errorf("only raw, dag-pb, dag-cbor and dag-json codec are allowed")
+	}
+	hashFunction, ok := valueHashFunctions[req.GetHashFunction()]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash function %v", req.GetHashFunction())
+	}
+	prefix := cid.Prefix{
+		Version:  1,
+		Codec:    codec,
+		MhType:   hashFunction,
+		MhLength: -1,
+	}
+	c, err := prefix.Sum(req.GetValue())
+	if err != nil {
+		return "", err
+	}
+	if err := validateBlock(c, req.GetValue()); err != nil {
+		return "", err
 	}
+	return c.String(), nil
 }
 
 func (s *Server) CreateValue(
@@ -78,22 +145,36 @@ func (s *Server) CreateValue(
 	}
 	// TODO: if cid turns out to be existing then prolong the ttl
 	name := fmt.Sprintf("values/%s", cid)
-	if err := s.redisClient.Set(
-		ctx,
-		name,
-		req.GetValue(),
-		req.GetTtl().AsDuration(),
-	).Err(); err != nil {
+	pipe := s.redisClient.TxPipeline()
+	storedSize, encoding, chunkRoot := s.storeValue(ctx, pipe, name, req.GetValue(), req.GetTtl().AsDuration())
+	// The compressed size is known before anything is written
+	if s.config.PricingSizeBasis == middleware.PRICING_SIZE_BASIS_STORED {
+		if err := s.chargeStream(
+			ctx, kvstoreconnect.KvStoreServiceCreateValueProcedure, storedSize,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, status.Error(
 			codes.Internal,
 			"failed to set value",
 		)
-	} else {
-		return connect.NewResponse(&pb.CreateValueResponse{
-			Name: name,
-			Ttl:  req.GetTtl(),
-		}), nil
 	}
+	resp := connect.NewResponse(&pb.CreateValueResponse{
+		Name:       name,
+		Ttl:        req.GetTtl(),
+		StoredSize: storedSize,
+		Encoding:   encoding,
+		ChunkRoot:  chunkRoot,
+	})
+	resp.Msg.Receipt, err = s.issueReceipt(
+		ctx, name, int64(len(req.GetValue())), time.Now().Add(req.GetTtl().AsDuration()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 func (s *Server) GetValue(
@@ -113,7 +194,7 @@ func (s *Server) GetValue(
 		return nil, err
 	}
 	cidKey := fmt.Sprintf("values/%s", cidV1)
-	value, err := s.redisClient.Get(ctx, cidKey).Result()
+	stored, encoding, err := getStoredValue(ctx, s.redisClient, cidKey)
 	if err == redis.Nil {
 		return nil, status.Error(
 			codes.NotFound,
@@ -124,11 +205,152 @@ func (s *Server) GetValue(
 			codes.Internal,
 			"failed to get value",
 		)
-	} else {
+	}
+	value, err := decodeValue(stored, encoding)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.DataLoss,
+			"failed to decode value: %v",
+			err,
+		)
+	}
+	if err := s.verifyOnRead(cidKey, value); err != nil {
+		return nil, err
+	}
+	// Clients decoding the value themselves save the transfer
+	if acceptsEncoding(req.GetAcceptedEncodings(), encoding) {
 		return connect.NewResponse(&pb.GetValueResponse{
-			Value: []byte(value),
+			Value:    stored,
+			Encoding: encoding,
 		}), nil
 	}
+	return connect.NewResponse(&pb.GetValueResponse{
+		Value: value,
+	}), nil
+}
+
+// normalizeValueName returns the redis key of a value resource name.
+func normalizeValueName(name string) (string, error) {
+	cid, find := strings.CutPrefix(name, "values/")
+	if !find {
+		return "", status.Errorf(
+			codes.InvalidArgument,
+			"expected resource name begin with \"values/\" but got %s",
+			name,
+		)
+	}
+	cidV1, err := NormalizeCidToV1(cid)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("values/%s", cidV1), nil
+}
+
+func resultStatus(err error) *pb.ResultStatus {
+	st := status.Convert(err)
+	return &pb.ResultStatus{
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}
+}
+
+func (s *Server) BatchGetValues(
+	ctx context.Context, connectReq *connect.Request[pb.BatchGetValuesRequest],
+) (*connect.Response[pb.BatchGetValuesResponse], error) {
+	req := connectReq.Msg
+	results := make([]*pb.BatchGetValuesResponse_Result, len(req.GetNames()))
+	keys := make([]string, 0, len(req.GetNames()))
+	keyIndices := make([]int, 0, len(req.GetNames()))
+	for i, name := range req.GetNames() {
+		results[i] = &pb.BatchGetValuesResponse_Result{Name: name}
+		key, err := normalizeValueName(name)
+		if err != nil {
+			results[i].Status = resultStatus(err)
+			continue
+		}
+		keys = append(keys, key)
+		keyIndices = append(keyIndices, i)
+	}
+	if len(keys) > 0 {
+		pipe := s.redisClient.Pipeline()
+		valuesCmd := pipe.MGet(ctx, keys...)
+		encodingCmds := make([]*redis.StringCmd, 0, len(keys))
+		for _, key := range keys {
+			encodingCmds = append(encodingCmds, pipe.HGet(ctx, valueMetadataKey(key), valueMetadataEncoding))
+		}
+		// Values stored before metadata was recorded have no encoding
+		pipe.Exec(ctx)
+		if err := valuesCmd.Err(); err != nil {
+			return nil, status.Error(
+				codes.Internal,
+				"failed to get values",
+			)
+		}
+		for j, value := range valuesCmd.Val() {
+			result := results[keyIndices[j]]
+			str, ok := value.(string)
+			if !ok {
+				result.Status = resultStatus(status.Error(codes.NotFound, "resource not found"))
+				continue
+			}
+			decoded, err := decodeValue([]byte(str), encodingCmds[j].Val())
+			if err != nil {
+				result.Status = resultStatus(status.Errorf(codes.DataLoss, "failed to decode value: %v", err))
+			} else if err := s.verifyOnRead(keys[j], decoded); err != nil {
+				result.Status = resultStatus(err)
+			} else {
+				result.Value = decoded
+			}
+		}
+	}
+	return connect.NewResponse(&pb.BatchGetValuesResponse{
+		Results: results,
+	}), nil
+}
+
+func (s *Server) StatValues(
+	ctx context.Context, connectReq *connect.Request[pb.StatValuesRequest],
+) (*connect.Response[pb.StatValuesResponse], error) {
+	req := connectReq.Msg
+	results := make([]*pb.StatValuesResponse_Result, len(req.GetNames()))
+	metadataFuncs := make([]func() (*pb.ValueMetadata, error), len(req.GetNames()))
+	pipe := s.redisClient.Pipeline()
+	for i, name := range req.GetNames() {
+		results[i] = &pb.StatValuesResponse_Result{Name: name}
+		key, err := normalizeValueName(name)
+		if err != nil {
+			results[i].Status = resultStatus(err)
+			continue
+		}
+		metadataFuncs[i] = queueValueMetadata(ctx, pipe, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, status.Error(
+			codes.Internal,
+			"failed to stat values",
+		)
+	}
+	for i, result := range results {
+		if result.Status != nil {
+			continue
+		}
+		metadata, err := metadataFuncs[i]()
+		if err != nil {
+			result.Status = resultStatus(err)
+			continue
+		}
+		result.Stat = &pb.ValueStat{
+			Name:  metadata.GetName(),
+			Size:  metadata.GetSize(),
+			Codec: metadata.GetCodec(),
+		}
+		if metadata.GetExpireTime() != nil {
+			result.Stat.Ttl = durationpb.New(time.Until(metadata.GetExpireTime().AsTime()))
+		}
+	}
+	return connect.NewResponse(&pb.StatValuesResponse{
+		Results: results,
+	}), nil
 }
 
 func (s *Server) ProlongValue(
@@ -171,15 +393,28 @@ func (s *Server) ProlongValue(
 		)
 	}
 	newTtl := req.GetTtl().AsDuration() + oldTtl
-	if err = s.redisClient.Expire(ctx, req.GetName(), newTtl).Err(); err != nil {
+	pipe := s.redisClient.TxPipeline()
+	pipe.Expire(ctx, req.GetName(), newTtl)
+	pipe.Expire(ctx, valueMetadataKey(req.GetName()), newTtl)
+	if _, err = pipe.Exec(ctx); err != nil {
 		return nil, status.Error(
 			codes.Internal,
 			"failed to set ttl",
 		)
-	} else {
-		return connect.NewResponse(&pb.ProlongValueResponse{
-			Name: req.GetName(),
-			Ttl:  durationpb.New(newTtl),
-		}), nil
 	}
+	metadata, err := s.getValueMetadata(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	resp := connect.NewResponse(&pb.ProlongValueResponse{
+		Name: req.GetName(),
+		Ttl:  durationpb.New(newTtl),
+	})
+	resp.Msg.Receipt, err = s.issueReceipt(
+		ctx, req.GetName(), metadata.GetSize(), time.Now().Add(newTtl),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
@@ -5,13 +5,16 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"slices"
 	"sort"
 
 	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
 	"github.com/ipfs/go-cid"
 	"github.com/mr-tron/base58/base58"
 	"github.com/redis/go-redis/v9"
+	"github.com/wealdtech/go-merkletree/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -20,8 +23,16 @@ func (s *Server) RegisterInstance(
 	ctx context.Context, connectReq *connect.Request[pb.RegisterInstanceRequest],
 ) (*connect.Response[pb.RegisterInstanceResponse], error) {
 	req := connectReq.Msg
-	processors := []MerkleTreeFileServing{NewServeAllFileServing(s.redisClient)}
-	for _, p := range processors {
+	if req.GetAdvertisement().GetProviderInstance().GetDid() == s.config.SelfIdentifier {
+		if err := s.advertiseSelf(req.GetAdvertisement()); err != nil {
+			return nil, status.Errorf(
+				codes.Internal,
+				"failed to describe this instance: %v",
+				err,
+			)
+		}
+	}
+	for _, p := range s.fileServings {
 		matching, err := p.IsBehaviorMatching(req.GetAdvertisement().GetVirtualService().GetBehaviorLink())
 		if err != nil {
 			return nil, status.Errorf(
@@ -55,6 +66,29 @@ func (s *Server) RegisterInstance(
 	)
 }
 
+// advertiseSelf adds the addresses this node serves on, including the
+// libp2p ones only known once the host is listening, to an advertisement of
+// its own.
+func (s *Server) advertiseSelf(advertisement *pb.ProviderAdvertise) error {
+	self, err := s.Instance()
+	if err != nil {
+		return err
+	}
+	instance := advertisement.GetProviderInstance()
+	instance.PeerId = self.GetPeerId()
+	for _, addr := range self.GetMultiaddrs() {
+		if !slices.Contains(instance.GetMultiaddrs(), addr) {
+			instance.Multiaddrs = append(instance.Multiaddrs, addr)
+		}
+	}
+	for _, protocol := range self.GetProtocols() {
+		if !slices.Contains(instance.GetProtocols(), protocol) {
+			instance.Protocols = append(instance.Protocols, protocol)
+		}
+	}
+	return nil
+}
+
 type AdWithScore struct {
 	score float64
 	ad    *pb.ProviderAdvertise
@@ -86,9 +120,14 @@ func (s *Server) searchInstances(
 		)
 	}
 	for _, z := range zs {
-		instanceKey := fmt.Sprintf("instance:%s", z.Member.(string))
 		var ad pb.ProviderAdvertise
-		if err = s.redisClient.Get(ctx, instanceKey).Scan(&ad); err != nil {
+		err := s.redisClient.Get(ctx, instanceAdKey(vHash, z.Member.(string))).Scan(&ad)
+		if err == redis.Nil {
+			// Registered before advertisements were kept per virtual service
+			instanceKey := fmt.Sprintf("instance:%s", z.Member.(string))
+			err = s.redisClient.Get(ctx, instanceKey).Scan(&ad)
+		}
+		if err != nil {
 			fmt.Printf("invalid instance advertisement from redis: %s\n", err)
 			continue
 		}
@@ -145,12 +184,16 @@ func NormalizeCidToV1(cidString string) (string, error) {
 		)
 	}
 	codec := requestCid.Prefix().Codec
-	if requestCid.Version() == 1 && (codec != cid.Raw && codec != cid.DagProtobuf) {
+	if requestCid.Version() == 1 && !slices.Contains(
+		[]uint64{cid.Raw, cid.DagProtobuf, cid.DagCBOR, cid.DagJSON}, codec,
+	) {
 		return "", status.Errorf(
 			codes.InvalidArgument,
-			"currently only raw codec (%d) and dag-pb (%d) are accepted but got %d",
+			"currently only raw (%d), dag-pb (%d), dag-cbor (%d) and dag-json (%d) codec are accepted but got %d",
 			cid.Raw,
 			cid.DagProtobuf,
+			cid.DagCBOR,
+			cid.DagJSON,
 			codec,
 		)
 	}
@@ -158,28 +201,23 @@ func NormalizeCidToV1(cidString string) (string, error) {
 	return cidV1.String(), nil
 }
 
-func (s *Server) doSearchCid(
-	ctx context.Context, connectReq *connect.Request[pb.SearchCidRequest],
-) (*connect.Response[pb.SearchCidResponse], error) {
-	// TODO: add pagination later
-	defaultVirtualServiceCount := int64(100)
-	defaultInstanceCount := int64(100)
-	req := connectReq.Msg
-	cidV1, err := NormalizeCidToV1(req.GetCid())
-	if err != nil {
-		return nil, err
-	}
+// searchVirtualServices returns the virtual services containing cidV1 along
+// with the inclusion proofs of it.
+func (s *Server) searchVirtualServices(
+	ctx context.Context, cidV1 string, count int64,
+) ([]*pb.VirtualService, []*pb.MerkleInclusionProof, error) {
 	cidKey := fmt.Sprintf("cid:vsvc:%s", cidV1)
-	it := s.redisClient.HScan(ctx, cidKey, 0, "", defaultVirtualServiceCount).Iterator()
-	virtualServices := make([]*pb.VirtualService, 0, defaultVirtualServiceCount)
+	it := s.redisClient.HScan(ctx, cidKey, 0, "", count).Iterator()
+	virtualServices := make([]*pb.VirtualService, 0, count)
+	inclusionProofs := make([]*pb.MerkleInclusionProof, 0, count)
 	for it.Next(ctx) {
 		if err := it.Err(); err == redis.Nil {
-			return nil, status.Error(
+			return nil, nil, status.Error(
 				codes.NotFound,
 				"not found",
 			)
 		} else if err != nil {
-			return nil, status.Errorf(
+			return nil, nil, status.Errorf(
 				codes.Internal,
 				"failed to scan: %s",
 				err.Error(),
@@ -198,6 +236,40 @@ func (s *Server) doSearchCid(
 			continue
 		}
 		virtualServices = append(virtualServices, &vsvc)
+		inclusionProofs = append(inclusionProofs, s.generateInclusionProof(ctx, vHash, &vsvc, cidV1))
+	}
+	return virtualServices, inclusionProofs, nil
+}
+
+func (s *Server) doSearchCid(
+	ctx context.Context, connectReq *connect.Request[pb.SearchCidRequest],
+) (*connect.Response[pb.SearchCidResponse], error) {
+	// TODO: add pagination later
+	defaultVirtualServiceCount := int64(100)
+	defaultInstanceCount := int64(100)
+	req := connectReq.Msg
+	cidV1, err := NormalizeCidToV1(req.GetCid())
+	if err != nil {
+		return nil, err
+	}
+	virtualServices, inclusionProofs, err := s.searchVirtualServices(ctx, cidV1, defaultVirtualServiceCount)
+	if err != nil {
+		return nil, err
+	}
+	if len(virtualServices) == 0 {
+		// Content ingested from IPNI is announced by multihash only and
+		// indexed as raw CIDs
+		parsed, err := cid.Decode(cidV1)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to decode cid: %v", err)
+		}
+		if parsed.Prefix().Codec != cid.Raw {
+			cidV1 = cid.NewCidV1(cid.Raw, parsed.Hash()).String()
+			virtualServices, inclusionProofs, err = s.searchVirtualServices(ctx, cidV1, defaultVirtualServiceCount)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if len(virtualServices) == 0 {
@@ -214,7 +286,14 @@ func (s *Server) doSearchCid(
 		if err != nil {
 			continue
 		}
-		instances = append(instances, batchInstances...)
+		subset := s.servesSubset(vsvc.GetBehaviorLink())
+		for _, instance := range batchInstances {
+			// Instances of behaviors like serve_subset only serve part of the
+			// virtual service, so check the cid against what they advertised.
+			if !subset || slices.Contains(instance.ad.GetCids(), cidV1) {
+				instances = append(instances, instance)
+			}
+		}
 	}
 	sort.Slice(instances, func(i, j int) bool {
 		return instances[i].score < instances[j].score
@@ -226,41 +305,92 @@ func (s *Server) doSearchCid(
 			return connect.NewResponse(&pb.SearchCidResponse{
 				VirtualServices:  virtualServices,
 				StorageInstances: ret,
+				InclusionProofs:  inclusionProofs,
 			}), nil
 		}
 	}
 	return connect.NewResponse(&pb.SearchCidResponse{
 		VirtualServices:  virtualServices,
 		StorageInstances: ret,
+		InclusionProofs:  inclusionProofs,
 	}), nil
 }
 
-func MustEncodeMultihash(c cid.Cid) string {
-	mhBytes, err := base58.Decode(c.Hash().B58String())
+// servesSubset tells whether instances of behavior serve only the CIDs they
+// advertised.
+func (s *Server) servesSubset(behavior *pb.GlobalLink) bool {
+	for _, p := range s.fileServings {
+		if matching, err := p.IsBehaviorMatching(behavior); err != nil || !matching {
+			continue
+		}
+		if subset, ok := p.(SubsetFileServing); ok {
+			return subset.ServesSubset()
+		}
+		return false
+	}
+	return false
+}
+
+// generateInclusionProof returns the proof recorded at registration, or else
+// one from the variant tree built from the registered leaves. An empty proof
+// is returned if neither is known.
+func (s *Server) generateInclusionProof(
+	ctx context.Context, vHash string, vsvc *pb.VirtualService, cidV1 string,
+) *pb.MerkleInclusionProof {
+	var recorded pb.MerkleInclusionProof
+	proofsKey := fmt.Sprintf("vsvc:proofs:%s", vHash)
+	if err := s.redisClient.HGet(ctx, proofsKey, cidV1).Scan(&recorded); err == nil {
+		return &recorded
+	} else if err != redis.Nil {
+		log.Printf("failed to get inclusion proof in virtual service %s: %v", vHash, err)
+	}
+	mt, err := s.variantTree(ctx, vHash, vsvc)
 	if err != nil {
-		log.Fatalf("failed to decode base58: %s", err.Error())
+		log.Printf("failed to rebuild merkle tree of virtual service %s: %v", vHash, err)
+		return &pb.MerkleInclusionProof{}
 	}
-	mhString := base64.StdEncoding.EncodeToString(mhBytes)
-	return mhString
+	if mt == nil {
+		return &pb.MerkleInclusionProof{}
+	}
+	proof, err := merkle.GenerateInclusionProof(mt, cidV1)
+	if err != nil {
+		log.Printf("failed to generate inclusion proof in virtual service %s: %v", vHash, err)
+		return &pb.MerkleInclusionProof{}
+	}
+	return proof
 }
 
-func (s *Server) DelegatedRouting(
-	ctx context.Context,
-	connectReq *connect.Request[pb.DelegatedRoutingRequest],
-) (*connect.Response[pb.DelegatedRoutingResponse], error) {
-	searchCidResponse, err := s.doSearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{
-		Cid: connectReq.Msg.GetCid(),
-	}))
+// variantTree builds the tree of the registered leaves once per variant root,
+// or returns nil if the leaves are unknown.
+func (s *Server) variantTree(
+	ctx context.Context, vHash string, vsvc *pb.VirtualService,
+) (*merkletree.MerkleTree, error) {
+	root := vsvc.GetVariantLink().GetName()
+	if mt, ok := s.variantTrees.Get(root); ok {
+		return mt, nil
+	}
+	leavesKey := fmt.Sprintf("vsvc:leaves:%s", vHash)
+	leaves, err := s.redisClient.LRange(ctx, leavesKey, 0, -1).Result()
+	if err != nil || len(leaves) == 0 {
+		return nil, nil
+	}
+	mt, err := merkle.NewCidTree(leaves)
 	if err != nil {
 		return nil, err
 	}
-	var ret []*pb.Instance
-	for _, instance := range searchCidResponse.Msg.GetStorageInstances() {
-		ret = append(ret, instance.GetProviderInstance())
+	// Leaves of a root are fixed, so a tree of other leaves is never cached
+	if builtRoot, err := merkle.RootCid(mt); err != nil || builtRoot != root {
+		return nil, fmt.Errorf("registered leaves not matching variant %s", root)
 	}
-	return connect.NewResponse(
-		&pb.DelegatedRoutingResponse{
-			Providers: ret,
-		},
-	), nil
+	s.variantTrees.Add(root, mt)
+	return mt, nil
+}
+
+func MustEncodeMultihash(c cid.Cid) string {
+	mhBytes, err := base58.Decode(c.Hash().B58String())
+	if err != nil {
+		log.Fatalf("failed to decode base58: %s", err.Error())
+	}
+	mhString := base64.StdEncoding.EncodeToString(mhBytes)
+	return mhString
 }
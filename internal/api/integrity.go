@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	CORRUPTION_SOURCE_READ  = "read"
+	CORRUPTION_SOURCE_SCRUB = "scrub"
+)
+
+var (
+	corruptedValuesDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "pkv",
+		Name:      "corrupted_values_detected_total",
+		Help:      "Stored values whose content does not hash to their cid.",
+	}, []string{"source"})
+	corruptedValuesDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "pkv",
+		Name:      "corrupted_values_deleted_total",
+		Help:      "Corrupted values removed by the scrubber.",
+	})
+	scrubbedValues = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "pkv",
+		Name:      "scrubbed_values_total",
+		Help:      "Stored values rehashed by the scrubber.",
+	})
+)
+
+// verifyValue checks that value hashes to the cid of the value stored at key.
+func verifyValue(key string, value []byte) error {
+	expected, err := cid.Decode(strings.TrimPrefix(key, "values/"))
+	if err != nil {
+		return fmt.Errorf("invalid cid of %s: %v", key, err)
+	}
+	actual, err := expected.Prefix().Sum(value)
+	if err != nil {
+		return fmt.Errorf("failed to hash value of %s: %v", key, err)
+	}
+	if !bytes.Equal(actual.Hash(), expected.Hash()) {
+		return fmt.Errorf("value of %s hashes to %s", key, actual)
+	}
+	return nil
+}
+
+// verifyOnRead rehashes a value about to be returned when VERIFY_ON_READ is
+// set, so that a corrupted store fails the read instead of serving bad data.
+func (s *Server) verifyOnRead(key string, value []byte) error {
+	if !s.config.VerifyOnRead {
+		return nil
+	}
+	if err := verifyValue(key, value); err != nil {
+		corruptedValuesDetected.WithLabelValues(CORRUPTION_SOURCE_READ).Inc()
+		log.Printf("corrupted value detected on read: %v", err)
+		return status.Error(codes.DataLoss, "stored value does not match its cid")
+	}
+	return nil
+}
+
+// Scrubber periodically rehashes stored values to find corrupted ones before
+// they are read.
+type Scrubber struct {
+	redisClient     *redis.Client
+	interval        time.Duration
+	budget          int64
+	deleteCorrupted bool
+
+	values *keyScanner
+}
+
+func NewScrubber(conf *Config, redisClient *redis.Client) *Scrubber {
+	return &Scrubber{
+		redisClient:     redisClient,
+		interval:        conf.ScrubInterval,
+		budget:          conf.ScrubBudget,
+		deleteCorrupted: conf.ScrubDeleteCorrupted,
+		values:          newKeyScanner(redisClient, "values/*"),
+	}
+}
+
+func (s *Scrubber) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ScrubRound(ctx); err != nil {
+				log.Printf("scrub round failed: %v", err)
+			}
+		}
+	}
+}
+
+// ScrubRound rehashes at most budget values, continuing from where the
+// previous round stopped, and returns the keys of the corrupted ones.
+func (s *Scrubber) ScrubRound(ctx context.Context) ([]string, error) {
+	corrupted := make([]string, 0)
+	var scrubbed int64
+	for scrubbed < s.budget {
+		key, ok, err := s.values.Next(ctx, s.budget-scrubbed)
+		if err != nil {
+			return corrupted, fmt.Errorf("failed to scan values: %v", err)
+		}
+		if !ok {
+			break
+		}
+		stored, encoding, err := getStoredValue(ctx, s.redisClient, key)
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return corrupted, fmt.Errorf("failed to get %s: %v", key, err)
+		}
+		scrubbed++
+		scrubbedValues.Inc()
+		value, err := decodeValue(stored, encoding)
+		if err == nil {
+			err = verifyValue(key, value)
+		}
+		if err != nil {
+			corruptedValuesDetected.WithLabelValues(CORRUPTION_SOURCE_SCRUB).Inc()
+			log.Printf("corrupted value detected by scrubber: %v", err)
+			corrupted = append(corrupted, key)
+			if s.deleteCorrupted {
+				if err := s.redisClient.Del(ctx, key, valueMetadataKey(key)).Err(); err != nil {
+					log.Printf("failed to delete corrupted value %s: %v", key, err)
+					continue
+				}
+				corruptedValuesDeleted.Inc()
+			}
+		}
+	}
+	return corrupted, nil
+}
@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ = Describe("Value integrity", Label("integrity"), func() {
+	ctx := context.Background()
+
+	var name string
+	BeforeEach(func() {
+		name = "values/" + api.HashRawBytes([]byte("original "+uuid.NewString()))
+		Expect(RedisClient.Set(ctx, name, []byte("tampered"), time.Minute).Err()).To(Succeed())
+	})
+
+	It("should refuse to return corrupted values when verifying on read", func() {
+		conf := *Conf
+		conf.VerifyOnRead = true
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		_, err = server.GetValue(ctx, connect.NewRequest(&pb.GetValueRequest{Name: name}))
+		Expect(err).To(Not(BeNil()))
+
+		conf.VerifyOnRead = false
+		server, err = api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		resp, err := server.GetValue(ctx, connect.NewRequest(&pb.GetValueRequest{Name: name}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetValue()).To(Equal([]byte("tampered")))
+	})
+
+	It("should find and delete corrupted values while scrubbing", func() {
+		conf := *Conf
+		conf.ScrubBudget = 1 << 20
+		conf.ScrubDeleteCorrupted = true
+		scrubber := api.NewScrubber(&conf, RedisClient)
+		corrupted, err := scrubber.ScrubRound(ctx)
+		Expect(err).To(BeNil())
+		Expect(corrupted).To(ContainElement(name))
+		Expect(RedisClient.Get(ctx, name).Err()).To(Equal(redis.Nil))
+	})
+})
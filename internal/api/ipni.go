@@ -0,0 +1,382 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/dagsync/ipnisync"
+	"github.com/ipni/go-libipni/dagsync/ipnisync/head"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	IPNI_VARIANT_VERSION = "v0.1.0"
+
+	// Advertisements walked back from the head in a single poll
+	ipniMaxChainDepth = 1000
+	// Entry chunks are the largest blocks and hold about 16k multihashes
+	ipniMaxBlockSize = 4 << 20
+)
+
+// IpniIngester follows the advertisement chains of IPNI publishers over HTTP
+// and indexes the announced multihashes as serve_all virtual services, one per
+// provider and context ID.
+type IpniIngester struct {
+	redisClient *redis.Client
+	httpClient  *http.Client
+	publishers  []string
+	interval    time.Duration
+	maxEntries  int
+	fileServing *ServeAllFileServing
+}
+
+func NewIpniIngester(conf *Config, redisClient *redis.Client, httpClient *http.Client) *IpniIngester {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	publishers := make([]string, 0, len(conf.IpniPublishers))
+	for _, publisher := range conf.IpniPublishers {
+		if publisher = strings.TrimSpace(publisher); publisher != "" {
+			publishers = append(publishers, publisher)
+		}
+	}
+	return &IpniIngester{
+		redisClient: redisClient,
+		httpClient:  httpClient,
+		publishers:  publishers,
+		interval:    conf.IpniPollInterval,
+		maxEntries:  conf.IpniMaxEntries,
+		fileServing: newServeAllFileServing(&pb.GlobalLink{
+			Name:       SERVE_ALL_BEHAVIOR_NAME,
+			Maintainer: conf.BehaviorMaintainer,
+			Version:    SERVE_ALL_BEHAVIOR_VERSION,
+		}, redisClient),
+	}
+}
+
+func (i *IpniIngester) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, publisher := range i.publishers {
+				if _, err := i.IngestPublisher(ctx, publisher); err != nil {
+					log.Printf("failed to ingest from ipni publisher %s: %v", publisher, err)
+				}
+			}
+		}
+	}
+}
+
+// ProviderDid returns the did:key of an ed25519 libp2p peer id.
+func ProviderDid(providerId peer.ID) (string, error) {
+	publicKey, err := providerId.ExtractPublicKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract public key of %s: %v", providerId, err)
+	}
+	if publicKey.Type() != crypto.Ed25519 {
+		return "", fmt.Errorf("provider %s is not an ed25519 peer", providerId)
+	}
+	raw, err := publicKey.Raw()
+	if err != nil {
+		return "", err
+	}
+	return Ed25519DidKey(raw), nil
+}
+
+// ipniInstanceDid identifies the instance of a provider serving one context,
+// so that advertisements with different context IDs do not overwrite each other.
+func ipniInstanceDid(providerDid string, contextId []byte) string {
+	return fmt.Sprintf("%s#%s", providerDid, base64.RawURLEncoding.EncodeToString(contextId))
+}
+
+func publisherBaseUrl(publisher string) (string, error) {
+	if strings.HasPrefix(publisher, "/") {
+		return MultiaddrToUrl(publisher)
+	}
+	return strings.TrimSuffix(publisher, "/"), nil
+}
+
+func (i *IpniIngester) fetch(ctx context.Context, url string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, ipniMaxBlockSize+1))
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if len(data) > ipniMaxBlockSize {
+		return nil, resp.StatusCode, fmt.Errorf("response from %s too large", url)
+	}
+	return data, resp.StatusCode, nil
+}
+
+func (i *IpniIngester) fetchBlock(ctx context.Context, baseUrl string, c cid.Cid) ([]byte, error) {
+	data, _, err := i.fetch(ctx, fmt.Sprintf("%s%s/%s", baseUrl, ipnisync.IPNIPath, c))
+	if err != nil {
+		return nil, err
+	}
+	actual, err := c.Prefix().Sum(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash block %s: %v", c, err)
+	}
+	if !bytes.Equal(actual.Hash(), c.Hash()) {
+		return nil, fmt.Errorf("block served for %s hashes to %s", c, actual)
+	}
+	return data, nil
+}
+
+// fetchHead returns the latest advertisement CID of the publisher, or
+// cid.Undef if nothing is published yet.
+func (i *IpniIngester) fetchHead(ctx context.Context, baseUrl string) (cid.Cid, peer.ID, error) {
+	data, statusCode, err := i.fetch(ctx, fmt.Sprintf("%s%s/head", baseUrl, ipnisync.IPNIPath))
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotFound {
+		return cid.Undef, "", nil
+	} else if err != nil {
+		return cid.Undef, "", err
+	}
+	signedHead, err := head.Decode(bytes.NewReader(data))
+	if err != nil {
+		return cid.Undef, "", fmt.Errorf("failed to decode head: %v", err)
+	}
+	publisherId, err := signedHead.Validate()
+	if err != nil {
+		return cid.Undef, "", fmt.Errorf("invalid head signature: %v", err)
+	}
+	link, ok := signedHead.Head.(cidlink.Link)
+	if !ok {
+		return cid.Undef, "", fmt.Errorf("head is not a cid link")
+	}
+	return link.Cid, publisherId, nil
+}
+
+func ipniPublisherKey(baseUrl string) string {
+	return fmt.Sprintf("ipni:publisher:%s", baseUrl)
+}
+
+// IngestPublisher processes the advertisements published since the last
+// ingested one, oldest first, and returns how many were processed.
+func (i *IpniIngester) IngestPublisher(ctx context.Context, publisher string) (int, error) {
+	baseUrl, err := publisherBaseUrl(publisher)
+	if err != nil {
+		return 0, err
+	}
+	headCid, publisherId, err := i.fetchHead(ctx, baseUrl)
+	if err != nil || !headCid.Defined() {
+		return 0, err
+	}
+	lastCid, err := i.redisClient.Get(ctx, ipniPublisherKey(baseUrl)).Result()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	ads := make([]*schema.Advertisement, 0)
+	for c := headCid; c.Defined() && c.String() != lastCid; {
+		if len(ads) == ipniMaxChainDepth {
+			log.Printf("ipni publisher %s has more than %d new advertisements, skipping older ones", baseUrl, ipniMaxChainDepth)
+			break
+		}
+		data, err := i.fetchBlock(ctx, baseUrl, c)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch advertisement %s: %v", c, err)
+		}
+		ad, err := schema.BytesToAdvertisement(c, data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode advertisement %s: %v", c, err)
+		}
+		ads = append(ads, &ad)
+		c = ad.PreviousCid()
+	}
+
+	for j := len(ads) - 1; j >= 0; j-- {
+		if err := i.ingestAdvertisement(ctx, baseUrl, publisherId, ads[j]); err != nil {
+			log.Printf("skipping ipni advertisement from %s: %v", baseUrl, err)
+		}
+	}
+	if err := i.redisClient.Set(ctx, ipniPublisherKey(baseUrl), headCid.String(), 0).Err(); err != nil {
+		return len(ads), err
+	}
+	return len(ads), nil
+}
+
+func (i *IpniIngester) fetchEntries(ctx context.Context, baseUrl string, entries cid.Cid) ([]string, error) {
+	cids := make([]string, 0)
+	seen := make(map[string]bool)
+	for c := entries; c.Defined(); {
+		data, err := i.fetchBlock(ctx, baseUrl, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch entries %s: %v", c, err)
+		}
+		chunk, err := schema.BytesToEntryChunk(c, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode entries %s: %v", c, err)
+		}
+		for _, mh := range chunk.Entries {
+			cidString := cid.NewCidV1(cid.Raw, mh).String()
+			if seen[cidString] {
+				continue
+			}
+			if len(cids) == i.maxEntries {
+				log.Printf("truncating entries of %s to %d multihashes", entries, i.maxEntries)
+				return cids, nil
+			}
+			seen[cidString] = true
+			cids = append(cids, cidString)
+		}
+		if chunk.Next == nil {
+			break
+		}
+		next, ok := chunk.Next.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("next entries of %s is not a cid link", c)
+		}
+		c = next.Cid
+	}
+	return cids, nil
+}
+
+func ipniProtocols(rawMetadata []byte) []string {
+	md := metadata.Default.New()
+	if err := md.UnmarshalBinary(rawMetadata); err != nil {
+		return nil
+	}
+	protocols := make([]string, 0, md.Len())
+	for _, code := range md.Protocols() {
+		protocols = append(protocols, code.String())
+	}
+	return protocols
+}
+
+func ipniContextKey(did string) string {
+	return fmt.Sprintf("ipni:context:%s", did)
+}
+
+func (i *IpniIngester) ingestAdvertisement(
+	ctx context.Context, baseUrl string, publisherId peer.ID, ad *schema.Advertisement,
+) error {
+	if err := ad.Validate(); err != nil {
+		return err
+	}
+	providerId, err := peer.Decode(ad.Provider)
+	if err != nil {
+		return fmt.Errorf("invalid provider %s: %v", ad.Provider, err)
+	}
+	signerId, err := ad.VerifySignature()
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if signerId != providerId && signerId != publisherId {
+		return fmt.Errorf("advertisement of %s signed by %s", providerId, signerId)
+	}
+	providerDid, err := ProviderDid(providerId)
+	if err != nil {
+		return err
+	}
+	did := ipniInstanceDid(providerDid, ad.ContextID)
+	if ad.IsRm {
+		return i.withdraw(ctx, did)
+	}
+
+	var cids []string
+	entries, ok := ad.Entries.(cidlink.Link)
+	if !ok {
+		return fmt.Errorf("entries of %s is not a cid link", did)
+	}
+	if entries.Cid == schema.NoEntries.Cid {
+		// Metadata only update, keeping the entries indexed before
+		var previous pb.ProviderAdvertise
+		if err := i.redisClient.Get(ctx, fmt.Sprintf("instance:%s", did)).Scan(&previous); err == redis.Nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		cids = previous.GetCids()
+	} else if cids, err = i.fetchEntries(ctx, baseUrl, entries.Cid); err != nil {
+		return err
+	}
+	if len(cids) == 0 {
+		return nil
+	}
+
+	root, err := CalculateMerkleRoot(cids)
+	if err != nil {
+		return err
+	}
+	advertisement := &pb.ProviderAdvertise{
+		ProviderInstance: &pb.Instance{
+			Did:        did,
+			PeerId:     providerId.String(),
+			Multiaddrs: ad.Addresses,
+			Protocols:  ipniProtocols(ad.Metadata),
+		},
+		VirtualService: &pb.VirtualService{
+			BehaviorLink: i.fileServing.behavior,
+			VariantLink: &pb.GlobalLink{
+				Name:       root,
+				Maintainer: providerDid,
+				Version:    IPNI_VARIANT_VERSION,
+			},
+		},
+		Cids:       cids,
+		ExpireTime: timestamppb.New(time.Now().Add(DEFAULT_TTL)),
+		UpdateTime: timestamppb.Now(),
+	}
+	vHash, err := HashMessage(advertisement.GetVirtualService())
+	if err != nil {
+		return err
+	}
+	// The context now points to different content, so leave the old variant
+	if err := i.leavePreviousVariant(ctx, did, vHash); err != nil {
+		return err
+	}
+	if err := i.fileServing.Register(ctx, advertisement); err != nil {
+		return err
+	}
+	return i.redisClient.Set(ctx, ipniContextKey(did), vHash, DEFAULT_TTL).Err()
+}
+
+func (i *IpniIngester) leavePreviousVariant(ctx context.Context, did string, vHash string) error {
+	previous, err := i.redisClient.Get(ctx, ipniContextKey(did)).Result()
+	if err == redis.Nil || previous == vHash {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return i.redisClient.ZRem(ctx, fmt.Sprintf("vsvc:instance:%s", previous), did).Err()
+}
+
+// withdraw removes the instance serving a context, as requested by a removal
+// advertisement.
+func (i *IpniIngester) withdraw(ctx context.Context, did string) error {
+	if err := i.leavePreviousVariant(ctx, did, ""); err != nil {
+		return err
+	}
+	return i.redisClient.Del(ctx, ipniContextKey(did), fmt.Sprintf("instance:%s", did)).Err()
+}
@@ -0,0 +1,191 @@
+package api_test
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/storage/memstore"
+	"github.com/ipni/go-libipni/dagsync/ipnisync/head"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/ipni/go-libipni/metadata"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeIpniPublisher serves an advertisement chain the way ipnisync does over
+// plain HTTP.
+type fakeIpniPublisher struct {
+	key crypto.PrivKey
+	// Signs advertisements instead of key if set
+	adSigner crypto.PrivKey
+	lsys     ipld.LinkSystem
+	head     cid.Cid
+	server   *httptest.Server
+}
+
+func newFakeIpniPublisher() *fakeIpniPublisher {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	Expect(err).To(BeNil())
+	store := &memstore.Store{}
+	lsys := cidlink.DefaultLinkSystem()
+	lsys.SetReadStorage(store)
+	lsys.SetWriteStorage(store)
+	p := &fakeIpniPublisher{key: key, lsys: lsys}
+	p.server = httptest.NewServer(http.HandlerFunc(p.serve))
+	return p
+}
+
+func (p *fakeIpniPublisher) serve(w http.ResponseWriter, r *http.Request) {
+	resource := strings.TrimPrefix(r.URL.Path, "/ipni/v1/ad/")
+	if resource == "head" {
+		if !p.head.Defined() {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		signedHead, err := head.NewSignedHead(p.head, "", p.key)
+		Expect(err).To(BeNil())
+		data, err := signedHead.Encode()
+		Expect(err).To(BeNil())
+		w.Write(data)
+		return
+	}
+	c, err := cid.Decode(resource)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := p.lsys.LoadRaw(ipld.LinkContext{}, cidlink.Link{Cid: c})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Write(data)
+}
+
+func (p *fakeIpniPublisher) store(node ipld.Node) ipld.Link {
+	link, err := p.lsys.Store(ipld.LinkContext{}, schema.Linkproto, node)
+	Expect(err).To(BeNil())
+	return link
+}
+
+// publish appends an advertisement of the multihashes to the chain. No
+// multihashes with isRm removes the context.
+func (p *fakeIpniPublisher) publish(contextId string, mhs []multihash.Multihash, isRm bool) {
+	providerId, err := peer.IDFromPrivateKey(p.key)
+	Expect(err).To(BeNil())
+	var entries ipld.Link = schema.NoEntries
+	if len(mhs) > 0 {
+		node, err := schema.EntryChunk{Entries: mhs}.ToNode()
+		Expect(err).To(BeNil())
+		entries = p.store(node)
+	}
+	md := metadata.Default.New(metadata.IpfsGatewayHttp{})
+	rawMetadata, err := md.MarshalBinary()
+	Expect(err).To(BeNil())
+	ad := schema.Advertisement{
+		Provider:  providerId.String(),
+		Addresses: []string{"/dns4/provider.example.com/tcp/443/https"},
+		Entries:   entries,
+		ContextID: []byte(contextId),
+		Metadata:  rawMetadata,
+		IsRm:      isRm,
+	}
+	if p.head.Defined() {
+		ad.PreviousID = cidlink.Link{Cid: p.head}
+	}
+	signer := p.key
+	if p.adSigner != nil {
+		signer = p.adSigner
+	}
+	Expect(ad.Sign(signer)).To(Succeed())
+	node, err := ad.ToNode()
+	Expect(err).To(BeNil())
+	p.head = p.store(node).(cidlink.Link).Cid
+}
+
+var _ = Describe("Ingest IPNI advertisements", Label("ipni"), func() {
+	ctx := context.Background()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	var publisher *fakeIpniPublisher
+	var ingester *api.IpniIngester
+	var rawCid string
+	var mh multihash.Multihash
+	BeforeEach(func() {
+		publisher = newFakeIpniPublisher()
+		DeferCleanup(publisher.server.Close)
+		ingester = api.NewIpniIngester(Conf, RedisClient, nil)
+		rawCid = api.HashRawBytes([]byte("announced through ipni " + publisher.server.URL))
+		parsed, err := cid.Decode(rawCid)
+		Expect(err).To(BeNil())
+		mh = parsed.Hash()
+	})
+
+	searchCid := func(c string) []*pb.ProviderAdvertise {
+		resp, err := client.SearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{Cid: c}))
+		if err != nil {
+			Expect(err.Error()).To(ContainSubstring("code = NotFound"))
+			return nil
+		}
+		return resp.Msg.GetStorageInstances()
+	}
+
+	It("should make announced multihashes searchable", func() {
+		publisher.publish("context-1", []multihash.Multihash{mh}, false)
+		processed, err := ingester.IngestPublisher(ctx, publisher.server.URL)
+		Expect(err).To(BeNil())
+		Expect(processed).To(Equal(1))
+
+		instances := searchCid(rawCid)
+		Expect(instances).To(HaveLen(1))
+		instance := instances[0].GetProviderInstance()
+		providerId, err := peer.IDFromPrivateKey(publisher.key)
+		Expect(err).To(BeNil())
+		Expect(instance.GetPeerId()).To(Equal(providerId.String()))
+		Expect(instance.GetProtocols()).To(Equal([]string{"transport-ipfs-gateway-http"}))
+
+		// dag-pb CIDs of the same multihash are found as well
+		Expect(searchCid(cid.NewCidV1(cid.DagProtobuf, mh).String())).To(HaveLen(1))
+
+		processed, err = ingester.IngestPublisher(ctx, publisher.server.URL)
+		Expect(err).To(BeNil())
+		Expect(processed).To(Equal(0))
+	})
+
+	It("should remove withdrawn contexts", func() {
+		publisher.publish("context-1", []multihash.Multihash{mh}, false)
+		publisher.publish("context-2", []multihash.Multihash{mh}, false)
+		_, err := ingester.IngestPublisher(ctx, publisher.server.URL)
+		Expect(err).To(BeNil())
+		Expect(searchCid(rawCid)).To(HaveLen(2))
+
+		publisher.publish("context-1", nil, true)
+		processed, err := ingester.IngestPublisher(ctx, publisher.server.URL)
+		Expect(err).To(BeNil())
+		Expect(processed).To(Equal(1))
+		Expect(searchCid(rawCid)).To(HaveLen(1))
+	})
+
+	It("should skip advertisements not signed by the provider or publisher", func() {
+		otherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		Expect(err).To(BeNil())
+		publisher.adSigner = otherKey
+		publisher.publish("context-1", []multihash.Multihash{mh}, false)
+		_, err = ingester.IngestPublisher(ctx, publisher.server.URL)
+		Expect(err).To(BeNil())
+		Expect(searchCid(rawCid)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mr-tron/base58"
+	"github.com/redis/go-redis/v9"
+)
+
+// sessionKeyId names a key by a digest of its public material, or of the
+// secret for HMAC keys, which must not leak through the kid header.
+func sessionKeyId(material []byte) string {
+	digest := sha256.Sum256(material)
+	return base58.Encode(digest[:12])
+}
+
+// sessionKeyMaker makes the session keys of algorithm from their material.
+func sessionKeyMaker(algorithm string) (middleware.SessionKeyMaker, error) {
+	switch algorithm {
+	case middleware.SESSION_SIGNING_ALGORITHM_HS256:
+		return func(generation int64, secret []byte) (*middleware.SessionKey, error) {
+			return &middleware.SessionKey{
+				Kid:             sessionKeyId(secret),
+				Generation:      generation,
+				Method:          jwt.SigningMethodHS256,
+				SigningKey:      secret,
+				VerificationKey: secret,
+			}, nil
+		}, nil
+	case middleware.SESSION_SIGNING_ALGORITHM_EDDSA:
+		return func(generation int64, seed []byte) (*middleware.SessionKey, error) {
+			if len(seed) != ed25519.SeedSize {
+				return nil, fmt.Errorf("expected a seed of %d bytes but got %d", ed25519.SeedSize, len(seed))
+			}
+			privateKey := ed25519.NewKeyFromSeed(seed)
+			publicKey := privateKey.Public().(ed25519.PublicKey)
+			return &middleware.SessionKey{
+				Kid:             sessionKeyId(publicKey),
+				Generation:      generation,
+				Method:          jwt.SigningMethodEdDSA,
+				SigningKey:      privateKey,
+				VerificationKey: publicKey,
+			}, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported session signing algorithm %s", algorithm)
+	}
+}
+
+// initialSessionKeyMaterial is the material of generation 0. For HS256 it is
+// jwtSecret itself, so that tokens issued before keyrings keep verifying
+// until the first rotation retires it.
+func initialSessionKeyMaterial(jwtSecret []byte, algorithm string) ([]byte, error) {
+	if algorithm == middleware.SESSION_SIGNING_ALGORITHM_HS256 {
+		return jwtSecret, nil
+	}
+	return DeriveKey(jwtSecret, fmt.Sprintf("SessionKey/%s/0", algorithm))
+}
+
+// NewSessionKeyring returns the keyring of the session tokens of the node,
+// shared by the nodes with the same secret seed, Redis and self identifier.
+func NewSessionKeyring(rdb *redis.Client, conf *Config) (*middleware.SessionKeyring, error) {
+	makeKey, err := sessionKeyMaker(conf.SessionSigningAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := initialSessionKeyMaterial(conf.JwtSecret, conf.SessionSigningAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	return middleware.NewSessionKeyring(
+		rdb,
+		fmt.Sprintf("session-keyring:%s", conf.SelfIdentifier),
+		makeKey,
+		initial,
+		conf.SessionKeyringSealKey,
+		conf.SessionKeyGracePeriod,
+	)
+}
+
+// RotateSessionKey signs new session tokens with a key of the next
+// generation, on every node sharing the keyring.
+func (s *Server) RotateSessionKey(ctx context.Context) (*middleware.SessionKey, error) {
+	return s.sessionKeys.Rotate(ctx)
+}
+
+// JwksHandler publishes the keys session tokens are verified with, empty
+// unless they are signed with EdDSA.
+func (s *Server) JwksHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwks, err := s.sessionKeys.JWKS(r.Context())
+		if err != nil {
+			log.Printf("failed to load session keys: %v", err)
+			http.Error(w, "failed to load session keys", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwks)
+	})
+}
@@ -0,0 +1,188 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Session key rotation", Label("keyring"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+
+	var conf api.Config
+	var server *api.Server
+	var client kvstoreconnect.KvStoreServiceClient
+	BeforeEach(func() {
+		conf = *Conf
+		// A node of its own keeps the keyring of the shared test node intact
+		conf.SelfIdentifier = "did:example:pkv-" + uuid.NewString()
+	})
+	start := func() {
+		var err error
+		server, err = api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		path, handler, err := api.NewKvStoreHandler(server)
+		Expect(err).To(BeNil())
+		mux := http.NewServeMux()
+		mux.Handle(path, handler)
+		mux.Handle("GET /.well-known/jwks.json", server.JwksHandler())
+		httpServer := httptest.NewServer(mux)
+		DeferCleanup(httpServer.Close)
+		keyringKey := "session-keyring:" + conf.SelfIdentifier
+		DeferCleanup(func() {
+			RedisClient.Del(ctx, keyringKey, keyringKey+":material")
+		})
+		client = kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, httpServer.URL)
+	}
+	createSession := func() string {
+		resp, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaTokenFor(uuid.NewString(), conf.SelfIdentifier),
+		}))
+		Expect(err).To(BeNil())
+		return resp.Msg.GetJwt()
+	}
+	useSession := func(token string) error {
+		req := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: []byte("keyring value " + uuid.NewString()),
+			Ttl:   durationpb.New(time.Minute),
+		})
+		req.Header().Set("Authorization", "Bearer "+token)
+		_, err := client.CreateValue(ctx, req)
+		return err
+	}
+	kidOf := func(token string) string {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+		Expect(err).To(BeNil())
+		kid, _ := parsed.Header["kid"].(string)
+		return kid
+	}
+
+	It("should keep verifying tokens of the previous key during the grace period", func() {
+		conf.SessionKeyGracePeriod = time.Hour
+		start()
+		before := createSession()
+		Expect(useSession(before)).To(Succeed())
+
+		key, err := server.RotateSessionKey(ctx)
+		Expect(err).To(BeNil())
+		Expect(key.Generation).To(Equal(int64(1)))
+		Expect(key.Kid).To(Not(Equal(kidOf(before))))
+
+		after := createSession()
+		Expect(kidOf(after)).To(Equal(key.Kid))
+		Expect(useSession(after)).To(Succeed())
+		Expect(useSession(before)).To(Succeed())
+	})
+
+	It("should rotate in random keys shared by the nodes of the keyring", func() {
+		conf.SessionKeyGracePeriod = time.Hour
+		start()
+		key, err := server.RotateSessionKey(ctx)
+		Expect(err).To(BeNil())
+		token := createSession()
+
+		// Another node sharing the keyring verifies the new key
+		other, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		path, handler, err := api.NewKvStoreHandler(other)
+		Expect(err).To(BeNil())
+		mux := http.NewServeMux()
+		mux.Handle(path, handler)
+		otherServer := httptest.NewServer(mux)
+		DeferCleanup(otherServer.Close)
+		client = kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, otherServer.URL)
+		Expect(useSession(token)).To(Succeed())
+
+		// The same generation of a keyring with the same secret gets another key
+		conf.SelfIdentifier = "did:example:pkv-" + uuid.NewString()
+		start()
+		otherKey, err := server.RotateSessionKey(ctx)
+		Expect(err).To(BeNil())
+		Expect(otherKey.Generation).To(Equal(key.Generation))
+		Expect(otherKey.Kid).To(Not(Equal(key.Kid)))
+	})
+
+	It("should only fall back to the first key for tokens without key id", func() {
+		conf.SessionKeyGracePeriod = time.Hour
+		start()
+		token := createSession()
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &middleware.SessionJwtClaims{})
+		Expect(err).To(BeNil())
+		resign := func(kid string) string {
+			resigned := jwt.NewWithClaims(jwt.SigningMethodHS256, parsed.Claims)
+			if kid != "" {
+				resigned.Header["kid"] = kid
+			}
+			signed, err := resigned.SignedString(conf.JwtSecret)
+			Expect(err).To(BeNil())
+			return signed
+		}
+
+		Expect(useSession(resign(""))).To(Succeed())
+		Expect(useSession(resign("unknown"))).To(Not(Succeed()))
+	})
+
+	It("should refuse tokens of keys rotated out past the grace period", func() {
+		conf.SessionKeyGracePeriod = 0
+		start()
+		before := createSession()
+		_, err := server.RotateSessionKey(ctx)
+		Expect(err).To(BeNil())
+
+		Expect(useSession(before)).To(Not(Succeed()))
+		Expect(useSession(createSession())).To(Succeed())
+	})
+
+	It("should publish Ed25519 session keys for other services", func() {
+		conf.SessionSigningAlgorithm = middleware.SESSION_SIGNING_ALGORITHM_EDDSA
+		start()
+		token := createSession()
+		Expect(useSession(token)).To(Succeed())
+
+		recorder := httptest.NewRecorder()
+		server.JwksHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(recorder.Body)
+		Expect(err).To(BeNil())
+		var jwks struct {
+			Keys []struct {
+				Kid string `json:"kid"`
+				X   string `json:"x"`
+			} `json:"keys"`
+		}
+		Expect(json.Unmarshal(body, &jwks)).To(Succeed())
+		Expect(jwks.Keys).To(HaveLen(1))
+		Expect(jwks.Keys[0].Kid).To(Equal(kidOf(token)))
+		x, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+		Expect(err).To(BeNil())
+
+		_, err = jwt.ParseWithClaims(token, &middleware.SessionJwtClaims{}, func(t *jwt.Token) (any, error) {
+			return ed25519.PublicKey(x), nil
+		}, jwt.WithValidMethods([]string{"EdDSA"}), jwt.WithAudience(conf.SelfIdentifier))
+		Expect(err).To(BeNil())
+	})
+
+	It("should refuse unknown signing algorithms", func() {
+		conf.SessionSigningAlgorithm = "RS256"
+		_, err := api.NewServer(&conf)
+		Expect(err).To(Not(BeNil()))
+	})
+})
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	libp2phttp "github.com/libp2p/go-libp2p/p2p/http"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// Protocol of the KvStoreService served over libp2p streams with HTTP
+	// semantics, so that the Connect handler and its interceptors are reused
+	PKV_LIBP2P_PROTOCOL = "/pkv/kvstore/1.0.0"
+	BITSWAP_PROTOCOL    = "transport-bitswap"
+)
+
+// NewLibp2pHost starts a libp2p host identified by the key derived from
+// SECRET_SEED, so that the peer id stays stable across restarts.
+func NewLibp2pHost(conf *Config) (host.Host, error) {
+	return libp2p.New(
+		libp2p.Identity(conf.Libp2pPrivateKey),
+		libp2p.ListenAddrStrings(conf.Libp2pListenAddrs...),
+		libp2p.NATPortMap(),
+		libp2p.EnableHolePunching(),
+	)
+}
+
+// Libp2pServer serves the KvStoreService over the streams of a libp2p host.
+type Libp2pServer struct {
+	host     host.Host
+	httpHost *libp2phttp.Host
+}
+
+func NewLibp2pServer(h host.Host, path string, handler http.Handler) *Libp2pServer {
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	httpHost := &libp2phttp.Host{
+		StreamHost: h,
+	}
+	httpHost.SetHTTPHandler(PKV_LIBP2P_PROTOCOL, mux)
+	return &Libp2pServer{
+		host:     h,
+		httpHost: httpHost,
+	}
+}
+
+// Serve blocks until the server is closed.
+func (s *Libp2pServer) Serve() error {
+	return s.httpHost.Serve()
+}
+
+func (s *Libp2pServer) Close() error {
+	return s.httpHost.Close()
+}
+
+// Multiaddrs returns the listen addresses of the host, each ending with its
+// peer id so that they can be dialed directly.
+func (s *Libp2pServer) Multiaddrs() []string {
+	p2pComponent, err := multiaddr.NewComponent("p2p", s.host.ID().String())
+	if err != nil {
+		return nil
+	}
+	ret := make([]string, 0, len(s.host.Addrs()))
+	for _, addr := range s.host.Addrs() {
+		ret = append(ret, addr.Encapsulate(p2pComponent).String())
+	}
+	return ret
+}
+
+// Instance describes this node for its advertisements, listing the
+// configured public HTTP multiaddrs and the libp2p ones if a host is running.
+// Advertisements of this node registered with RegisterInstance include them.
+func (s *Server) Instance() (*pb.Instance, error) {
+	peerId, err := peer.IDFromPrivateKey(s.config.Libp2pPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive peer id: %v", err)
+	}
+	instance := &pb.Instance{
+		Did:        s.config.SelfIdentifier,
+		PeerId:     peerId.String(),
+		Multiaddrs: make([]string, 0),
+		Protocols:  make([]string, 0),
+	}
+	if len(s.config.AdvertisedMultiaddrs) > 0 {
+		instance.Multiaddrs = append(instance.Multiaddrs, s.config.AdvertisedMultiaddrs...)
+		instance.Protocols = append(instance.Protocols, PKV_CONNECT_PROTOCOL)
+	}
+	if s.libp2pServer != nil {
+		instance.Multiaddrs = append(instance.Multiaddrs, s.libp2pServer.Multiaddrs()...)
+		instance.Protocols = append(instance.Protocols, PKV_LIBP2P_PROTOCOL)
+		if s.bitswapServer != nil {
+			instance.Protocols = append(instance.Protocols, BITSWAP_PROTOCOL)
+		}
+	}
+	return instance, nil
+}
+
+// SetLibp2pServer makes the libp2p addresses part of the advertised instance.
+func (s *Server) SetLibp2pServer(libp2pServer *Libp2pServer) {
+	s.libp2pServer = libp2pServer
+}
+
+// SetBitswapServer advertises bitswap on the libp2p addresses.
+func (s *Server) SetBitswapServer(bitswapServer *BitswapServer) {
+	s.bitswapServer = bitswapServer
+}
+
+// NewLibp2pKvStoreClient returns a client calling the KvStoreService of the
+// server peer over libp2p streams from h.
+func NewLibp2pKvStoreClient(h host.Host, server peer.AddrInfo) (kvstoreconnect.KvStoreServiceClient, error) {
+	httpHost := &libp2phttp.Host{
+		StreamHost: h,
+	}
+	httpClient, err := httpHost.NamespacedClient(PKV_LIBP2P_PROTOCOL, server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", server.ID, err)
+	}
+	// The host part is ignored since requests go through libp2p streams
+	return kvstoreconnect.NewKvStoreServiceClient(&httpClient, "http://"+server.ID.String()), nil
+}
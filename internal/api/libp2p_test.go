@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Serve over libp2p", Label("libp2p"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+
+	var server *api.Server
+	var libp2pServer *api.Libp2pServer
+	var client kvstoreconnect.KvStoreServiceClient
+	BeforeEach(func() {
+		var err error
+		server, err = api.NewServer(Conf)
+		Expect(err).To(BeNil())
+		path, handler, err := api.NewKvStoreHandler(server)
+		Expect(err).To(BeNil())
+
+		mn := mocknet.New()
+		DeferCleanup(mn.Close)
+		serverHost, err := mn.AddPeer(Conf.Libp2pPrivateKey, multiaddr.StringCast("/ip4/127.0.0.1/tcp/4001"))
+		Expect(err).To(BeNil())
+		clientHost, err := mn.GenPeer()
+		Expect(err).To(BeNil())
+		Expect(mn.LinkAll()).To(Succeed())
+		Expect(mn.ConnectAllButSelf()).To(Succeed())
+
+		libp2pServer = api.NewLibp2pServer(serverHost, path, handler)
+		server.SetLibp2pServer(libp2pServer)
+		go libp2pServer.Serve()
+		DeferCleanup(libp2pServer.Close)
+
+		Eventually(func() error {
+			client, err = api.NewLibp2pKvStoreClient(clientHost, peer.AddrInfo{ID: serverHost.ID()})
+			return err
+		}).WithTimeout(5 * time.Second).Should(Succeed())
+	})
+
+	It("should apply the same interceptors", func() {
+		_, err := client.Ping(ctx, connect.NewRequest(&pb.PingRequest{Dummy: 1}))
+		Expect(err).To(BeNil())
+		_, err = client.Ping(ctx, connect.NewRequest(&pb.PingRequest{Dummy: 0}))
+		Expect(err).To(Not(BeNil()))
+
+		createReq := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: []byte("over libp2p"),
+			Ttl:   durationpb.New(1000 * time.Second),
+		})
+		_, err = client.CreateValue(ctx, createReq)
+		Expect(err).To(Not(BeNil()))
+
+		sessionResp, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: issuer.IssueQuotaToken(uuid.NewString()),
+		}))
+		Expect(err).To(BeNil())
+		createReq.Header().Set("authorization", "bearer "+sessionResp.Msg.GetJwt())
+		createResp, err := client.CreateValue(ctx, createReq)
+		Expect(err).To(BeNil())
+
+		getReq := connect.NewRequest(&pb.GetValueRequest{Name: createResp.Msg.GetName()})
+		getReq.Header().Set("authorization", "bearer "+sessionResp.Msg.GetJwt())
+		getResp, err := client.GetValue(ctx, getReq)
+		Expect(err).To(BeNil())
+		Expect(getResp.Msg.GetValue()).To(Equal([]byte("over libp2p")))
+	})
+
+	It("should advertise the libp2p multiaddrs", func() {
+		instance, err := server.Instance()
+		Expect(err).To(BeNil())
+		Expect(instance.GetProtocols()).To(ContainElement(api.PKV_LIBP2P_PROTOCOL))
+		Expect(instance.GetMultiaddrs()).To(ContainElement(
+			"/ip4/127.0.0.1/tcp/4001/p2p/" + instance.GetPeerId(),
+		))
+		peerId, err := api.InstancePeerId(instance)
+		Expect(err).To(BeNil())
+		Expect(strings.HasSuffix(libp2pServer.Multiaddrs()[0], peerId.String())).To(BeTrue())
+	})
+	It("should add the libp2p multiaddrs to registrations of this node", func() {
+		cids := []string{api.HashRawBytes([]byte("self " + uuid.NewString()))}
+		root, err := api.CalculateMerkleRoot(cids)
+		Expect(err).To(BeNil())
+		_, err = server.RegisterInstance(ctx, connect.NewRequest(&pb.RegisterInstanceRequest{
+			Advertisement: &pb.ProviderAdvertise{
+				ProviderInstance: &pb.Instance{
+					Did:        Conf.SelfIdentifier,
+					Multiaddrs: []string{"/dns4/pkv.example.com/tcp/443/https"},
+				},
+				VirtualService: &pb.VirtualService{
+					BehaviorLink: api.ServeAllBehavior,
+					VariantLink:  &pb.GlobalLink{Name: root},
+				},
+				Cids: cids,
+			},
+		}))
+		Expect(err).To(BeNil())
+
+		resp, err := server.SearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{Cid: cids[0]}))
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetStorageInstances()).To(HaveLen(1))
+		instance := resp.Msg.GetStorageInstances()[0].GetProviderInstance()
+		Expect(instance.GetMultiaddrs()).To(ContainElements(
+			"/dns4/pkv.example.com/tcp/443/https",
+			libp2pServer.Multiaddrs()[0],
+		))
+		Expect(instance.GetProtocols()).To(ContainElement(api.PKV_LIBP2P_PROTOCOL))
+	})
+})
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/ed25519"
 	"time"
 
 	"connectrpc.com/connect"
@@ -13,21 +14,21 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func (s *Server) generateJwt(sessionId string, expireAt time.Time) (string, error) {
+func (s *Server) generateJwt(ctx context.Context, sessionId string, expireAt time.Time) (string, error) {
 	claims := &middleware.SessionJwtClaims{
 		RegisteredClaims: &jwt.RegisteredClaims{
 			Issuer:    s.config.SelfIdentifier,
 			Audience:  jwt.ClaimStrings{s.config.SelfIdentifier},
 			ExpiresAt: jwt.NewNumericDate(expireAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
 			Subject:   sessionId,
 			ID:        uuid.NewString(),
 		},
 		Usage: pb.JwtUsage_JWT_USAGE_MANAGE_SESSION,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token.Header["kid"] = s.config.TokenSigningKeyId
-	jwt, err := token.SignedString(s.config.JwtSecret)
+	jwt, err := s.sessionKeys.Sign(ctx, claims)
 	if err != nil {
 		return "", status.Errorf(
 			codes.InvalidArgument,
@@ -76,7 +77,11 @@ func (s *Server) CreateSession(
 		)
 	}
 	session, err := s.sessionManager.CreateSession(
-		ctx, createSessionClaims.ID, createSessionClaims.Quantity, time.Until(expireAt.Time),
+		ctx,
+		createSessionClaims.Issuer,
+		createSessionClaims.ID,
+		createSessionClaims.Quantity,
+		time.Until(expireAt.Time),
 	)
 	if err != nil {
 		return nil, status.Errorf(
@@ -85,7 +90,7 @@ func (s *Server) CreateSession(
 			err.Error(),
 		)
 	}
-	jwt, err := s.generateJwt(session.GetSessionId(), expireAt.Time)
+	jwt, err := s.generateJwt(ctx, session.GetSessionId(), expireAt.Time)
 	if err != nil {
 		return nil, status.Errorf(
 			codes.Internal,
@@ -98,3 +103,114 @@ func (s *Server) CreateSession(
 		Jwt:     jwt,
 	}), nil
 }
+
+// RevokeSession invalidates the session of the bearer token, so that a leaked
+// token cannot drain the balance left, which is returned as a voucher
+// redeemable at the issuing exchange.
+func (s *Server) RevokeSession(
+	ctx context.Context, req *connect.Request[pb.RevokeSessionRequest],
+) (*connect.Response[pb.RevokeSessionResponse], error) {
+	session, ok := ctx.Value(middleware.KeySession).(*pb.Session)
+	if !ok {
+		return nil, status.Error(
+			codes.Unauthenticated,
+			"no session to revoke",
+		)
+	}
+	refund, err := s.revokeSession(ctx, session.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	voucher, err := s.refundVoucher(refund)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&pb.RevokeSessionResponse{
+		Refund:  refund,
+		Voucher: voucher,
+	}), nil
+}
+
+// refundVoucherTtl leaves exchanges as long to settle vouchers as revoked
+// sessions keep their refund record.
+const refundVoucherTtl = 30 * 24 * time.Hour
+
+// CloseSession ends the session of the bearer token like RevokeSession and
+// hands the balance left back as a voucher, redeemable at the exchange which
+// issued the quota token instead of expiring with the session. Sessions
+// revoked before, by their holder or the operator, get the voucher of the
+// refund recorded then.
+func (s *Server) CloseSession(
+	ctx context.Context, req *connect.Request[pb.CloseSessionRequest],
+) (*connect.Response[pb.CloseSessionResponse], error) {
+	session, ok := ctx.Value(middleware.KeySession).(*pb.Session)
+	if !ok {
+		return nil, status.Error(
+			codes.Unauthenticated,
+			"no session to close",
+		)
+	}
+	revoked, err := s.sessionManager.IsSessionRevoked(ctx, session.GetSessionId())
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Internal,
+			"failed to check session revocation: %v",
+			err,
+		)
+	}
+	var refund *pb.SessionRefund
+	if revoked {
+		refund, err = s.sessionManager.GetSessionRefund(ctx, session.GetSessionId())
+		if err != nil {
+			return nil, status.Errorf(
+				codes.NotFound,
+				"failed to get refund: %v",
+				err,
+			)
+		}
+	} else if refund, err = s.revokeSession(ctx, session.GetSessionId()); err != nil {
+		return nil, err
+	}
+	voucher, err := s.refundVoucher(refund)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&pb.CloseSessionResponse{
+		Refund:  refund,
+		Voucher: voucher,
+	}), nil
+}
+
+// refundVoucher signs a voucher for refund with the exchange account key, or
+// returns an empty one when no balance is left.
+func (s *Server) refundVoucher(refund *pb.SessionRefund) (string, error) {
+	if refund.GetBalance() <= 0 {
+		return "", nil
+	}
+	voucher, err := middleware.SignRefundVoucher(
+		refund,
+		Ed25519DidKey(s.config.ExchangeAccountPrivateKey.Public().(ed25519.PublicKey)),
+		s.config.ExchangeAccountPrivateKey,
+		refundVoucherTtl,
+	)
+	if err != nil {
+		return "", status.Errorf(
+			codes.Internal,
+			"failed to issue refund voucher: %v",
+			err,
+		)
+	}
+	return voucher, nil
+}
+
+func (s *Server) revokeSession(ctx context.Context, sessionId string) (*pb.SessionRefund, error) {
+	refund, err := s.sessionManager.RevokeSession(ctx, sessionId)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.NotFound,
+			"failed to revoke session: %s",
+			err.Error(),
+		)
+	}
+	return refund, nil
+}
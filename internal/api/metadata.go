@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/go-cid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	valueMetadataSize       = "size"
+	valueMetadataStoredSize = "stored_size"
+	valueMetadataEncoding   = "encoding"
+	valueMetadataCreatedAt  = "created_at"
+	valueMetadataChunkRoot  = "chunk_root"
+)
+
+// valueMetadataKey is the hash holding the metadata of the value stored at
+// key. It expires together with the value.
+func valueMetadataKey(key string) string {
+	return fmt.Sprintf("value:meta:%s", strings.TrimPrefix(key, "values/"))
+}
+
+// setValueMetadata records the sizes, encoding and chunk root of a value being
+// stored at key. The creation time of a value stored again is kept.
+func setValueMetadata(
+	ctx context.Context,
+	pipe redis.Pipeliner,
+	key string,
+	size int64,
+	storedSize int64,
+	encoding string,
+	chunkRoot []byte,
+	ttl time.Duration,
+) {
+	metadataKey := valueMetadataKey(key)
+	pipe.HSet(
+		ctx, metadataKey,
+		valueMetadataSize, size,
+		valueMetadataStoredSize, storedSize,
+		valueMetadataEncoding, encoding,
+		valueMetadataChunkRoot, chunkRoot,
+	)
+	pipe.HSetNX(ctx, metadataKey, valueMetadataCreatedAt, time.Now().UnixMilli())
+	pipe.Expire(ctx, metadataKey, ttl)
+}
+
+// storeValue adds the writes of value, compressed when worth it, and its
+// metadata to pipe. It returns the stored size, encoding and chunk root.
+func (s *Server) storeValue(
+	ctx context.Context, pipe redis.Pipeliner, key string, value []byte, ttl time.Duration,
+) (int64, string, []byte) {
+	stored, encoding := s.compressor.Encode(value)
+	// Empty blocks imported from CARs have no chunks to challenge
+	chunkRoot, _ := merkle.ChunkRoot(value)
+	pipe.Set(ctx, key, stored, ttl)
+	setValueMetadata(ctx, pipe, key, int64(len(value)), int64(len(stored)), encoding, chunkRoot, ttl)
+	return int64(len(stored)), encoding, chunkRoot
+}
+
+// getStoredValue returns the value at key as stored along with its encoding.
+// Missing values return redis.Nil.
+func getStoredValue(ctx context.Context, rdb redis.Cmdable, key string) ([]byte, string, error) {
+	pipe := rdb.Pipeline()
+	valueCmd := pipe.Get(ctx, key)
+	encodingCmd := pipe.HGet(ctx, valueMetadataKey(key), valueMetadataEncoding)
+	// Errors are checked per command, values stored before metadata was
+	// recorded have no encoding
+	pipe.Exec(ctx)
+	if err := valueCmd.Err(); err != nil {
+		return nil, "", err
+	}
+	if err := encodingCmd.Err(); err != nil && err != redis.Nil {
+		return nil, "", err
+	}
+	return []byte(valueCmd.Val()), encodingCmd.Val(), nil
+}
+
+// getValue returns the original bytes of the value at key.
+func getValue(ctx context.Context, rdb redis.Cmdable, key string) ([]byte, error) {
+	stored, encoding, err := getStoredValue(ctx, rdb, key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeValue(stored, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", key, err)
+	}
+	return value, nil
+}
+
+// queueValueMetadata adds the reads of the metadata of the value at key to
+// pipe and returns a function building the metadata once pipe is executed.
+func queueValueMetadata(
+	ctx context.Context, pipe redis.Pipeliner, key string,
+) func() (*pb.ValueMetadata, error) {
+	ttlCmd := pipe.PTTL(ctx, key)
+	sizeCmd := pipe.StrLen(ctx, key)
+	metadataCmd := pipe.HGetAll(ctx, valueMetadataKey(key))
+	return func() (*pb.ValueMetadata, error) {
+		c, err := cid.Decode(strings.TrimPrefix(key, "values/"))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cid: %v", err)
+		}
+		if err := errors.Join(ttlCmd.Err(), sizeCmd.Err(), metadataCmd.Err()); err != nil {
+			return nil, status.Error(
+				codes.Internal,
+				"failed to get metadata",
+			)
+		}
+		ttl := ttlCmd.Val()
+		if ttl == -2 {
+			return nil, status.Error(
+				codes.NotFound,
+				"resource not found",
+			)
+		}
+		ret := &pb.ValueMetadata{
+			Name:       key,
+			Size:       sizeCmd.Val(),
+			StoredSize: sizeCmd.Val(),
+			Codec:      c.Type(),
+			Encoding:   VALUE_ENCODING_NONE,
+		}
+		if ttl > 0 {
+			ret.ExpireTime = timestamppb.New(time.Now().Add(ttl))
+		}
+		// Values stored before metadata was recorded only have their stored
+		// size and are not compressed
+		metadata := metadataCmd.Val()
+		if size, err := strconv.ParseInt(metadata[valueMetadataSize], 10, 64); err == nil {
+			ret.Size = size
+		}
+		if encoding, ok := metadata[valueMetadataEncoding]; ok {
+			ret.Encoding = encoding
+		}
+		if createdAt, err := strconv.ParseInt(metadata[valueMetadataCreatedAt], 10, 64); err == nil {
+			ret.CreateTime = timestamppb.New(time.UnixMilli(createdAt))
+		}
+		if chunkRoot := metadata[valueMetadataChunkRoot]; chunkRoot != "" {
+			ret.ChunkRoot = []byte(chunkRoot)
+		}
+		return ret, nil
+	}
+}
+
+func (s *Server) getValueMetadata(ctx context.Context, key string) (*pb.ValueMetadata, error) {
+	pipe := s.redisClient.Pipeline()
+	metadata := queueValueMetadata(ctx, pipe, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, status.Error(
+			codes.Internal,
+			"failed to get metadata",
+		)
+	}
+	return metadata()
+}
+
+func (s *Server) GetValueMetadata(
+	ctx context.Context, connectReq *connect.Request[pb.GetValueMetadataRequest],
+) (*connect.Response[pb.ValueMetadata], error) {
+	key, err := normalizeValueName(connectReq.Msg.GetName())
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.getValueMetadata(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(metadata), nil
+}
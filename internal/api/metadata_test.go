@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Value metadata", Label("metadata"), func() {
+	ctx := context.Background()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	var token string
+	BeforeEach(func() {
+		token, _ = newSession(ctx)
+	})
+
+	getMetadata := func(name string) (*pb.ValueMetadata, error) {
+		req := connect.NewRequest(&pb.GetValueMetadataRequest{Name: name})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := client.GetValueMetadata(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	It("should report size, codec, expiry and creation time", func() {
+		value := []byte("value with metadata " + uuid.NewString())
+		createReq := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Hour),
+		})
+		createReq.Header().Set("Authorization", "Bearer "+token)
+		createResp, err := client.CreateValue(ctx, createReq)
+		Expect(err).To(BeNil())
+		name := createResp.Msg.GetName()
+
+		metadata, err := getMetadata(name)
+		Expect(err).To(BeNil())
+		Expect(metadata.GetName()).To(Equal(name))
+		Expect(metadata.GetSize()).To(Equal(int64(len(value))))
+		Expect(metadata.GetCodec()).To(Equal(uint64(cid.Raw)))
+		Expect(metadata.GetExpireTime().AsTime()).To(BeTemporally("~", time.Now().Add(time.Hour), 5*time.Second))
+		Expect(metadata.GetCreateTime().AsTime()).To(BeTemporally("~", time.Now(), 5*time.Second))
+
+		req, err := http.NewRequest(http.MethodHead, gatewayBaseUrl+strings.TrimPrefix(name, "values/"), nil)
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("X-Pkv-Size")).To(Equal(strconv.Itoa(len(value))))
+		Expect(resp.Header.Get("X-Pkv-Codec")).To(Equal("raw"))
+		Expect(resp.Header.Get("X-Pkv-Expire-Time")).To(Not(BeEmpty()))
+		Expect(resp.Header.Get("Last-Modified")).To(Not(BeEmpty()))
+	})
+
+	It("should fall back to the stored size of values without metadata", func() {
+		value := []byte("value without metadata " + uuid.NewString())
+		name := "values/" + api.HashRawBytes(value)
+		Expect(RedisClient.Set(ctx, name, value, time.Minute).Err()).To(Succeed())
+
+		metadata, err := getMetadata(name)
+		Expect(err).To(BeNil())
+		Expect(metadata.GetSize()).To(Equal(int64(len(value))))
+		Expect(metadata.GetCreateTime()).To(BeNil())
+
+		_, err = getMetadata("values/" + api.HashRawBytes([]byte("missing "+uuid.NewString())))
+		Expect(err).To(Not(BeNil()))
+	})
+})
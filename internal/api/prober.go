@@ -0,0 +1,331 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Weight of the latest sample in the latency moving average
+	probeLatencyEwmaWeight = 0.2
+	// Milliseconds added to the index score of an instance failing a probe.
+	// Search results are ordered by ascending score, so this pushes the
+	// instance behind healthy ones for roughly the same duration.
+	probeDemotionPenalty = float64(time.Hour / time.Millisecond)
+)
+
+type ProbeResult struct {
+	Did     string
+	Latency time.Duration
+	Err     error
+}
+
+type InstanceHealth struct {
+	Probes              int64
+	Failures            int64
+	ConsecutiveFailures int64
+	LatencyEwma         time.Duration
+	LastProbeTime       time.Time
+}
+
+func (h *InstanceHealth) FailureRate() float64 {
+	if h.Probes == 0 {
+		return 0
+	}
+	return float64(h.Failures) / float64(h.Probes)
+}
+
+// HealthProber periodically contacts indexed storage instances to check that
+// they are alive and actually serve the CIDs they advertise.
+type HealthProber struct {
+	redisClient    *redis.Client
+	httpClient     connect.HTTPClient
+	interval       time.Duration
+	timeout        time.Duration
+	budget         int64
+	minSamples     int64
+	maxFailureRate float64
+	sessionToken   string
+
+	instances *keyScanner
+}
+
+func NewHealthProber(conf *Config, redisClient *redis.Client, httpClient connect.HTTPClient) *HealthProber {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &HealthProber{
+		redisClient:    redisClient,
+		httpClient:     httpClient,
+		interval:       conf.ProbeInterval,
+		timeout:        conf.ProbeTimeout,
+		budget:         conf.ProbeBudget,
+		minSamples:     conf.ProbeMinSamples,
+		maxFailureRate: conf.ProbeMaxFailureRate,
+		sessionToken:   conf.ProbeSessionToken,
+		instances:      newKeyScanner(redisClient, "instance:*"),
+	}
+}
+
+func (p *HealthProber) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.ProbeRound(ctx); err != nil {
+				log.Printf("probe round failed: %v", err)
+			}
+		}
+	}
+}
+
+// ProbeRound probes at most budget instances, continuing from where the
+// previous round stopped.
+func (p *HealthProber) ProbeRound(ctx context.Context) error {
+	var probed int64
+	for probed < p.budget {
+		key, ok, err := p.instances.Next(ctx, p.budget-probed)
+		if err != nil {
+			return fmt.Errorf("failed to scan instances: %v", err)
+		}
+		if !ok {
+			break
+		}
+		var ad pb.ProviderAdvertise
+		if err := p.redisClient.Get(ctx, key).Scan(&ad); err == redis.Nil {
+			continue
+		} else if err != nil {
+			log.Printf("invalid instance advertisement %s from redis: %v", key, err)
+			continue
+		}
+		if _, err := p.ProbeAndRecord(ctx, &ad); err != nil {
+			log.Printf("failed to record probe result of %s: %v", ad.GetProviderInstance().GetDid(), err)
+		}
+		probed++
+	}
+	return nil
+}
+
+func MultiaddrToUrl(addr string) (string, error) {
+	m, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		return "", err
+	}
+	host := ""
+	for _, code := range []int{
+		multiaddr.P_DNS4, multiaddr.P_DNS6, multiaddr.P_DNS, multiaddr.P_IP4, multiaddr.P_IP6,
+	} {
+		if value, err := m.ValueForProtocol(code); err == nil {
+			host = value
+			if code == multiaddr.P_IP6 {
+				host = "[" + value + "]"
+			}
+			break
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("no host found in multiaddr %s", addr)
+	}
+	port, err := m.ValueForProtocol(multiaddr.P_TCP)
+	if err != nil {
+		return "", fmt.Errorf("no tcp port found in multiaddr %s", addr)
+	}
+	scheme := ""
+	if _, err := m.ValueForProtocol(multiaddr.P_HTTPS); err == nil {
+		scheme = "https"
+	} else if _, err := m.ValueForProtocol(multiaddr.P_HTTP); err == nil {
+		scheme = "http"
+		if _, err := m.ValueForProtocol(multiaddr.P_TLS); err == nil {
+			scheme = "https"
+		}
+	} else {
+		return "", fmt.Errorf("multiaddr %s is not an http endpoint", addr)
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, host, port), nil
+}
+
+func (p *HealthProber) probeEndpoint(
+	ctx context.Context, baseUrl string, ad *pb.ProviderAdvertise,
+) error {
+	client := kvstoreconnect.NewKvStoreServiceClient(p.httpClient, baseUrl)
+	if _, err := client.Ping(ctx, connect.NewRequest(&pb.PingRequest{Dummy: 1})); err != nil {
+		return fmt.Errorf("ping failed: %v", err)
+	}
+	cids := ad.GetCids()
+	if len(cids) == 0 {
+		return nil
+	}
+	cidString := cids[rand.IntN(len(cids))]
+	expected, err := cid.Decode(cidString)
+	if err != nil {
+		return fmt.Errorf("advertised cid %s is invalid: %v", cidString, err)
+	}
+	req := connect.NewRequest(&pb.GetValueRequest{
+		Name: fmt.Sprintf("values/%s", cidString),
+	})
+	if p.sessionToken != "" {
+		req.Header().Set("Authorization", "Bearer "+p.sessionToken)
+	}
+	resp, err := client.GetValue(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to get advertised cid %s: %v", cidString, err)
+	}
+	actual, err := expected.Prefix().Sum(resp.Msg.GetValue())
+	if err != nil {
+		return fmt.Errorf("failed to hash value of %s: %v", cidString, err)
+	}
+	if !bytes.Equal(actual.Hash(), expected.Hash()) {
+		return fmt.Errorf("value served for %s hashes to %s", cidString, actual)
+	}
+	return nil
+}
+
+// ProbeInstance pings the first reachable http multiaddr of the instance and
+// spot checks one of its advertised CIDs.
+func (p *HealthProber) ProbeInstance(ctx context.Context, ad *pb.ProviderAdvertise) *ProbeResult {
+	result := &ProbeResult{
+		Did: ad.GetProviderInstance().GetDid(),
+	}
+	result.Err = fmt.Errorf("no http multiaddr advertised")
+	for _, addr := range ad.GetProviderInstance().GetMultiaddrs() {
+		baseUrl, err := MultiaddrToUrl(addr)
+		if err != nil {
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		start := time.Now()
+		result.Err = p.probeEndpoint(probeCtx, baseUrl, ad)
+		result.Latency = time.Since(start)
+		cancel()
+		if result.Err == nil {
+			break
+		}
+	}
+	return result
+}
+
+// ProbeAndRecord probes the instance and updates its health statistics,
+// demoting or evicting it from the index on failure.
+func (p *HealthProber) ProbeAndRecord(ctx context.Context, ad *pb.ProviderAdvertise) (*ProbeResult, error) {
+	result := p.ProbeInstance(ctx, ad)
+	return result, p.record(ctx, ad, result)
+}
+
+func healthKey(did string) string {
+	return fmt.Sprintf("health:instance:%s", did)
+}
+
+func (p *HealthProber) GetInstanceHealth(ctx context.Context, did string) (*InstanceHealth, error) {
+	fields, err := p.redisClient.HGetAll(ctx, healthKey(did)).Result()
+	if err != nil {
+		return nil, err
+	}
+	parse := func(name string) int64 {
+		value, _ := strconv.ParseInt(fields[name], 10, 64)
+		return value
+	}
+	return &InstanceHealth{
+		Probes:              parse("probes"),
+		Failures:            parse("failures"),
+		ConsecutiveFailures: parse("consecutive_failures"),
+		LatencyEwma:         time.Duration(parse("latency_ewma_us")) * time.Microsecond,
+		LastProbeTime:       time.UnixMilli(parse("last_probe_ms")),
+	}, nil
+}
+
+func (p *HealthProber) record(ctx context.Context, ad *pb.ProviderAdvertise, result *ProbeResult) error {
+	health, err := p.GetInstanceHealth(ctx, result.Did)
+	if err != nil {
+		return err
+	}
+	health.Probes++
+	health.LastProbeTime = time.Now()
+	if result.Err != nil {
+		log.Printf("probe of instance %s failed: %v", result.Did, result.Err)
+		health.Failures++
+		health.ConsecutiveFailures++
+	} else {
+		health.ConsecutiveFailures = 0
+		if health.LatencyEwma == 0 {
+			health.LatencyEwma = result.Latency
+		} else {
+			health.LatencyEwma = time.Duration(
+				probeLatencyEwmaWeight*float64(result.Latency) +
+					(1-probeLatencyEwmaWeight)*float64(health.LatencyEwma),
+			)
+		}
+	}
+	key := healthKey(result.Did)
+	if err := p.redisClient.HSet(ctx, key, map[string]interface{}{
+		"probes":               health.Probes,
+		"failures":             health.Failures,
+		"consecutive_failures": health.ConsecutiveFailures,
+		"latency_ewma_us":      health.LatencyEwma.Microseconds(),
+		"last_probe_ms":        health.LastProbeTime.UnixMilli(),
+	}).Err(); err != nil {
+		return err
+	}
+	if err := p.redisClient.Expire(ctx, key, DEFAULT_TTL).Err(); err != nil {
+		return err
+	}
+	if result.Err == nil {
+		return nil
+	}
+	if health.Probes >= p.minSamples && health.FailureRate() > p.maxFailureRate {
+		return p.evict(ctx, ad)
+	}
+	return p.demote(ctx, ad)
+}
+
+func (p *HealthProber) demote(ctx context.Context, ad *pb.ProviderAdvertise) error {
+	vHash, err := HashMessage(ad.GetVirtualService())
+	if err != nil {
+		return err
+	}
+	scoreKey := fmt.Sprintf("vsvc:instance:%s", vHash)
+	// XX so that a demotion never re-adds an instance removed meanwhile
+	err = p.redisClient.ZAddArgsIncr(ctx, scoreKey, redis.ZAddArgs{
+		XX: true,
+		Members: []redis.Z{{
+			Score:  probeDemotionPenalty,
+			Member: ad.GetProviderInstance().GetDid(),
+		}},
+	}).Err()
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
+
+func (p *HealthProber) evict(ctx context.Context, ad *pb.ProviderAdvertise) error {
+	did := ad.GetProviderInstance().GetDid()
+	log.Printf("evicting unhealthy instance %s", did)
+	vHash, err := HashMessage(ad.GetVirtualService())
+	if err != nil {
+		return err
+	}
+	scoreKey := fmt.Sprintf("vsvc:instance:%s", vHash)
+	if err := p.redisClient.ZRem(ctx, scoreKey, did).Err(); err != nil {
+		return err
+	}
+	if err := p.redisClient.Del(ctx, fmt.Sprintf("instance:%s", did), healthKey(did)).Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeProvider serves a fixed set of values, optionally corrupting them.
+type fakeProvider struct {
+	kvstoreconnect.UnimplementedKvStoreServiceHandler
+	values  map[string][]byte
+	corrupt bool
+	pings   atomic.Int64
+}
+
+func (f *fakeProvider) Ping(
+	ctx context.Context, req *connect.Request[pb.PingRequest],
+) (*connect.Response[pb.PingResponse], error) {
+	f.pings.Add(1)
+	return connect.NewResponse(&pb.PingResponse{Pong: "pong"}), nil
+}
+
+func (f *fakeProvider) GetValue(
+	ctx context.Context, req *connect.Request[pb.GetValueRequest],
+) (*connect.Response[pb.GetValueResponse], error) {
+	value, ok := f.values[req.Msg.GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+	if f.corrupt {
+		value = append([]byte("tampered "), value...)
+	}
+	return connect.NewResponse(&pb.GetValueResponse{Value: value}), nil
+}
+
+func startFakeProvider(provider *fakeProvider) (*httptest.Server, string) {
+	mux := http.NewServeMux()
+	mux.Handle(kvstoreconnect.NewKvStoreServiceHandler(provider))
+	server := httptest.NewServer(mux)
+	u, err := url.Parse(server.URL)
+	Expect(err).To(BeNil())
+	return server, fmt.Sprintf("/ip4/%s/tcp/%s/http", u.Hostname(), u.Port())
+}
+
+func newProbedAd(multiaddr string, cids []string) *pb.ProviderAdvertise {
+	root, err := api.CalculateMerkleRoot(cids)
+	Expect(err).To(BeNil())
+	return &pb.ProviderAdvertise{
+		ProviderInstance: &pb.Instance{
+			Did:        "did:example:" + uuid.NewString(),
+			Multiaddrs: []string{multiaddr},
+		},
+		VirtualService: &pb.VirtualService{
+			BehaviorLink: api.ServeAllBehavior,
+			VariantLink: &pb.GlobalLink{
+				Name:       root,
+				Maintainer: "did:example:proposer",
+				Version:    "v0.1.0",
+			},
+		},
+		Cids:       cids,
+		ExpireTime: timestamppb.New(time.Now().AddDate(0, 0, 7)),
+	}
+}
+
+var _ = Describe("Probe health of storage instances", Label("prober"), func() {
+	ctx := context.Background()
+	value := []byte("probed value")
+	cid := api.HashRawBytes(value)
+	values := map[string][]byte{"values/" + cid: value}
+
+	var prober *api.HealthProber
+	BeforeEach(func() {
+		conf := *Conf
+		conf.ProbeTimeout = 5 * time.Second
+		conf.ProbeMinSamples = 2
+		conf.ProbeMaxFailureRate = 0.5
+		prober = api.NewHealthProber(&conf, RedisClient, nil)
+	})
+
+	getScore := func(ad *pb.ProviderAdvertise) (float64, error) {
+		vHash, err := api.HashMessage(ad.GetVirtualService())
+		Expect(err).To(BeNil())
+		return RedisClient.ZScore(
+			ctx, "vsvc:instance:"+vHash, ad.GetProviderInstance().GetDid(),
+		).Result()
+	}
+
+	When("the provider serves the advertised cids", func() {
+		It("should record a successful probe", func() {
+			server, multiaddr := startFakeProvider(&fakeProvider{values: values})
+			defer server.Close()
+			ad := newProbedAd(multiaddr, []string{cid})
+			Expect(api.NewServeAllFileServing(RedisClient).Register(ctx, ad)).To(Succeed())
+
+			result, err := prober.ProbeAndRecord(ctx, ad)
+			Expect(err).To(BeNil())
+			Expect(result.Err).To(BeNil())
+			health, err := prober.GetInstanceHealth(ctx, ad.GetProviderInstance().GetDid())
+			Expect(err).To(BeNil())
+			Expect(health.Probes).To(Equal(int64(1)))
+			Expect(health.Failures).To(Equal(int64(0)))
+			Expect(health.LatencyEwma).To(BeNumerically(">", 0))
+		})
+	})
+
+	When("the provider serves corrupted values", func() {
+		It("should demote and then evict it", func() {
+			server, multiaddr := startFakeProvider(&fakeProvider{values: values, corrupt: true})
+			defer server.Close()
+			ad := newProbedAd(multiaddr, []string{cid})
+			Expect(api.NewServeAllFileServing(RedisClient).Register(ctx, ad)).To(Succeed())
+			scoreBefore, err := getScore(ad)
+			Expect(err).To(BeNil())
+
+			result, err := prober.ProbeAndRecord(ctx, ad)
+			Expect(err).To(BeNil())
+			Expect(result.Err).To(Not(BeNil()))
+			scoreAfter, err := getScore(ad)
+			Expect(err).To(BeNil())
+			Expect(scoreAfter).To(BeNumerically(">", scoreBefore))
+
+			_, err = prober.ProbeAndRecord(ctx, ad)
+			Expect(err).To(BeNil())
+			_, err = getScore(ad)
+			Expect(err).To(Not(BeNil()))
+			exists, err := RedisClient.Exists(ctx, "instance:"+ad.GetProviderInstance().GetDid()).Result()
+			Expect(err).To(BeNil())
+			Expect(exists).To(Equal(int64(0)))
+		})
+	})
+
+	When("the provider is unreachable", func() {
+		It("should record a failure", func() {
+			server, multiaddr := startFakeProvider(&fakeProvider{values: values})
+			server.Close()
+			ad := newProbedAd(multiaddr, []string{cid})
+			Expect(api.NewServeAllFileServing(RedisClient).Register(ctx, ad)).To(Succeed())
+
+			result, err := prober.ProbeAndRecord(ctx, ad)
+			Expect(err).To(BeNil())
+			Expect(result.Err).To(Not(BeNil()))
+			health, err := prober.GetInstanceHealth(ctx, ad.GetProviderInstance().GetDid())
+			Expect(err).To(BeNil())
+			Expect(health.Failures).To(Equal(int64(1)))
+		})
+	})
+	When("there are more instances than the budget of a round", func() {
+		It("should stop at the budget and carry the rest over", func() {
+			// A database of its own so that only these instances are scanned
+			options := *RedisClient.Options()
+			options.DB = 1
+			rdb := redis.NewClient(&options)
+			DeferCleanup(rdb.Close)
+			Expect(rdb.FlushDB(ctx).Err()).To(Succeed())
+			DeferCleanup(func() { rdb.FlushDB(ctx) })
+
+			provider := &fakeProvider{values: values}
+			server, multiaddr := startFakeProvider(provider)
+			defer server.Close()
+			dids := make([]string, 0, 3)
+			for range 3 {
+				ad := newProbedAd(multiaddr, []string{cid})
+				Expect(api.NewServeAllFileServing(rdb).Register(ctx, ad)).To(Succeed())
+				dids = append(dids, ad.GetProviderInstance().GetDid())
+			}
+			conf := *Conf
+			conf.ProbeTimeout = 5 * time.Second
+			conf.ProbeBudget = 2
+			prober := api.NewHealthProber(&conf, rdb, nil)
+
+			Expect(prober.ProbeRound(ctx)).To(Succeed())
+			Expect(provider.pings.Load()).To(Equal(int64(2)))
+			// The next round probes the one left and ends with the pass
+			Expect(prober.ProbeRound(ctx)).To(Succeed())
+			Expect(provider.pings.Load()).To(Equal(int64(3)))
+			for _, did := range dids {
+				health, err := prober.GetInstanceHealth(ctx, did)
+				Expect(err).To(BeNil())
+				Expect(health.Probes).To(Equal(int64(1)))
+			}
+
+			Expect(prober.ProbeRound(ctx)).To(Succeed())
+			Expect(provider.pings.Load()).To(Equal(int64(5)))
+		})
+	})
+})
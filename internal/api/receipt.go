@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/receipt"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// receiptSigningKey returns the libp2p identity key of the node as an
+// ed25519 key, so that receipts are attributable to its advertised peer id.
+func receiptSigningKey(key crypto.PrivKey) (ed25519.PrivateKey, error) {
+	if key == nil || key.Type() != crypto.Ed25519 {
+		return nil, fmt.Errorf("libp2p private key is not ed25519")
+	}
+	raw, err := key.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw libp2p private key: %v", err)
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("unexpected ed25519 private key size %d", len(raw))
+	}
+	return ed25519.PrivateKey(raw), nil
+}
+
+// issueReceipt signs the promise to keep the value at key stored until
+// expireTime, for the price charged so far for the call of ctx. That is
+// nothing when auth is disabled.
+func (s *Server) issueReceipt(
+	ctx context.Context,
+	key string,
+	size int64,
+	expireTime time.Time,
+) (*pb.SignedStorageReceipt, error) {
+	signed, err := receipt.Sign(&pb.StorageReceipt{
+		Cid:        strings.TrimPrefix(key, "values/"),
+		Size:       size,
+		ExpireTime: timestamppb.New(expireTime),
+		Price:      middleware.ChargedPrice(ctx),
+		NodeDid:    s.config.SelfIdentifier,
+		IssueTime:  timestamppb.Now(),
+	}, s.receiptKey)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign receipt: %v", err)
+	}
+	return signed, nil
+}
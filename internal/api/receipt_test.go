@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/atticplaygroup/pkv/pkg/receipt"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Storage receipts", Label("receipt"), func() {
+	ctx := context.Background()
+
+	var token string
+	var sessionId string
+	var signerDid string
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+
+		peerId, err := peer.IDFromPrivateKey(Conf.Libp2pPrivateKey)
+		Expect(err).To(BeNil())
+		signerDid, err = api.ProviderDid(peerId)
+		Expect(err).To(BeNil())
+	})
+
+	It("should sign the price charged and expiry when creating and prolonging values", func() {
+		value := []byte("receipt value " + uuid.NewString())
+		before := sessionBalance(ctx, sessionId)
+		createReq := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Hour),
+		})
+		createReq.Header().Set("Authorization", "Bearer "+token)
+		created, err := testClient.CreateValue(ctx, createReq)
+		Expect(err).To(BeNil())
+
+		createReceipt, err := receipt.VerifyFrom(created.Msg.GetReceipt(), signerDid)
+		Expect(err).To(BeNil())
+		Expect("values/" + createReceipt.GetCid()).To(Equal(created.Msg.GetName()))
+		Expect(createReceipt.GetSize()).To(Equal(int64(len(value))))
+		Expect(createReceipt.GetNodeDid()).To(Equal(Conf.SelfIdentifier))
+		Expect(createReceipt.GetExpireTime().AsTime()).To(BeTemporally("~", time.Now().Add(time.Hour), 5*time.Second))
+		Expect(createReceipt.GetPrice()).To(Equal(before - sessionBalance(ctx, sessionId)))
+
+		before = sessionBalance(ctx, sessionId)
+		prolongReq := connect.NewRequest(&pb.ProlongValueRequest{
+			Name:    created.Msg.GetName(),
+			Ttl:     durationpb.New(time.Hour),
+			MaxSize: 1 << 10,
+		})
+		prolongReq.Header().Set("Authorization", "Bearer "+token)
+		prolonged, err := testClient.ProlongValue(ctx, prolongReq)
+		Expect(err).To(BeNil())
+
+		prolongReceipt, err := receipt.VerifyFrom(prolonged.Msg.GetReceipt(), signerDid)
+		Expect(err).To(BeNil())
+		Expect(prolongReceipt.GetSize()).To(Equal(int64(len(value))))
+		Expect(prolongReceipt.GetExpireTime().AsTime()).To(BeTemporally("~", time.Now().Add(2*time.Hour), 5*time.Second))
+		Expect(prolongReceipt.GetPrice()).To(Equal(before - sessionBalance(ctx, sessionId)))
+	})
+	It("should sign the stored size charged under the stored size basis", func() {
+		conf := *Conf
+		conf.CompressionAlgorithm = api.VALUE_ENCODING_ZSTD
+		conf.CompressionMinSize = 64
+		conf.CompressionMaxEntropy = 7.5
+		conf.PricingSizeBasis = middleware.PRICING_SIZE_BASIS_STORED
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		path, handler, err := api.NewKvStoreHandler(server)
+		Expect(err).To(BeNil())
+		mux := http.NewServeMux()
+		mux.Handle(path, handler)
+		httpServer := httptest.NewServer(mux)
+		DeferCleanup(httpServer.Close)
+		storedClient := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, httpServer.URL)
+
+		value := []byte(strings.Repeat("compressible receipt value "+uuid.NewString()+"\n", 50))
+		before := sessionBalance(ctx, sessionId)
+		createReq := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: value,
+			Ttl:   durationpb.New(time.Hour),
+		})
+		createReq.Header().Set("Authorization", "Bearer "+token)
+		created, err := storedClient.CreateValue(ctx, createReq)
+		Expect(err).To(BeNil())
+		Expect(created.Msg.GetStoredSize()).To(BeNumerically("<", len(value)))
+
+		createReceipt, err := receipt.VerifyFrom(created.Msg.GetReceipt(), signerDid)
+		Expect(err).To(BeNil())
+		Expect(createReceipt.GetSize()).To(Equal(int64(len(value))))
+		// One unit from the interceptor and the stored size from the handler
+		Expect(createReceipt.GetPrice()).To(Equal(1 + created.Msg.GetStoredSize()))
+		Expect(createReceipt.GetPrice()).To(Equal(before - sessionBalance(ctx, sessionId)))
+	})
+})
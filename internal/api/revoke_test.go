@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("Session revocation", Label("revoke"), func() {
+	ctx := context.Background()
+	sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+	nodeAccounts := []string{api.Ed25519DidKey(Conf.ExchangeAccountPrivateKey.Public().(ed25519.PublicKey))}
+
+	var token string
+	var sessionId string
+	BeforeEach(func() {
+		token, sessionId = newSession(ctx)
+	})
+
+	ping := func() error {
+		req := connect.NewRequest(&pb.CreateValueRequest{
+			Codec: pb.CreateValueRequest_CODEC_RAW,
+			Value: []byte("revoke value " + uuid.NewString()),
+			Ttl:   durationpb.New(time.Minute),
+		})
+		req.Header().Set("Authorization", "Bearer "+token)
+		_, err := testClient.CreateValue(ctx, req)
+		return err
+	}
+
+	It("should refund the balance left and refuse the token afterwards", func() {
+		Expect(ping()).To(Succeed())
+		balance := sessionBalance(ctx, sessionId)
+
+		req := connect.NewRequest(&pb.RevokeSessionRequest{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.RevokeSession(ctx, req)
+		Expect(err).To(BeNil())
+		refund := resp.Msg.GetRefund()
+		Expect(refund.GetSessionId()).To(Equal(sessionId))
+		// Revoking is free so that drained sessions can be revoked too
+		Expect(refund.GetBalance()).To(Equal(balance))
+		Expect(refund.GetIssuer()).To(Equal("did:key:z6MktULudTtAsAhRegYPiZ6631RV3viv12qd4GQF8z1xB22S"))
+		claims, err := middleware.VerifyRefundVoucher(resp.Msg.GetVoucher(), refund.GetIssuer(), nodeAccounts, 0)
+		Expect(err).To(BeNil())
+		Expect(claims.ID).To(Equal(refund.GetJti()))
+		Expect(claims.Quantity).To(Equal(refund.GetBalance()))
+
+		Expect(ping()).To(Not(Succeed()))
+		_, err = testClient.RevokeSession(ctx, req)
+		Expect(err).To(Not(BeNil()))
+
+		recorded, err := sessionManager.GetSessionRefund(ctx, sessionId)
+		Expect(err).To(BeNil())
+		Expect(recorded.GetBalance()).To(Equal(refund.GetBalance()))
+		Expect(recorded.GetJti()).To(Equal(refund.GetJti()))
+	})
+
+	It("should revoke sessions without balance left", func() {
+		_, err := sessionManager.DeductSessionBalance(ctx, sessionId, sessionBalance(ctx, sessionId))
+		Expect(err).To(BeNil())
+
+		req := connect.NewRequest(&pb.RevokeSessionRequest{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		resp, err := testClient.RevokeSession(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(resp.Msg.GetRefund().GetBalance()).To(Equal(int64(0)))
+		Expect(resp.Msg.GetVoucher()).To(BeEmpty())
+		Expect(ping()).To(Not(Succeed()))
+	})
+
+	It("should let the operator revoke sessions with the admin token", func() {
+		conf := *Conf
+		conf.AdminToken = "admin-" + uuid.NewString()
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		handler := server.AdminHandler()
+		revoke := func(adminToken string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/admin/v1/sessions/"+sessionId+"/revoke", nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		Expect(revoke("wrong").Code).To(Equal(http.StatusForbidden))
+		Expect(ping()).To(Succeed())
+
+		recorder := revoke(conf.AdminToken)
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		refund := &pb.SessionRefund{}
+		Expect(protojson.Unmarshal(recorder.Body.Bytes(), refund)).To(Succeed())
+		Expect(refund.GetSessionId()).To(Equal(sessionId))
+		Expect(ping()).To(Not(Succeed()))
+
+		Expect(revoke(conf.AdminToken).Code).To(Equal(http.StatusNotFound))
+
+		// The holder settles the refund by closing the revoked session
+		req := connect.NewRequest(&pb.CloseSessionRequest{})
+		req.Header().Set("Authorization", "Bearer "+token)
+		closed, err := testClient.CloseSession(ctx, req)
+		Expect(err).To(BeNil())
+		Expect(closed.Msg.GetRefund().GetBalance()).To(Equal(refund.GetBalance()))
+		claims, err := middleware.VerifyRefundVoucher(closed.Msg.GetVoucher(), refund.GetIssuer(), nodeAccounts, 0)
+		Expect(err).To(BeNil())
+		Expect(claims.Quantity).To(Equal(refund.GetBalance()))
+	})
+
+	It("should keep operator endpoints disabled without an admin token", func() {
+		conf := *Conf
+		conf.AdminToken = ""
+		server, err := api.NewServer(&conf)
+		Expect(err).To(BeNil())
+		req := httptest.NewRequest(http.MethodPost, "/admin/v1/sessions/"+sessionId+"/revoke", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		recorder := httptest.NewRecorder()
+		server.AdminHandler().ServeHTTP(recorder, req)
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		Expect(ping()).To(Succeed())
+	})
+})
@@ -0,0 +1,434 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/ipfs/boxo/ipns"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	ROUTING_SCHEMA_PEER = "peer"
+	// Protocol of the Connect KvStoreService, assumed for instances not
+	// listing their protocols
+	PKV_CONNECT_PROTOCOL = "transport-pkv-connect"
+
+	mediaTypeJson       = "application/json"
+	mediaTypeNdjson     = "application/x-ndjson"
+	mediaTypeIpnsRecord = "application/vnd.ipfs.ipns-record"
+
+	// Cache durations recommended by the delegated routing spec
+	routingFoundMaxAge    = 5 * time.Minute
+	routingNotFoundMaxAge = 15 * time.Second
+)
+
+// InstancePeerId returns the libp2p peer id of the instance, derived from its
+// did if it is an ed25519 did:key and no peer id is given.
+func InstancePeerId(instance *pb.Instance) (peer.ID, error) {
+	if instance.GetPeerId() != "" {
+		return peer.Decode(instance.GetPeerId())
+	}
+	rawPublicKey, err := ParseEd25519DidKey(instance.GetDid())
+	if err != nil {
+		return "", fmt.Errorf("no peer id and did is not derivable: %v", err)
+	}
+	publicKey, err := crypto.UnmarshalEd25519PublicKey(rawPublicKey)
+	if err != nil {
+		return "", err
+	}
+	return peer.IDFromPublicKey(publicKey)
+}
+
+// RoutingFilter implements the filter-addrs and filter-protocols query
+// parameters of IPIP-484.
+type RoutingFilter struct {
+	Addrs     []string
+	Protocols []string
+}
+
+func parseFilterList(value string) []string {
+	ret := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+func NewRoutingFilter(filterAddrs string, filterProtocols string) *RoutingFilter {
+	return &RoutingFilter{
+		Addrs:     parseFilterList(filterAddrs),
+		Protocols: parseFilterList(filterProtocols),
+	}
+}
+
+func multiaddrHasProtocol(m multiaddr.Multiaddr, name string) bool {
+	for _, p := range m.Protocols() {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *RoutingFilter) keepAddr(addr string) bool {
+	m, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		return false
+	}
+	hasPositive := false
+	matchesPositive := false
+	for _, filter := range f.Addrs {
+		if negated, found := strings.CutPrefix(filter, "!"); found {
+			if multiaddrHasProtocol(m, negated) {
+				return false
+			}
+			continue
+		}
+		if filter == "unknown" {
+			continue
+		}
+		hasPositive = true
+		if multiaddrHasProtocol(m, filter) {
+			matchesPositive = true
+		}
+	}
+	return !hasPositive || matchesPositive
+}
+
+// Apply filters the record in place and reports whether it should be kept.
+func (f *RoutingFilter) Apply(record *pb.PeerRecord) bool {
+	if len(f.Protocols) > 0 {
+		if len(record.GetProtocols()) == 0 {
+			if !slices.Contains(f.Protocols, "unknown") {
+				return false
+			}
+		} else if !slices.ContainsFunc(record.GetProtocols(), func(p string) bool {
+			return slices.Contains(f.Protocols, p)
+		}) {
+			return false
+		}
+	}
+	if len(f.Addrs) == 0 {
+		return true
+	}
+	if len(record.GetAddrs()) == 0 {
+		return slices.Contains(f.Addrs, "unknown")
+	}
+	addrs := make([]string, 0, len(record.GetAddrs()))
+	for _, addr := range record.GetAddrs() {
+		if f.keepAddr(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	record.Addrs = addrs
+	return len(addrs) > 0
+}
+
+func NewPeerRecord(instance *pb.Instance) (*pb.PeerRecord, error) {
+	peerId, err := InstancePeerId(instance)
+	if err != nil {
+		return nil, err
+	}
+	protocols := instance.GetProtocols()
+	if len(protocols) == 0 {
+		protocols = []string{PKV_CONNECT_PROTOCOL}
+	}
+	return &pb.PeerRecord{
+		Schema:    ROUTING_SCHEMA_PEER,
+		Id:        peerId.String(),
+		Addrs:     slices.Clone(instance.GetMultiaddrs()),
+		Protocols: slices.Clone(protocols),
+	}, nil
+}
+
+func (s *Server) findProviders(
+	ctx context.Context, cidString string, filter *RoutingFilter,
+) ([]*pb.PeerRecord, error) {
+	searchCidResponse, err := s.doSearchCid(ctx, connect.NewRequest(&pb.SearchCidRequest{
+		Cid: cidString,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*pb.PeerRecord, 0, len(searchCidResponse.Msg.GetStorageInstances()))
+	seen := make(map[string]bool)
+	for _, ad := range searchCidResponse.Msg.GetStorageInstances() {
+		record, err := NewPeerRecord(ad.GetProviderInstance())
+		if err != nil {
+			log.Printf("skipping instance %s without peer id: %v", ad.GetProviderInstance().GetDid(), err)
+			continue
+		}
+		if seen[record.GetId()] || !filter.Apply(record) {
+			continue
+		}
+		seen[record.GetId()] = true
+		ret = append(ret, record)
+	}
+	return ret, nil
+}
+
+func (s *Server) DelegatedRouting(
+	ctx context.Context,
+	connectReq *connect.Request[pb.DelegatedRoutingRequest],
+) (*connect.Response[pb.DelegatedRoutingResponse], error) {
+	providers, err := s.findProviders(ctx, connectReq.Msg.GetCid(), &RoutingFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(
+		&pb.DelegatedRoutingResponse{
+			Providers: providers,
+		},
+	), nil
+}
+
+func peerIndexKey(peerId peer.ID) string {
+	return fmt.Sprintf("peer:instance:%s", peerId)
+}
+
+func (s *Server) findPeer(
+	ctx context.Context, peerId peer.ID, filter *RoutingFilter,
+) ([]*pb.PeerRecord, error) {
+	did, err := s.redisClient.Get(ctx, peerIndexKey(peerId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get peer: %v", err)
+	}
+	var ad pb.ProviderAdvertise
+	if err := s.redisClient.Get(ctx, fmt.Sprintf("instance:%s", did)).Scan(&ad); err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get instance: %v", err)
+	}
+	record, err := NewPeerRecord(ad.GetProviderInstance())
+	if err != nil || record.GetId() != peerId.String() || !filter.Apply(record) {
+		return nil, nil
+	}
+	return []*pb.PeerRecord{record}, nil
+}
+
+// RoutingHandler serves the IPFS Delegated Routing V1 HTTP API under
+// /routing/v1/ so that Kubo and Helia can use the index as a delegated router.
+// Publishing IPNS records takes a session bearer token to charge.
+func (s *Server) RoutingHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /routing/v1/providers/{cid}", s.handleFindProviders)
+	mux.HandleFunc("GET /routing/v1/peers/{peerId}", s.handleFindPeers)
+	mux.HandleFunc("GET /routing/v1/ipns/{name}", s.handleGetIpns)
+	mux.HandleFunc("PUT /routing/v1/ipns/{name}", s.handlePutIpns)
+	return mux
+}
+
+func writeRoutingError(w http.ResponseWriter, err error) {
+	httpStatus := runtime.HTTPStatusFromCode(status.Code(err))
+	if httpStatus == http.StatusNotFound {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(routingNotFoundMaxAge.Seconds())))
+	}
+	http.Error(w, status.Convert(err).Message(), httpStatus)
+}
+
+func acceptsNdjson(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, mediaTypeNdjson) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeRecords responds with {"<field>": [...]} or one record per line when
+// the client accepts NDJSON.
+func writeRecords(w http.ResponseWriter, r *http.Request, field string, records []*pb.PeerRecord) {
+	w.Header().Add("Vary", "Accept")
+	if len(records) == 0 {
+		writeRoutingError(w, status.Error(codes.NotFound, "no records found"))
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(routingFoundMaxAge.Seconds())))
+	if acceptsNdjson(r) {
+		w.Header().Set("Content-Type", mediaTypeNdjson)
+		flusher, _ := w.(http.Flusher)
+		for _, record := range records {
+			line, err := protojson.Marshal(record)
+			if err != nil {
+				log.Printf("failed to marshal peer record: %v", err)
+				return
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return
+	}
+	encoded := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		line, err := protojson.Marshal(record)
+		if err != nil {
+			writeRoutingError(w, status.Errorf(codes.Internal, "failed to marshal peer record: %v", err))
+			return
+		}
+		encoded = append(encoded, line)
+	}
+	w.Header().Set("Content-Type", mediaTypeJson)
+	if err := json.NewEncoder(w).Encode(map[string][]json.RawMessage{field: encoded}); err != nil {
+		log.Printf("failed to write routing response: %v", err)
+	}
+}
+
+func routingFilterFromQuery(r *http.Request) *RoutingFilter {
+	query := r.URL.Query()
+	return NewRoutingFilter(query.Get("filter-addrs"), query.Get("filter-protocols"))
+}
+
+func (s *Server) handleFindProviders(w http.ResponseWriter, r *http.Request) {
+	records, err := s.findProviders(r.Context(), r.PathValue("cid"), routingFilterFromQuery(r))
+	if err != nil && status.Code(err) != codes.NotFound {
+		writeRoutingError(w, err)
+		return
+	}
+	writeRecords(w, r, "Providers", records)
+}
+
+func (s *Server) handleFindPeers(w http.ResponseWriter, r *http.Request) {
+	peerId, err := peer.Decode(r.PathValue("peerId"))
+	if err != nil {
+		writeRoutingError(w, status.Errorf(codes.InvalidArgument, "invalid peer id: %v", err))
+		return
+	}
+	records, err := s.findPeer(r.Context(), peerId, routingFilterFromQuery(r))
+	if err != nil {
+		writeRoutingError(w, err)
+		return
+	}
+	writeRecords(w, r, "Peers", records)
+}
+
+func ipnsRecordKey(name ipns.Name) string {
+	return fmt.Sprintf("ipns:%s", name)
+}
+
+func (s *Server) handleGetIpns(w http.ResponseWriter, r *http.Request) {
+	name, err := ipns.NameFromString(r.PathValue("name"))
+	if err != nil {
+		writeRoutingError(w, status.Errorf(codes.InvalidArgument, "invalid ipns name: %v", err))
+		return
+	}
+	raw, err := s.redisClient.Get(r.Context(), ipnsRecordKey(name)).Bytes()
+	if err == redis.Nil {
+		writeRoutingError(w, status.Error(codes.NotFound, "ipns record not found"))
+		return
+	} else if err != nil {
+		writeRoutingError(w, status.Errorf(codes.Internal, "failed to get ipns record: %v", err))
+		return
+	}
+	record, err := ipns.UnmarshalRecord(raw)
+	if err != nil {
+		writeRoutingError(w, status.Errorf(codes.Internal, "invalid ipns record stored: %v", err))
+		return
+	}
+	maxAge := routingFoundMaxAge
+	if ttl, err := record.TTL(); err == nil && ttl > 0 {
+		maxAge = ttl
+	}
+	if eol, err := record.Validity(); err == nil && time.Until(eol) < maxAge {
+		maxAge = max(time.Until(eol), 0)
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("Content-Type", mediaTypeIpnsRecord)
+	w.Header().Add("Vary", "Accept")
+	if _, err := w.Write(raw); err != nil {
+		log.Printf("failed to write ipns record: %v", err)
+	}
+}
+
+// PutIpnsRecord stores the record if it is valid for name and supersedes the
+// stored one, according to the sequence number and validity. Records are
+// kept until their end of life, which the session of ctx is charged for.
+func (s *Server) PutIpnsRecord(ctx context.Context, name ipns.Name, raw []byte) error {
+	record, err := ipns.UnmarshalRecord(raw)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid ipns record: %v", err)
+	}
+	if err := ipns.ValidateWithName(record, name); err != nil {
+		return status.Errorf(codes.InvalidArgument, "ipns record not valid: %v", err)
+	}
+	eol, err := record.Validity()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid ipns record validity: %v", err)
+	}
+	key := ipnsRecordKey(name)
+	existing, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil && err != redis.Nil {
+		return status.Errorf(codes.Internal, "failed to get ipns record: %v", err)
+	}
+	if err == nil && !bytes.Equal(existing, raw) {
+		selected, err := (ipns.Validator{}).Select(string(name.RoutingKey()), [][]byte{existing, raw})
+		if err == nil && selected == 0 {
+			return status.Error(codes.FailedPrecondition, "a newer ipns record is already stored")
+		}
+	}
+	ttl := time.Until(eol)
+	price, err := s.pricingManager.GetStoragePrice(int64(len(raw)), ttl)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to get price: %v", err)
+	}
+	if err := s.chargeSession(ctx, price); err != nil {
+		return err
+	}
+	if err := s.redisClient.Set(ctx, key, raw, ttl).Err(); err != nil {
+		return status.Errorf(codes.Internal, "failed to store ipns record: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) handlePutIpns(w http.ResponseWriter, r *http.Request) {
+	name, err := ipns.NameFromString(r.PathValue("name"))
+	if err != nil {
+		writeRoutingError(w, status.Errorf(codes.InvalidArgument, "invalid ipns name: %v", err))
+		return
+	}
+	ctx, err := s.authenticateHttpSession(r)
+	if err != nil {
+		writeRoutingError(w, err)
+		return
+	}
+	raw, err := io.ReadAll(io.LimitReader(r.Body, int64(ipns.MaxRecordSize)+1))
+	if err != nil {
+		writeRoutingError(w, status.Errorf(codes.InvalidArgument, "failed to read body: %v", err))
+		return
+	}
+	if len(raw) > ipns.MaxRecordSize {
+		writeRoutingError(w, status.Error(codes.InvalidArgument, "ipns record too large"))
+		return
+	}
+	if err := s.PutIpnsRecord(ctx, name, raw); err != nil {
+		writeRoutingError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
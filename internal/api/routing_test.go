@@ -0,0 +1,235 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/atticplaygroup/pkv/internal/api"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/path"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/mr-tron/base58"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const routingBaseUrl = "http://127.0.0.1:50051/routing/v1"
+
+type routingPeerRecord struct {
+	Schema    string
+	ID        string
+	Addrs     []string
+	Protocols []string
+}
+
+func routingGet(url string, accept string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	Expect(err).To(BeNil())
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).To(BeNil())
+	return resp
+}
+
+func routingPutIpns(name ipns.Name, record []byte, sessionToken string) *http.Response {
+	req, err := http.NewRequest(
+		http.MethodPut, routingBaseUrl+"/ipns/"+name.String(), bytes.NewReader(record),
+	)
+	Expect(err).To(BeNil())
+	req.Header.Set("Content-Type", "application/vnd.ipfs.ipns-record")
+	if sessionToken != "" {
+		req.Header.Set("Authorization", "Bearer "+sessionToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).To(BeNil())
+	return resp
+}
+
+func newIpnsRecord(sk crypto.PrivKey, seq uint64) []byte {
+	value, err := path.NewPath("/ipfs/" + api.HashRawBytes([]byte("ipns value")))
+	Expect(err).To(BeNil())
+	record, err := ipns.NewRecord(sk, value, seq, time.Now().Add(time.Hour), time.Minute)
+	Expect(err).To(BeNil())
+	raw, err := ipns.MarshalRecord(record)
+	Expect(err).To(BeNil())
+	return raw
+}
+
+var _ = Describe("Delegated routing over http", Label("routing"), func() {
+	ctx := context.Background()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).To(BeNil())
+	did := "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+	libp2pPublicKey, err := crypto.UnmarshalEd25519PublicKey(publicKey)
+	Expect(err).To(BeNil())
+	peerId, err := peer.IDFromPublicKey(libp2pPublicKey)
+	Expect(err).To(BeNil())
+
+	cid := api.HashRawBytes([]byte("routed " + did))
+	ad := newProbedAd("/dns4/pkv.example.com/tcp/443/https", []string{cid})
+	// The peer id is derived from the did:key when absent
+	ad.ProviderInstance.Did = did
+	ad.ProviderInstance.Multiaddrs = append(ad.ProviderInstance.Multiaddrs, "/ip4/1.2.3.4/udp/4001/quic-v1")
+
+	BeforeEach(func() {
+		Expect(api.NewServeAllFileServing(RedisClient).Register(ctx, ad)).To(Succeed())
+	})
+
+	When("finding providers", func() {
+		It("should return peer schema records in json", func() {
+			resp := routingGet(routingBaseUrl+"/providers/"+cid, "application/json")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+			var body struct{ Providers []routingPeerRecord }
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Providers).To(Equal([]routingPeerRecord{{
+				Schema:    api.ROUTING_SCHEMA_PEER,
+				ID:        peerId.String(),
+				Addrs:     ad.GetProviderInstance().GetMultiaddrs(),
+				Protocols: []string{api.PKV_CONNECT_PROTOCOL},
+			}}))
+		})
+
+		It("should stream records in ndjson", func() {
+			resp := routingGet(routingBaseUrl+"/providers/"+cid, "application/x-ndjson")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+			scanner := bufio.NewScanner(resp.Body)
+			records := make([]routingPeerRecord, 0)
+			for scanner.Scan() {
+				var record routingPeerRecord
+				Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+				records = append(records, record)
+			}
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].ID).To(Equal(peerId.String()))
+		})
+
+		It("should apply address and protocol filters", func() {
+			resp := routingGet(routingBaseUrl+"/providers/"+cid+"?filter-addrs=https,!quic-v1", "application/json")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var body struct{ Providers []routingPeerRecord }
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Providers).To(HaveLen(1))
+			Expect(body.Providers[0].Addrs).To(Equal([]string{"/dns4/pkv.example.com/tcp/443/https"}))
+
+			resp = routingGet(routingBaseUrl+"/providers/"+cid+"?filter-protocols=transport-bitswap", "application/json")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("should return not found for unknown cids", func() {
+			resp := routingGet(routingBaseUrl+"/providers/"+api.HashRawBytes([]byte("not indexed")), "application/json")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	When("finding peers", func() {
+		It("should return the indexed instance", func() {
+			resp := routingGet(routingBaseUrl+"/peers/"+peer.ToCid(peerId).String(), "application/json")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var body struct{ Peers []routingPeerRecord }
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Peers).To(HaveLen(1))
+			Expect(body.Peers[0].ID).To(Equal(peerId.String()))
+		})
+	})
+
+	When("publishing ipns records", func() {
+		It("should store valid records and reject stale or forged ones", func() {
+			sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+			Expect(err).To(BeNil())
+			pid, err := peer.IDFromPrivateKey(sk)
+			Expect(err).To(BeNil())
+			name := ipns.NameFromPeer(pid)
+
+			resp := routingGet(routingBaseUrl+"/ipns/"+name.String(), "application/vnd.ipfs.ipns-record")
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+			record := newIpnsRecord(sk, 2)
+			resp = routingPutIpns(name, record, "")
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+			sessionToken, sessionId := newSession(ctx)
+			sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+			session, err := sessionManager.GetSession(ctx, sessionId)
+			Expect(err).To(BeNil())
+			resp = routingPutIpns(name, record, sessionToken)
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			charged, err := sessionManager.GetSession(ctx, sessionId)
+			Expect(err).To(BeNil())
+			Expect(charged.GetBalance()).To(BeNumerically("<", session.GetBalance()))
+
+			resp = routingGet(routingBaseUrl+"/ipns/"+name.String(), "application/vnd.ipfs.ipns-record")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/vnd.ipfs.ipns-record"))
+			stored, err := io.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(stored).To(Equal(record))
+
+			resp = routingPutIpns(name, newIpnsRecord(sk, 1), sessionToken)
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+			otherSk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+			Expect(err).To(BeNil())
+			resp = routingPutIpns(name, newIpnsRecord(otherSk, 3), sessionToken)
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
+
+var _ = Describe("Filter peer records", Label("routing"), func() {
+	record := func() *pb.PeerRecord {
+		return &pb.PeerRecord{
+			Schema:    api.ROUTING_SCHEMA_PEER,
+			Addrs:     []string{"/ip4/1.2.3.4/tcp/80/http", "/ip4/1.2.3.4/udp/4001/quic-v1"},
+			Protocols: []string{"transport-ipfs-gateway-http"},
+		}
+	}
+
+	It("should keep everything without filters", func() {
+		r := record()
+		Expect(api.NewRoutingFilter("", "").Apply(r)).To(BeTrue())
+		Expect(r.GetAddrs()).To(HaveLen(2))
+	})
+
+	It("should filter addresses by protocol", func() {
+		r := record()
+		Expect(api.NewRoutingFilter("tcp", "").Apply(r)).To(BeTrue())
+		Expect(r.GetAddrs()).To(Equal([]string{"/ip4/1.2.3.4/tcp/80/http"}))
+
+		r = record()
+		Expect(api.NewRoutingFilter("!tcp,!quic-v1", "").Apply(r)).To(BeFalse())
+	})
+
+	It("should filter records by transfer protocol", func() {
+		Expect(api.NewRoutingFilter("", "transport-bitswap,transport-ipfs-gateway-http").Apply(record())).To(BeTrue())
+		Expect(api.NewRoutingFilter("", "transport-bitswap").Apply(record())).To(BeFalse())
+
+		r := record()
+		r.Protocols = nil
+		Expect(api.NewRoutingFilter("", "unknown").Apply(r)).To(BeTrue())
+	})
+})
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyScanner walks the keys matching a pattern across rounds, so that rounds
+// processing a limited budget of keys still eventually cover all of them.
+type keyScanner struct {
+	redisClient *redis.Client
+	match       string
+
+	cursor uint64
+	// Keys scanned but not handed out yet. SCAN may return more keys than
+	// asked for, and those left when a round runs out of budget are carried
+	// over to the next one.
+	pending []string
+	// Set when the last SCAN completed a pass over the keys
+	passEnded bool
+}
+
+func newKeyScanner(redisClient *redis.Client, match string) *keyScanner {
+	return &keyScanner{
+		redisClient: redisClient,
+		match:       match,
+	}
+}
+
+// Next returns the next key, scanning about count more keys when none are
+// pending. It returns false once at the end of every pass so that a round
+// does not go over the same keys twice.
+func (s *keyScanner) Next(ctx context.Context, count int64) (string, bool, error) {
+	for len(s.pending) == 0 {
+		if s.passEnded {
+			s.passEnded = false
+			return "", false, nil
+		}
+		keys, cursor, err := s.redisClient.Scan(ctx, s.cursor, s.match, count).Result()
+		if err != nil {
+			return "", false, err
+		}
+		s.cursor = cursor
+		s.pending = keys
+		s.passEnded = cursor == 0
+	}
+	key := s.pending[0]
+	s.pending = s.pending[1:]
+	return key, true, nil
+}
@@ -3,16 +3,32 @@ package api
 import (
 	"crypto/ed25519"
 	"fmt"
+	"net/http"
 
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
 	"github.com/atticplaygroup/pkv/pkg/middleware"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/redis/go-redis/v9"
+	"github.com/wealdtech/go-merkletree/v2"
 )
 
+const variantTreeCacheSize = 16
+
 type Server struct {
 	config         *Config
 	redisClient    *redis.Client
 	sessionManager middleware.ISessionManager
+	pricingManager middleware.IPricingManager
 	authmanager    middleware.IAuthManager
+	fileServings   []MerkleTreeFileServing
+	libp2pServer   *Libp2pServer
+	bitswapServer  *BitswapServer
+	compressor     *Compressor
+	receiptKey     ed25519.PrivateKey
+	sessionKeys    *middleware.SessionKeyring
+	variantTrees   *lru.Cache[string, *merkletree.MerkleTree]
 	unitPrice      int64
 }
 
@@ -28,18 +44,92 @@ func NewServer(conf *Config) (*Server, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("%s:%d", conf.RedisHost, conf.RedisPort),
 	})
+	fileServings, err := DefaultFileServingRegistry.Build(
+		conf.EnabledBehaviors, conf.BehaviorMaintainer, rdb,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable behaviors: %v", err)
+	}
+	compressor, err := NewCompressor(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure compression: %v", err)
+	}
+	if conf.PricingSizeBasis != middleware.PRICING_SIZE_BASIS_LOGICAL &&
+		conf.PricingSizeBasis != middleware.PRICING_SIZE_BASIS_STORED {
+		return nil, fmt.Errorf("unsupported pricing size basis %s", conf.PricingSizeBasis)
+	}
+	if conf.SelfIdentifier == "" {
+		return nil, fmt.Errorf("self identifier is required as audience of tokens")
+	}
+	exchanges, err := middleware.ParseTrustedExchanges(conf.TrustedExchanges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trusted exchanges: %v", err)
+	}
+	exchanges[conf.QuotaAuthorityDid] = ""
+	receiptKey, err := receiptSigningKey(conf.Libp2pPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt signing key: %v", err)
+	}
+	sessionKeys, err := NewSessionKeyring(rdb, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session keyring: %v", err)
+	}
+	// Each tree has up to MAX_CID_SIZE leaves, so only a few are kept
+	variantTrees, err := lru.New[string, *merkletree.MerkleTree](variantTreeCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create variant tree cache: %v", err)
+	}
 	server := Server{
 		config:         conf,
 		redisClient:    rdb,
 		unitPrice:      1,
-		sessionManager: middleware.NewRedisSessionManager(rdb),
-		authmanager: middleware.NewStaticAuthManager(
-			conf.JwtSecret,
-			map[string]ed25519.PublicKey{
-				conf.QuotaAuthorityDid: conf.QuotaAuthorityPublicKey,
-			},
-			"did:example:pkv",
+		fileServings:   fileServings,
+		sessionManager: middleware.NewRedisSessionManager(rdb, conf.SessionSalt),
+		pricingManager: &middleware.PricingManager{SizeBasis: conf.PricingSizeBasis},
+		compressor:     compressor,
+		receiptKey:     receiptKey,
+		sessionKeys:    sessionKeys,
+		variantTrees:   variantTrees,
+		authmanager: middleware.NewDynamicAuthManager(
+			sessionKeys.KeyFunc,
+			exchanges,
+			middleware.NewKeyResolver(nil, conf.ExchangeKeyCacheTtl, conf.ExchangeKeyMinRefreshInterval),
+			conf.SelfIdentifier,
+			conf.JwtLeeway,
 		),
 	}
 	return &server, nil
 }
+
+// NewKvStoreHandler wraps the server with the session, pricing and validation
+// interceptors. The same handler is served over HTTP and libp2p.
+func NewKvStoreHandler(server *Server) (string, http.Handler, error) {
+	validator, err := protovalidate.New()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to initialize validator: %v", err)
+	}
+	// Sessions created by CreateSession are charged by the interceptors
+	sessionManager := server.sessionManager
+	authManager := server.GetAuthManager()
+
+	interceptors := make([]connect.Interceptor, 0)
+	if !server.config.DisableAuth {
+		interceptors = append(
+			interceptors,
+			middleware.NewConnectUnarySessionInterceptor(sessionManager, server.pricingManager, authManager),
+			middleware.NewConnectStreamingSessionInterceptor(sessionManager, authManager),
+		)
+	}
+	interceptors = append(
+		interceptors,
+		middleware.NewConnectAccountInterceptor(authManager),
+		middleware.NewConnectValidationInterceptor(validator),
+		middleware.NewConnectStreamingValidationInterceptor(validator),
+	)
+
+	path, handler := kvstoreconnect.NewKvStoreServiceHandler(
+		server,
+		connect.WithInterceptors(interceptors...),
+	)
+	return path, handler, nil
+}
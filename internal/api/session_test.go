@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1/kvstoreconnect"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quota token redemption", Label("session"), func() {
+	ctx := context.Background()
+	issuer := NewMockJwtIssuer()
+	client := kvstoreconnect.NewKvStoreServiceClient(http.DefaultClient, "http://127.0.0.1:50051")
+
+	createSession := func(quotaToken string) (*pb.CreateSessionResponse, error) {
+		resp, err := client.CreateSession(ctx, connect.NewRequest(&pb.CreateSessionRequest{
+			Jwt: quotaToken,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	It("should return the existing session when a token is redeemed again", func() {
+		quotaToken := issuer.IssueQuotaToken(uuid.NewString())
+		first, err := createSession(quotaToken)
+		Expect(err).To(BeNil())
+		sessionId := first.GetSession().GetSessionId()
+		ttl, err := RedisClient.TTL(ctx, fmt.Sprintf("session:%s", sessionId)).Result()
+		Expect(err).To(BeNil())
+		Expect(ttl).To(BeNumerically(">", 39*time.Hour))
+
+		sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		_, err = sessionManager.DeductSessionBalance(ctx, sessionId, 100)
+		Expect(err).To(BeNil())
+
+		second, err := createSession(quotaToken)
+		Expect(err).To(BeNil())
+		Expect(second.GetSession().GetSessionId()).To(Equal(sessionId))
+		Expect(second.GetSession().GetBalance()).To(Equal(first.GetSession().GetBalance() - 100))
+	})
+
+	It("should map tokens to the same session across restarts and refuse them once the session is gone", func() {
+		jti := uuid.NewString()
+		// A manager built from the same secret seed stands for a restarted node
+		before := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		session, err := before.CreateSession(ctx, "did:example:issuer", jti, 1000, time.Minute)
+		Expect(err).To(BeNil())
+		_, err = before.DeductSessionBalance(ctx, session.GetSessionId(), 10)
+		Expect(err).To(BeNil())
+
+		after := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		again, err := after.CreateSession(ctx, "did:example:issuer", jti, 1000, time.Minute)
+		Expect(err).To(BeNil())
+		Expect(again.GetSessionId()).To(Equal(session.GetSessionId()))
+		Expect(again.GetBalance()).To(Equal(int64(990)))
+
+		Expect(RedisClient.Del(ctx, fmt.Sprintf("session:%s", session.GetSessionId())).Err()).To(Succeed())
+		_, err = after.CreateSession(ctx, "did:example:issuer", jti, 1000, time.Minute)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should keep tokens of different issuers with the same jti apart", func() {
+		jti := uuid.NewString()
+		sessionManager := middleware.NewRedisSessionManager(RedisClient, Conf.SessionSalt)
+		first, err := sessionManager.CreateSession(ctx, "did:example:first", jti, 1000, time.Minute)
+		Expect(err).To(BeNil())
+		_, err = sessionManager.DeductSessionBalance(ctx, first.GetSessionId(), 10)
+		Expect(err).To(BeNil())
+
+		second, err := sessionManager.CreateSession(ctx, "did:example:second", jti, 1000, time.Minute)
+		Expect(err).To(BeNil())
+		Expect(second.GetSessionId()).To(Not(Equal(first.GetSessionId())))
+		Expect(second.GetBalance()).To(Equal(int64(1000)))
+	})
+})
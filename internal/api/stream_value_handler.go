@@ -10,7 +10,6 @@ import (
 	"connectrpc.com/connect"
 	"github.com/atticplaygroup/pkv/pkg/middleware"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -134,7 +133,14 @@ func (s *Server) ListStreamValues(
 			err,
 		)
 	}
-	if err := s.ensureAuthToken(req.GetAuthToken(), fields[0]); err != nil {
+	if err := s.authorizeStreamAccess(
+		ctx,
+		req.GetAuthToken(),
+		req.GetDelegationToken(),
+		fields[0],
+		fields[1],
+		pb.StreamOperation_STREAM_OPERATION_LIST,
+	); err != nil {
 		return nil, err
 	}
 	xMessages, err := s.redisClient.XRangeN(
@@ -185,34 +191,6 @@ func (s *Server) ListStreamValues(
 	}), nil
 }
 
-func (s *Server) ensureAuthToken(authToken string, expectedSubject string) error {
-	claims, err := s.authmanager.VerifyAndParseJwt(authToken, &jwt.RegisteredClaims{}, true)
-	if err != nil {
-		return status.Errorf(
-			codes.PermissionDenied,
-			"failed to parse auth token in request: %v",
-			err,
-		)
-	}
-	subject, err := claims.GetSubject()
-	if err != nil {
-		return status.Errorf(
-			codes.PermissionDenied,
-			"failed to parse subject in auth token: %v",
-			err,
-		)
-	}
-	if subject != expectedSubject {
-		return status.Errorf(
-			codes.PermissionDenied,
-			"auth token subject %s not matching resource owner %s",
-			subject,
-			expectedSubject,
-		)
-	}
-	return nil
-}
-
 func (s *Server) GetStreamValue(
 	ctx context.Context, connectReq *connect.Request[pb.GetStreamValueRequest],
 ) (*connect.Response[pb.GetStreamValueResponse], error) {
@@ -227,15 +205,19 @@ func (s *Server) GetStreamValue(
 			err,
 		)
 	}
-	if err := s.ensureAuthToken(req.GetAuthToken(), fields[0]); err != nil {
+	if err := s.authorizeStreamAccess(
+		ctx,
+		req.GetAuthToken(),
+		req.GetDelegationToken(),
+		fields[0],
+		fields[1],
+		pb.StreamOperation_STREAM_OPERATION_GET,
+	); err != nil {
 		return nil, err
 	}
-	streamID := fmt.Sprintf("accounts/%s/streams/%s", fields[0], fields[1])
-	xStream, err := s.redisClient.XRead(ctx, &redis.XReadArgs{
-		Streams: []string{streamID},
-		ID:      fields[2],
-		Count:   1,
-	}).Result()
+	streamID := streamName(fields[0], fields[1])
+	// XREAD would block for and return the entry after the one named
+	xMessages, err := s.redisClient.XRangeN(ctx, streamID, fields[2], fields[2], 1).Result()
 	if err != nil {
 		return nil, status.Errorf(
 			codes.Internal,
@@ -243,22 +225,14 @@ func (s *Server) GetStreamValue(
 			err,
 		)
 	}
-	if len(xStream) > 1 {
-		return nil, status.Errorf(
-			codes.Internal,
-			"got %d streams but expected 1: %v",
-			len(xStream),
-			xStream,
-		)
-	}
-	if len(xStream) == 0 || len(xStream[0].Messages) == 0 {
+	if len(xMessages) == 0 {
 		return nil, status.Error(
 			codes.NotFound,
 			"stream or message not found",
 		)
 	}
 
-	streamValueInfo, err := parseXMessage(&xStream[0].Messages[0])
+	streamValueInfo, err := parseXMessage(&xMessages[0])
 	if err != nil {
 		return nil, status.Errorf(
 			codes.Internal,
@@ -1,7 +1,10 @@
 package api
 
 import (
+	"net/http"
+
 	connectcors "connectrpc.com/cors"
+	"github.com/atticplaygroup/pkv/pkg/middleware"
 	"github.com/rs/cors"
 )
 
@@ -10,11 +13,18 @@ func GetCorsConfig() *cors.Cors {
 	// The security may need further discussion, but the reason to do so is
 	// if all requests are made by the user agent, it already takes expenditure
 	// into account. Then quota consumption from any origin is intended.
+	// PUT is allowed additionally to publish IPNS records through delegated routing,
+	// HEAD and Range for the trustless gateway. Browsers send account tokens
+	// in their own header beside the session token.
 	return cors.New(cors.Options{
-		AllowOriginFunc:  func(origin string) bool { return true },
-		AllowedMethods:   connectcors.AllowedMethods(),
-		AllowedHeaders:   append(connectcors.AllowedHeaders(), "Authorization"),
-		ExposedHeaders:   connectcors.ExposedHeaders(),
+		AllowOriginFunc: func(origin string) bool { return true },
+		AllowedMethods:  append(connectcors.AllowedMethods(), http.MethodPut, http.MethodHead),
+		AllowedHeaders:  append(connectcors.AllowedHeaders(), "Authorization", "Range", middleware.ACCOUNT_AUTHORIZATION_HEADER),
+		ExposedHeaders: append(
+			connectcors.ExposedHeaders(),
+			"Content-Range", "Content-Length", "Etag", "X-Ipfs-Path", "X-Ipfs-Roots",
+			"Last-Modified", "X-Pkv-Size", "X-Pkv-Codec", "X-Pkv-Expire-Time",
+		),
 		AllowCredentials: true,
 		// Debug:            true,
 	})
@@ -7,13 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/atticplaygroup/pkv/pkg/merkle"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
-	"github.com/ipfs/go-cid"
 	"github.com/mr-tron/base58"
-	"github.com/multiformats/go-multihash"
 	"github.com/redis/go-redis/v9"
-	"github.com/wealdtech/go-merkletree/v2"
-	"github.com/wealdtech/go-merkletree/v2/keccak256"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -35,24 +32,7 @@ var ServeAllBehavior = &pb.GlobalLink{
 }
 
 func CalculateMerkleRoot(cidStrings []string) (string, error) {
-	cids := make([][]byte, 0, len(cidStrings))
-	for _, cidString := range cidStrings {
-		cid, err := cid.Decode(cidString)
-		if err != nil {
-			return "", status.Errorf(
-				codes.InvalidArgument,
-				"failed to parse cid %s: %v",
-				cidString,
-				err,
-			)
-		}
-		cids = append(cids, cid.Bytes())
-	}
-	mt, err := merkletree.NewTree(
-		merkletree.WithData(cids),
-		merkletree.WithHashType(&keccak256.Keccak256{}),
-		merkletree.WithSorted(false),
-	)
+	mt, err := merkle.NewCidTree(cidStrings)
 	if err != nil {
 		return "", status.Errorf(
 			codes.InvalidArgument,
@@ -60,7 +40,7 @@ func CalculateMerkleRoot(cidStrings []string) (string, error) {
 			err,
 		)
 	}
-	mh, err := multihash.Encode(mt.Root(), multihash.KECCAK_256)
+	root, err := merkle.RootCid(mt)
 	if err != nil {
 		return "", status.Errorf(
 			codes.Internal,
@@ -68,7 +48,7 @@ func CalculateMerkleRoot(cidStrings []string) (string, error) {
 			err,
 		)
 	}
-	return cid.NewCidV1(cid.Raw, mh).String(), nil
+	return root, nil
 }
 
 func HashMessage[T proto.Message](m T) (string, error) {
@@ -99,18 +79,36 @@ type MerkleTreeFileServing interface {
 	Register(ctx context.Context, advertisement *pb.ProviderAdvertise) error
 }
 
+// SubsetFileServing is implemented by behaviors whose instances serve only
+// the CIDs they advertise rather than the whole virtual service.
+type SubsetFileServing interface {
+	ServesSubset() bool
+}
+
+// instanceAdKey holds the advertisement of an instance in one virtual
+// service.
+func instanceAdKey(vHash string, did string) string {
+	return fmt.Sprintf("vsvc:ad:%s:%s", vHash, did)
+}
+
 type ServeAllFileServing struct {
 	redisClient *redis.Client
+	behavior    *pb.GlobalLink
 }
 
 func NewServeAllFileServing(redisClient *redis.Client) MerkleTreeFileServing {
+	return newServeAllFileServing(ServeAllBehavior, redisClient)
+}
+
+func newServeAllFileServing(behavior *pb.GlobalLink, redisClient *redis.Client) *ServeAllFileServing {
 	return &ServeAllFileServing{
 		redisClient: redisClient,
+		behavior:    behavior,
 	}
 }
 
 func (m *ServeAllFileServing) IsBehaviorMatching(behaviorLink *pb.GlobalLink) (bool, error) {
-	return GlobalLinkEqual(behaviorLink, ServeAllBehavior)
+	return GlobalLinkEqual(behaviorLink, m.behavior)
 }
 
 func (m *ServeAllFileServing) EnsureAdvertisementValid(advertisement *pb.ProviderAdvertise) error {
@@ -169,6 +167,21 @@ func (m *ServeAllFileServing) registerVirtualService(
 	if err = m.redisClient.Set(ctx, instanceKey, advertisement, DEFAULT_TTL).Err(); err != nil {
 		return err
 	}
+	// The latest advertisement above describes the instance, while an
+	// instance in several virtual services keeps the one of each for search
+	if err = m.redisClient.Set(
+		ctx, instanceAdKey(virtualServiceHash, advertisement.GetProviderInstance().GetDid()), advertisement, DEFAULT_TTL,
+	).Err(); err != nil {
+		return err
+	}
+
+	// Index the peer id for /routing/v1/peers lookups. Instances without one
+	// are still searchable by CID through the pkv API.
+	if peerId, err := InstancePeerId(advertisement.GetProviderInstance()); err == nil {
+		if err = m.redisClient.Set(ctx, peerIndexKey(peerId), advertisement.GetProviderInstance().GetDid(), DEFAULT_TTL).Err(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -193,6 +206,29 @@ func (m *ServeAllFileServing) registerCid(
 	return nil
 }
 
+// registerLeaves keeps the CIDs in merkle tree order so that inclusion proofs
+// can be generated at search time.
+func (m *ServeAllFileServing) registerLeaves(
+	ctx context.Context,
+	advertisement *pb.ProviderAdvertise,
+) error {
+	virtualServiceHash, err := HashMessage(advertisement.GetVirtualService())
+	if err != nil {
+		return err
+	}
+	leavesKey := fmt.Sprintf("vsvc:leaves:%s", virtualServiceHash)
+	leaves := make([]interface{}, 0, len(advertisement.GetCids()))
+	for _, cid := range advertisement.GetCids() {
+		leaves = append(leaves, cid)
+	}
+	pipe := m.redisClient.TxPipeline()
+	pipe.Del(ctx, leavesKey)
+	pipe.RPush(ctx, leavesKey, leaves...)
+	pipe.Expire(ctx, leavesKey, DEFAULT_TTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
 func (m *ServeAllFileServing) Register(
 	ctx context.Context, advertisement *pb.ProviderAdvertise,
 ) error {
@@ -202,5 +238,8 @@ func (m *ServeAllFileServing) Register(
 	if err := m.registerCid(ctx, advertisement); err != nil {
 		return err
 	}
+	if err := m.registerLeaves(ctx, advertisement); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,175 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/wealdtech/go-merkletree/v2"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+	"golang.org/x/crypto/sha3"
+)
+
+// CHUNK_SIZE is the size of the leaves of the chunk tree of a value. The
+// last chunk may be shorter.
+const CHUNK_SIZE = 1024
+
+// Prefixes separating the hashes of chunks from those of branches, so that a
+// branch cannot be passed off as a chunk to shorten a proof.
+const (
+	chunkLeafPrefix   = 0x00
+	chunkBranchPrefix = 0x01
+)
+
+// chunkHash is Keccak-256 with domain separated leaves. The tree hashes
+// leaves as a single input and branches as two.
+type chunkHash struct {
+	keccak256.Keccak256
+}
+
+func (*chunkHash) Hash(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	if len(data) == 1 {
+		hash.Write([]byte{chunkLeafPrefix})
+	} else {
+		hash.Write([]byte{chunkBranchPrefix})
+	}
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hash.Sum(nil)
+}
+
+// ChunkCount is the number of leaves of the chunk tree of a value of size.
+func ChunkCount(size int64) uint64 {
+	if size <= 0 {
+		return 0
+	}
+	return uint64((size + CHUNK_SIZE - 1) / CHUNK_SIZE)
+}
+
+// Chunks splits value into the leaves of its chunk tree.
+func Chunks(value []byte) [][]byte {
+	chunks := make([][]byte, 0, (len(value)+CHUNK_SIZE-1)/CHUNK_SIZE)
+	for start := 0; start < len(value); start += CHUNK_SIZE {
+		chunks = append(chunks, value[start:min(start+CHUNK_SIZE, len(value))])
+	}
+	return chunks
+}
+
+// NewChunkTree builds the tree over the chunks of value. Clients keep its
+// root at upload to later challenge the operator storing value.
+func NewChunkTree(value []byte) (*merkletree.MerkleTree, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("cannot build chunk tree of empty value")
+	}
+	mt, err := merkletree.NewTree(
+		merkletree.WithData(Chunks(value)),
+		merkletree.WithHashType(&chunkHash{}),
+		merkletree.WithSorted(false),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk tree: %v", err)
+	}
+	return mt, nil
+}
+
+// ChunkRoot returns the root of the chunk tree of value.
+func ChunkRoot(value []byte) ([]byte, error) {
+	mt, err := NewChunkTree(value)
+	if err != nil {
+		return nil, err
+	}
+	return mt.Root(), nil
+}
+
+// GenerateChunkProof proves the chunk at index belongs to the tree of value.
+// Proofs are generated by index since identical chunks are common.
+func GenerateChunkProof(
+	mt *merkletree.MerkleTree, value []byte, index uint64,
+) (*pb.ChunkProof, error) {
+	chunks := Chunks(value)
+	if index >= uint64(len(chunks)) {
+		return nil, fmt.Errorf("chunk %d out of %d chunks", index, len(chunks))
+	}
+	proof, err := mt.GenerateProofWithIndex(index, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof of chunk %d: %v", index, err)
+	}
+	return &pb.ChunkProof{
+		Index:  index,
+		Chunk:  chunks[index],
+		Hashes: proof.Hashes,
+	}, nil
+}
+
+// VerifyChunkProof checks a chunk returned by a challenge against the root
+// the client computed at upload, without trusting the operator for it. index
+// is the chunk the client asked for and size the size of the value, which
+// fix the position and length of both the chunk and its proof.
+func VerifyChunkProof(root []byte, proof *pb.ChunkProof, index uint64, size int64) error {
+	count := ChunkCount(size)
+	if index >= count {
+		return fmt.Errorf("chunk %d out of %d chunks", index, count)
+	}
+	if proof.GetIndex() != index {
+		return fmt.Errorf("proof is for chunk %d but chunk %d was challenged", proof.GetIndex(), index)
+	}
+	expectedSize := min(int64(CHUNK_SIZE), size-int64(index)*CHUNK_SIZE)
+	if int64(len(proof.GetChunk())) != expectedSize {
+		return fmt.Errorf("chunk %d has size %d but expected %d", index, len(proof.GetChunk()), expectedSize)
+	}
+	// The tree is padded to a power of two leaves
+	if depth := bits.Len64(count - 1); len(proof.GetHashes()) != depth {
+		return fmt.Errorf("proof of chunk %d has %d hashes but expected %d", index, len(proof.GetHashes()), depth)
+	}
+	verified, err := merkletree.VerifyProofUsing(
+		proof.GetChunk(),
+		false,
+		&merkletree.Proof{
+			Hashes: proof.GetHashes(),
+			Index:  index,
+		},
+		[][]byte{root},
+		&chunkHash{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to verify proof of chunk %d: %v", index, err)
+	}
+	if !verified {
+		return fmt.Errorf("chunk %d is not included in root %x", index, root)
+	}
+	return nil
+}
+
+// SegmentsDigest hashes nonce followed by the segments of value starting at
+// offsets. Segments are cut short at the end of value.
+func SegmentsDigest(value []byte, nonce []byte, offsets []int64, segmentSize int64) ([]byte, error) {
+	h := sha256.New()
+	h.Write(nonce)
+	for _, offset := range offsets {
+		if offset < 0 || offset >= int64(len(value)) {
+			return nil, fmt.Errorf("offset %d out of value of size %d", offset, len(value))
+		}
+		h.Write(value[offset:min(offset+segmentSize, int64(len(value)))])
+	}
+	return h.Sum(nil), nil
+}
+
+// VerifySegmentsDigest checks the digest answered to a challenge. Clients not
+// keeping the value can precompute the digests of the nonces they will use
+// before uploading.
+func VerifySegmentsDigest(
+	value []byte, nonce []byte, offsets []int64, segmentSize int64, digest []byte,
+) error {
+	expected, err := SegmentsDigest(value, nonce, offsets, segmentSize)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, digest) {
+		return fmt.Errorf("segments digest %x not matching expected %x", digest, expected)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package merkle_test
+
+import (
+	"bytes"
+
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test storage challenges", func() {
+	It("Should prove repeated and single chunks by index", func() {
+		value := bytes.Repeat([]byte("a"), 4*merkle.CHUNK_SIZE)
+		mt, err := merkle.NewChunkTree(value)
+		Expect(err).To(BeNil())
+		for i := range uint64(4) {
+			proof, err := merkle.GenerateChunkProof(mt, value, i)
+			Expect(err).To(BeNil())
+			Expect(proof.GetIndex()).To(Equal(i))
+			Expect(merkle.VerifyChunkProof(mt.Root(), proof, i, int64(len(value)))).To(Succeed())
+		}
+
+		single := []byte("short value")
+		mt, err = merkle.NewChunkTree(single)
+		Expect(err).To(BeNil())
+		proof, err := merkle.GenerateChunkProof(mt, single, 0)
+		Expect(err).To(BeNil())
+		Expect(proof.GetChunk()).To(Equal(single))
+		Expect(merkle.VerifyChunkProof(mt.Root(), proof, 0, int64(len(single)))).To(Succeed())
+		_, err = merkle.GenerateChunkProof(mt, single, 1)
+		Expect(err).To(Not(BeNil()))
+	})
+	It("Should reject proofs not of the challenged chunk", func() {
+		value := make([]byte, 4*merkle.CHUNK_SIZE)
+		for i := range value {
+			value[i] = byte(i / merkle.CHUNK_SIZE)
+		}
+		size := int64(len(value))
+		mt, err := merkle.NewChunkTree(value)
+		Expect(err).To(BeNil())
+		proof, err := merkle.GenerateChunkProof(mt, value, 1)
+		Expect(err).To(BeNil())
+		Expect(merkle.VerifyChunkProof(mt.Root(), proof, 1, size)).To(Succeed())
+
+		By("answering a challenge with another chunk")
+		Expect(merkle.VerifyChunkProof(mt.Root(), proof, 0, size)).To(Not(Succeed()))
+		Expect(merkle.VerifyChunkProof(mt.Root(), proof, 4, size)).To(Not(Succeed()))
+
+		By("passing a branch off as a chunk with a shortened proof")
+		forged := &pb.ChunkProof{
+			Index:  0,
+			Chunk:  append(append([]byte{}, mt.Nodes[4]...), mt.Nodes[5]...),
+			Hashes: [][]byte{mt.Nodes[3]},
+		}
+		Expect(merkle.VerifyChunkProof(mt.Root(), forged, 0, size)).To(Not(Succeed()))
+		// Even when a last chunk of the claimed size fits a branch and its
+		// shortened proof, as only leaves are hashed as chunks
+		forged = &pb.ChunkProof{
+			Index:  1,
+			Chunk:  append(append([]byte{}, mt.Nodes[6]...), mt.Nodes[7]...),
+			Hashes: [][]byte{mt.Nodes[2]},
+		}
+		Expect(merkle.VerifyChunkProof(mt.Root(), forged, 1, merkle.CHUNK_SIZE+64)).To(Not(Succeed()))
+
+		By("padding a proof with extra hashes")
+		padded := &pb.ChunkProof{
+			Index:  proof.GetIndex(),
+			Chunk:  proof.GetChunk(),
+			Hashes: append(append([][]byte{}, proof.GetHashes()...), mt.Root()),
+		}
+		Expect(merkle.VerifyChunkProof(mt.Root(), padded, 1, size)).To(Not(Succeed()))
+	})
+})
@@ -0,0 +1,118 @@
+// Package merkle builds the keccak-256 merkle trees identifying virtual
+// service variants and chunking stored values, and verifies the inclusion
+// proofs and storage challenges answered by untrusted servers.
+package merkle
+
+import (
+	"fmt"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/wealdtech/go-merkletree/v2"
+	"github.com/wealdtech/go-merkletree/v2/keccak256"
+)
+
+// NewCidTree builds the tree with the binary CIDs as leaves in the given order.
+func NewCidTree(cidStrings []string) (*merkletree.MerkleTree, error) {
+	cids := make([][]byte, 0, len(cidStrings))
+	for _, cidString := range cidStrings {
+		c, err := cid.Decode(cidString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cid %s: %v", cidString, err)
+		}
+		cids = append(cids, c.Bytes())
+	}
+	mt, err := merkletree.NewTree(
+		merkletree.WithData(cids),
+		merkletree.WithHashType(&keccak256.Keccak256{}),
+		merkletree.WithSorted(false),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merkle tree: %v", err)
+	}
+	return mt, nil
+}
+
+// RootCid encodes the tree root as a raw CIDv1 with a keccak-256 multihash.
+func RootCid(mt *merkletree.MerkleTree) (string, error) {
+	mh, err := multihash.Encode(mt.Root(), multihash.KECCAK_256)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode merkle root: %v", err)
+	}
+	return cid.NewCidV1(cid.Raw, mh).String(), nil
+}
+
+// GenerateInclusionProof proves cidString is a leaf of the tree.
+func GenerateInclusionProof(mt *merkletree.MerkleTree, cidString string) (*pb.MerkleInclusionProof, error) {
+	c, err := cid.Decode(cidString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cid %s: %v", cidString, err)
+	}
+	proof, err := mt.GenerateProof(c.Bytes(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof for %s: %v", cidString, err)
+	}
+	root, err := RootCid(mt)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MerkleInclusionProof{
+		Root:      root,
+		LeafIndex: proof.Index,
+		Hashes:    proof.Hashes,
+	}, nil
+}
+
+func decodeRoot(rootCid string) ([]byte, error) {
+	c, err := cid.Decode(rootCid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root %s: %v", rootCid, err)
+	}
+	decoded, err := multihash.Decode(c.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode multihash of root %s: %v", rootCid, err)
+	}
+	if decoded.Code != multihash.KECCAK_256 {
+		return nil, fmt.Errorf("root %s is not a keccak-256 hash", rootCid)
+	}
+	return decoded.Digest, nil
+}
+
+// VerifyInclusionProof checks that cidString is a leaf under the variant root
+// of vsvc, so that a client does not need to trust the index for it.
+func VerifyInclusionProof(
+	cidString string, vsvc *pb.VirtualService, proof *pb.MerkleInclusionProof,
+) error {
+	variantRoot := vsvc.GetVariantLink().GetName()
+	if proof.GetRoot() != variantRoot {
+		return fmt.Errorf(
+			"proof root %s not matching variant %s", proof.GetRoot(), variantRoot,
+		)
+	}
+	root, err := decodeRoot(variantRoot)
+	if err != nil {
+		return err
+	}
+	c, err := cid.Decode(cidString)
+	if err != nil {
+		return fmt.Errorf("failed to parse cid %s: %v", cidString, err)
+	}
+	verified, err := merkletree.VerifyProofUsing(
+		c.Bytes(),
+		false,
+		&merkletree.Proof{
+			Hashes: proof.GetHashes(),
+			Index:  proof.GetLeafIndex(),
+		},
+		[][]byte{root},
+		&keccak256.Keccak256{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to verify proof: %v", err)
+	}
+	if !verified {
+		return fmt.Errorf("cid %s is not included in variant %s", cidString, variantRoot)
+	}
+	return nil
+}
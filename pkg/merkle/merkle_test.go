@@ -0,0 +1,66 @@
+package merkle_test
+
+import (
+	"fmt"
+
+	"github.com/atticplaygroup/pkv/pkg/merkle"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func rawCid(data string) string {
+	mh, err := multihash.Sum([]byte(data), multihash.SHA2_256, -1)
+	Expect(err).To(BeNil())
+	return cid.NewCidV1(cid.Raw, mh).String()
+}
+
+var _ = Describe("Test inclusion proofs", func() {
+	cids := make([]string, 0, 5)
+	for i := range 5 {
+		cids = append(cids, rawCid(fmt.Sprintf("leaf %d", i)))
+	}
+
+	It("Should verify every leaf under the variant root", func() {
+		mt, err := merkle.NewCidTree(cids)
+		Expect(err).To(BeNil())
+		root, err := merkle.RootCid(mt)
+		Expect(err).To(BeNil())
+		vsvc := &pb.VirtualService{
+			VariantLink: &pb.GlobalLink{Name: root},
+		}
+		for i, c := range cids {
+			proof, err := merkle.GenerateInclusionProof(mt, c)
+			Expect(err).To(BeNil())
+			Expect(proof.GetLeafIndex()).To(Equal(uint64(i)))
+			Expect(merkle.VerifyInclusionProof(c, vsvc, proof)).To(Succeed())
+		}
+	})
+
+	It("Should reject fabricated associations", func() {
+		mt, err := merkle.NewCidTree(cids)
+		Expect(err).To(BeNil())
+		root, err := merkle.RootCid(mt)
+		Expect(err).To(BeNil())
+		vsvc := &pb.VirtualService{
+			VariantLink: &pb.GlobalLink{Name: root},
+		}
+		proof, err := merkle.GenerateInclusionProof(mt, cids[0])
+		Expect(err).To(BeNil())
+
+		Expect(merkle.VerifyInclusionProof(rawCid("not a leaf"), vsvc, proof)).To(Not(Succeed()))
+
+		otherTree, err := merkle.NewCidTree(cids[1:])
+		Expect(err).To(BeNil())
+		otherRoot, err := merkle.RootCid(otherTree)
+		Expect(err).To(BeNil())
+		Expect(merkle.VerifyInclusionProof(cids[0], &pb.VirtualService{
+			VariantLink: &pb.GlobalLink{Name: otherRoot},
+		}, proof)).To(Not(Succeed()))
+
+		_, err = merkle.GenerateInclusionProof(otherTree, cids[0])
+		Expect(err).To(Not(BeNil()))
+	})
+})
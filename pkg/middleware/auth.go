@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"crypto/ed25519"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc/codes"
@@ -13,34 +15,45 @@ type IAuthManager interface {
 }
 
 type StaticAuthManager struct {
-	jwtSecret      []byte
+	selfKeyFunc    jwt.Keyfunc
 	trustedIssuers map[string]ed25519.PublicKey
 	selfIdentifier string
+	leeway         time.Duration
 }
 
-func NewStaticAuthManager(jwtSecret []byte, trustedIssuers map[string]ed25519.PublicKey, selfIdentifier string) *StaticAuthManager {
+// NewStaticAuthManager verifies tokens of this server with selfKeyFunc, like
+// the KeyFunc of its SessionKeyring, and quota tokens with the fixed keys of
+// trusted issuers.
+func NewStaticAuthManager(
+	selfKeyFunc jwt.Keyfunc, trustedIssuers map[string]ed25519.PublicKey, selfIdentifier string, leeway time.Duration,
+) *StaticAuthManager {
 	return &StaticAuthManager{
-		jwtSecret:      jwtSecret,
+		selfKeyFunc:    selfKeyFunc,
 		trustedIssuers: trustedIssuers,
 		selfIdentifier: selfIdentifier,
+		leeway:         leeway,
 	}
 }
 
-func (a *StaticAuthManager) selfKeyFunc(token *jwt.Token) (any, error) {
-	if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, status.Errorf(
-			codes.Unauthenticated,
-			"invalid signing method %s",
-			token.Method.Alg(),
-		)
-	} else if method.Name != "HS256" {
-		return nil, status.Errorf(
-			codes.Unauthenticated,
-			"invalid signing method %s",
-			token.Method.Alg(),
-		)
+// HmacKeyFunc accepts HS256 tokens signed with a single secret, for servers
+// not rotating their keys.
+func HmacKeyFunc(jwtSecret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, status.Errorf(
+				codes.Unauthenticated,
+				"invalid signing method %s",
+				token.Method.Alg(),
+			)
+		} else if method.Name != "HS256" {
+			return nil, status.Errorf(
+				codes.Unauthenticated,
+				"invalid signing method %s",
+				token.Method.Alg(),
+			)
+		}
+		return jwtSecret, nil
 	}
-	return a.jwtSecret, nil
 }
 
 func (a *StaticAuthManager) exchangeKeyFunc(token *jwt.Token) (any, error) {
@@ -75,8 +88,31 @@ func (a *StaticAuthManager) VerifyAndParseJwt(jwtString string, claims jwt.Claim
 	if isSelf {
 		keyFunc = a.selfKeyFunc
 	}
+	return parseWithClaims(jwtString, claims, keyFunc, claimsParserOptions(a.selfIdentifier, a.leeway, isSelf)...)
+}
+
+// claimsParserOptions binds every token to this server as audience, so that
+// tokens minted for another operator cannot be redeemed here. Tokens of this
+// server must also be issued by it, exchange issuers are checked against the
+// trusted ones when resolving their keys.
+func claimsParserOptions(selfIdentifier string, leeway time.Duration, isSelf bool) []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithAudience(selfIdentifier),
+		jwt.WithLeeway(leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	if isSelf {
+		options = append(options, jwt.WithIssuer(selfIdentifier))
+	}
+	return options
+}
+
+func parseWithClaims(
+	jwtString string, claims jwt.Claims, keyFunc jwt.Keyfunc, options ...jwt.ParserOption,
+) (jwt.Claims, error) {
 	token, err := jwt.ParseWithClaims(
-		jwtString, claims, keyFunc,
+		jwtString, claims, keyFunc, options...,
 	)
 	if err != nil {
 		return nil, status.Errorf(
@@ -87,3 +123,80 @@ func (a *StaticAuthManager) VerifyAndParseJwt(jwtString string, claims jwt.Claim
 	}
 	return token.Claims, nil
 }
+
+// DynamicAuthManager trusts quota tokens from a set of exchanges whose keys
+// are resolved on demand, so that operators can accept payment from several
+// exchanges and follow their key rotations.
+type DynamicAuthManager struct {
+	selfKeyFunc    jwt.Keyfunc
+	selfIdentifier string
+	leeway         time.Duration
+	// JWKS URL of each trusted exchange DID, empty to resolve from the DID
+	exchanges map[string]string
+	resolver  *KeyResolver
+}
+
+func NewDynamicAuthManager(
+	selfKeyFunc jwt.Keyfunc,
+	exchanges map[string]string,
+	resolver *KeyResolver,
+	selfIdentifier string,
+	leeway time.Duration,
+) *DynamicAuthManager {
+	return &DynamicAuthManager{
+		selfKeyFunc:    selfKeyFunc,
+		selfIdentifier: selfIdentifier,
+		leeway:         leeway,
+		exchanges:      exchanges,
+		resolver:       resolver,
+	}
+}
+
+func (a *DynamicAuthManager) exchangeKeyFunc(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+		return nil, status.Errorf(
+			codes.Unauthenticated,
+			"invalid signing method %s",
+			token.Method.Alg(),
+		)
+	}
+	issuer, err := token.Claims.GetIssuer()
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Unauthenticated,
+			"failed to get issuer %s",
+			err.Error(),
+		)
+	}
+	jwksUrl, ok := a.exchanges[issuer]
+	if !ok {
+		return nil, status.Errorf(
+			codes.PermissionDenied,
+			"unexpected issuer %s",
+			issuer,
+		)
+	}
+	kid, _ := token.Header["kid"].(string)
+	publicKeys, err := a.resolver.Resolve(context.Background(), issuer, jwksUrl, kid)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Unauthenticated,
+			"failed to resolve keys of %s: %v",
+			issuer,
+			err,
+		)
+	}
+	keySet := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, 0, len(publicKeys))}
+	for _, publicKey := range publicKeys {
+		keySet.Keys = append(keySet.Keys, publicKey)
+	}
+	return keySet, nil
+}
+
+func (a *DynamicAuthManager) VerifyAndParseJwt(jwtString string, claims jwt.Claims, isSelf bool) (jwt.Claims, error) {
+	keyFunc := a.exchangeKeyFunc
+	if isSelf {
+		keyFunc = a.selfKeyFunc
+	}
+	return parseWithClaims(jwtString, claims, keyFunc, claimsParserOptions(a.selfIdentifier, a.leeway, isSelf)...)
+}
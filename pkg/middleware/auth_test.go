@@ -0,0 +1,224 @@
+package middleware_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// mockExchange serves the did:web document and JWKS of an exchange whose
+// keys can be rotated.
+type mockExchange struct {
+	mu     sync.Mutex
+	kid    string
+	key    ed25519.PrivateKey
+	server *httptest.Server
+	did    string
+}
+
+func newMockExchange() *mockExchange {
+	e := &mockExchange{}
+	e.rotate()
+	e.server = httptest.NewTLSServer(http.HandlerFunc(e.serve))
+	host := strings.TrimPrefix(e.server.URL, "https://")
+	e.did = "did:web:" + strings.ReplaceAll(host, ":", "%3A")
+	return e
+}
+
+func (e *mockExchange) rotate() {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).To(BeNil())
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.kid = uuid.NewString()
+	e.key = key
+}
+
+func (e *mockExchange) serve(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	publicKey := e.key.Public().(ed25519.PublicKey)
+	switch r.URL.Path {
+	case "/.well-known/did.json":
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": e.did,
+			"verificationMethod": []map[string]any{{
+				"id":                 e.did + "#" + e.kid,
+				"type":               "Multikey",
+				"controller":         e.did,
+				"publicKeyMultibase": "z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...)),
+			}},
+		})
+	case "/jwks.json":
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{{
+				"kid": e.kid,
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"x":   base64.RawURLEncoding.EncodeToString(publicKey),
+			}},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+const selfIdentifier = "did:example:pkv"
+
+func (e *mockExchange) issue(issuer string, kid string) string {
+	return e.issueClaims(&jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Issuer:    issuer,
+		Audience:  jwt.ClaimStrings{selfIdentifier},
+		ID:        uuid.NewString(),
+	}, kid)
+}
+
+func (e *mockExchange) issueClaims(registeredClaims *jwt.RegisteredClaims, kid string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	claims := middleware.CreateSessionJwtClaims{
+		Quantity: 1000,
+		SessionJwtClaims: &middleware.SessionJwtClaims{
+			RegisteredClaims: registeredClaims,
+			Usage:            pb.JwtUsage_JWT_USAGE_CREATE_SESSION,
+		},
+	}
+	token := jwt.NewWithClaims(&jwt.SigningMethodEd25519{}, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(e.key)
+	Expect(err).To(BeNil())
+	return signed
+}
+
+var _ = Describe("Test DynamicAuthManager", func() {
+	var exchange *mockExchange
+	var authManager *middleware.DynamicAuthManager
+	jwksDid := "did:example:prex"
+	BeforeEach(func() {
+		exchange = newMockExchange()
+		DeferCleanup(exchange.server.Close)
+		exchanges, err := middleware.ParseTrustedExchanges([]string{
+			exchange.did,
+			jwksDid + "=" + exchange.server.URL + "/jwks.json",
+		})
+		Expect(err).To(BeNil())
+		resolver := middleware.NewKeyResolver(exchange.server.Client(), time.Hour, 0)
+		authManager = middleware.NewDynamicAuthManager(middleware.HmacKeyFunc([]byte("secret")), exchanges, resolver, selfIdentifier, time.Minute)
+	})
+
+	verify := func(token string) error {
+		_, err := authManager.VerifyAndParseJwt(token, &middleware.CreateSessionJwtClaims{}, false)
+		return err
+	}
+
+	It("Should resolve did:web documents and JWKS and follow key rotations", func() {
+		Expect(verify(exchange.issue(exchange.did, "#"+exchange.kid))).To(Succeed())
+		Expect(verify(exchange.issue(exchange.did, ""))).To(Succeed())
+		Expect(verify(exchange.issue(jwksDid, exchange.kid))).To(Succeed())
+
+		exchange.rotate()
+		Expect(verify(exchange.issue(exchange.did, exchange.did+"#"+exchange.kid))).To(Succeed())
+		Expect(verify(exchange.issue(jwksDid, exchange.kid))).To(Succeed())
+	})
+
+	It("Should reject untrusted issuers and forged keys", func() {
+		Expect(verify(exchange.issue("did:example:other", exchange.kid))).To(Not(Succeed()))
+
+		kid := exchange.kid
+		exchange.rotate()
+		forged := exchange.issue(jwksDid, kid)
+		Expect(verify(forged)).To(Not(Succeed()))
+	})
+
+	It("Should resolve did:key issuers directly", func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		did := "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+		exchanges, err := middleware.ParseTrustedExchanges([]string{did})
+		Expect(err).To(BeNil())
+		authManager = middleware.NewDynamicAuthManager(
+			middleware.HmacKeyFunc([]byte("secret")), exchanges, middleware.NewKeyResolver(nil, time.Hour, 0), selfIdentifier, time.Minute,
+		)
+		exchange.key = privateKey
+		Expect(verify(exchange.issue(did, ""))).To(Succeed())
+	})
+
+	It("Should reject tokens minted for another operator", func() {
+		Expect(verify(exchange.issueClaims(&jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    exchange.did,
+			Audience:  jwt.ClaimStrings{"did:example:other-pkv"},
+			ID:        uuid.NewString(),
+		}, exchange.kid))).To(Not(Succeed()))
+		Expect(verify(exchange.issueClaims(&jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    exchange.did,
+			ID:        uuid.NewString(),
+		}, exchange.kid))).To(Not(Succeed()))
+	})
+
+	It("Should check not before and expiry with leeway", func() {
+		issueAt := func(notBefore time.Time, expiresAt time.Time) string {
+			return exchange.issueClaims(&jwt.RegisteredClaims{
+				NotBefore: jwt.NewNumericDate(notBefore),
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				Issuer:    exchange.did,
+				Audience:  jwt.ClaimStrings{selfIdentifier},
+				ID:        uuid.NewString(),
+			}, exchange.kid)
+		}
+		now := time.Now()
+		Expect(verify(issueAt(now.Add(30*time.Second), now.Add(time.Hour)))).To(Succeed())
+		Expect(verify(issueAt(now.Add(2*time.Minute), now.Add(time.Hour)))).To(Not(Succeed()))
+		Expect(verify(issueAt(now.Add(-time.Hour), now.Add(-30*time.Second)))).To(Succeed())
+		Expect(verify(issueAt(now.Add(-time.Hour), now.Add(-2*time.Minute)))).To(Not(Succeed()))
+	})
+
+	It("Should only accept own session tokens issued for itself", func() {
+		sign := func(issuer string, audience string) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, &middleware.SessionJwtClaims{
+				RegisteredClaims: &jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					Issuer:    issuer,
+					Audience:  jwt.ClaimStrings{audience},
+					Subject:   uuid.NewString(),
+				},
+				Usage: pb.JwtUsage_JWT_USAGE_MANAGE_SESSION,
+			})
+			signed, err := token.SignedString([]byte("secret"))
+			Expect(err).To(BeNil())
+			return signed
+		}
+		_, err := middleware.VerifySessionToken(authManager, sign(selfIdentifier, selfIdentifier))
+		Expect(err).To(BeNil())
+		_, err = middleware.VerifySessionToken(authManager, sign("did:example:other-pkv", selfIdentifier))
+		Expect(err).To(Not(BeNil()))
+		_, err = middleware.VerifySessionToken(authManager, sign(selfIdentifier, "did:example:other-pkv"))
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("Should build did:web document urls", func() {
+		location, err := middleware.DidWebUrl("did:web:example.com%3A8443:users:alice")
+		Expect(err).To(BeNil())
+		Expect(location).To(Equal("https://example.com:8443/users/alice/did.json"))
+		_, err = middleware.ParseTrustedExchanges([]string{"did:example:nokeys"})
+		Expect(err).To(Not(BeNil()))
+	})
+})
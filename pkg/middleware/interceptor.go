@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"net/http"
 	"strings"
 
 	"google.golang.org/grpc/codes"
@@ -35,36 +36,25 @@ func NewConnectUnarySessionInterceptor(s ISessionManager, p IPricingManager, a I
 			if len(authString) == 0 {
 				authString = req.Header().Get("authorization")
 			}
-			pieces := strings.Split(authString, " ")
-			if len(pieces) != 2 || !strings.EqualFold(pieces[0], "bearer") {
-				return nil, status.Errorf(
-					codes.InvalidArgument,
-					"failed to parse bearer token",
-				)
-			}
-			claims, err := a.VerifyAndParseJwt(pieces[1], &SessionJwtClaims{}, true)
+			jwtClaims, err := AuthenticateSession(a, authString)
 			if err != nil {
-				return nil, status.Errorf(
-					codes.PermissionDenied,
-					"failed to parse or verify token: %s",
-					err.Error(),
-				)
+				return nil, err
 			}
-
-			jwtClaims, ok := claims.(*SessionJwtClaims)
-			if !ok {
-				return nil, status.Errorf(
-					codes.InvalidArgument,
-					"failed to parse session jwt",
-				)
+			// Sessions are ended whatever balance is left, and revoked ones
+			// can still be closed to settle their refund
+			noCharge := []string{
+				pbc.KvStoreServiceRevokeSessionProcedure,
+				pbc.KvStoreServiceCloseSessionProcedure,
 			}
-			if jwtClaims.Usage != pb.JwtUsage_JWT_USAGE_MANAGE_SESSION {
-				return nil, status.Errorf(
-					codes.PermissionDenied,
-					"expected token usage %d but got %d",
-					pb.JwtUsage_JWT_USAGE_MANAGE_SESSION,
-					jwtClaims.Usage,
-				)
+			for _, procedureName := range noCharge {
+				if req.Spec().Procedure == procedureName {
+					return next(context.WithValue(ctx, KeySession, &pb.Session{
+						SessionId: jwtClaims.Subject,
+					}), req)
+				}
+			}
+			if err := ensureSessionNotRevoked(ctx, s, jwtClaims.Subject); err != nil {
+				return nil, err
 			}
 			price, err := p.GetPrice(req)
 			if err != nil {
@@ -82,8 +72,219 @@ func NewConnectUnarySessionInterceptor(s ISessionManager, p IPricingManager, a I
 					err.Error(),
 				)
 			}
-			return next(context.WithValue(ctx, KeySession, session), req)
+			ctx = WithChargeRecord(ctx)
+			RecordCharge(ctx, price)
+			resp, err := next(context.WithValue(ctx, KeySession, session), req)
+			if err != nil {
+				return nil, err
+			}
+			responsePrice, err := p.GetResponsePrice(req, resp)
+			if err != nil {
+				return nil, status.Errorf(
+					codes.Internal,
+					"failed to get response price: %s",
+					err.Error(),
+				)
+			}
+			if responsePrice > 0 {
+				if _, err := s.DeductSessionBalance(ctx, jwtClaims.Subject, responsePrice); err != nil {
+					return nil, status.Errorf(
+						codes.Internal,
+						"failed to deduct: %s",
+						err.Error(),
+					)
+				}
+				RecordCharge(ctx, responsePrice)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// VerifySessionToken checks that the jwt was issued by this server to manage
+// a session, whose id is the subject.
+func VerifySessionToken(a IAuthManager, token string) (*SessionJwtClaims, error) {
+	claims, err := a.VerifyAndParseJwt(token, &SessionJwtClaims{}, true)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.PermissionDenied,
+			"failed to parse or verify token: %s",
+			err.Error(),
+		)
+	}
+
+	jwtClaims, ok := claims.(*SessionJwtClaims)
+	if !ok {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"failed to parse session jwt",
+		)
+	}
+	if jwtClaims.Usage != pb.JwtUsage_JWT_USAGE_MANAGE_SESSION {
+		return nil, status.Errorf(
+			codes.PermissionDenied,
+			"expected token usage %d but got %d",
+			pb.JwtUsage_JWT_USAGE_MANAGE_SESSION,
+			jwtClaims.Usage,
+		)
+	}
+	return jwtClaims, nil
+}
+
+// AuthenticateSession verifies the session token in an Authorization header
+// value. It is shared by the Connect interceptor and plain HTTP endpoints.
+func AuthenticateSession(a IAuthManager, authString string) (*SessionJwtClaims, error) {
+	pieces := strings.Split(authString, " ")
+	if len(pieces) != 2 || !strings.EqualFold(pieces[0], "bearer") {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"failed to parse bearer token",
+		)
+	}
+	return VerifySessionToken(a, pieces[1])
+}
+
+// ensureSessionNotRevoked refuses the still unexpired tokens of revoked
+// sessions.
+func ensureSessionNotRevoked(ctx context.Context, s ISessionManager, sessionId string) error {
+	revoked, err := s.IsSessionRevoked(ctx, sessionId)
+	if err != nil {
+		return status.Errorf(
+			codes.Internal,
+			"failed to check session revocation: %s",
+			err.Error(),
+		)
+	}
+	if revoked {
+		return status.Error(
+			codes.PermissionDenied,
+			"session revoked",
+		)
+	}
+	return nil
+}
+
+type streamingSessionInterceptor struct {
+	sessionManager ISessionManager
+	authManager    IAuthManager
+}
+
+// NewConnectStreamingSessionInterceptor authenticates the session of
+// streaming calls and leaves it in the context under KeySession.
+// Unlike unary calls the price depends on the streamed data, so handlers
+// charge the session themselves.
+func NewConnectStreamingSessionInterceptor(s ISessionManager, a IAuthManager) connect.Interceptor {
+	return &streamingSessionInterceptor{
+		sessionManager: s,
+		authManager:    a,
+	}
+}
+
+func (i *streamingSessionInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return next
+}
+
+func (i *streamingSessionInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *streamingSessionInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		jwtClaims, err := AuthenticateSession(i.authManager, conn.RequestHeader().Get("Authorization"))
+		if err != nil {
+			return err
 		}
+		if err := ensureSessionNotRevoked(ctx, i.sessionManager, jwtClaims.Subject); err != nil {
+			return err
+		}
+		session := &pb.Session{SessionId: jwtClaims.Subject}
+		return next(context.WithValue(ctx, KeySession, session), conn)
+	}
+}
+
+// VerifyAccountToken checks that the jwt was issued by this server to
+// identify an account, whose id is the subject. Tokens with a usage, like
+// session or delegation tokens, do not identify accounts.
+func VerifyAccountToken(a IAuthManager, token string) (*SessionJwtClaims, error) {
+	claims, err := a.VerifyAndParseJwt(token, &SessionJwtClaims{}, true)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.PermissionDenied,
+			"failed to parse or verify account token: %s",
+			err.Error(),
+		)
+	}
+	jwtClaims, ok := claims.(*SessionJwtClaims)
+	if !ok {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"failed to parse account jwt",
+		)
+	}
+	if jwtClaims.Usage != pb.JwtUsage_JWT_USAGE_UNSPECIFIED {
+		return nil, status.Errorf(
+			codes.PermissionDenied,
+			"token of usage %d is not an account token",
+			jwtClaims.Usage,
+		)
+	}
+	if jwtClaims.Subject == "" {
+		return nil, status.Error(
+			codes.PermissionDenied,
+			"account token has no subject",
+		)
+	}
+	return jwtClaims, nil
+}
+
+type accountInterceptor struct {
+	authManager IAuthManager
+}
+
+// NewConnectAccountInterceptor verifies the account token sent in the
+// ACCOUNT_AUTHORIZATION_HEADER and leaves its claims in the context under
+// KeyAuthClaims. Calls without the header pass through, so that handlers
+// can fall back to tokens in request bodies.
+func NewConnectAccountInterceptor(a IAuthManager) connect.Interceptor {
+	return &accountInterceptor{authManager: a}
+}
+
+func (i *accountInterceptor) authenticate(ctx context.Context, header http.Header) (context.Context, error) {
+	if len(header.Values(ACCOUNT_AUTHORIZATION_HEADER)) == 0 {
+		return ctx, nil
+	}
+	token, err := TokenFromHeader(header, ACCOUNT_AUTHORIZATION_HEADER)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := VerifyAccountToken(i.authManager, token)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, KeyAuthClaims, claims), nil
+}
+
+func (i *accountInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := i.authenticate(ctx, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *accountInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *accountInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.authenticate(ctx, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
 	}
 }
 
@@ -119,3 +320,44 @@ func NewConnectValidationInterceptor(v protovalidate.Validator) connect.UnaryInt
 	}
 	return connect.UnaryInterceptorFunc(interceptor)
 }
+
+type validatingHandlerConn struct {
+	connect.StreamingHandlerConn
+	validator protovalidate.Validator
+}
+
+func (c *validatingHandlerConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+	return protoValidation(msg, c.validator)
+}
+
+type streamingValidationInterceptor struct {
+	validator protovalidate.Validator
+}
+
+// NewConnectStreamingValidationInterceptor validates every message received
+// by streaming handlers.
+func NewConnectStreamingValidationInterceptor(v protovalidate.Validator) connect.Interceptor {
+	return &streamingValidationInterceptor{
+		validator: v,
+	}
+}
+
+func (i *streamingValidationInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return next
+}
+
+func (i *streamingValidationInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *streamingValidationInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ctx, &validatingHandlerConn{
+			StreamingHandlerConn: conn,
+			validator:            i.validator,
+		})
+	}
+}
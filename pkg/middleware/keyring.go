@@ -0,0 +1,364 @@
+package middleware
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	SESSION_SIGNING_ALGORITHM_HS256 = "HS256"
+	// Session tokens signed with Ed25519 can be verified by other services
+	// from the JWKS of the node without sharing its secret
+	SESSION_SIGNING_ALGORITHM_EDDSA = "EdDSA"
+)
+
+const (
+	// keyringRefreshInterval is how long nodes sharing a keyring may keep
+	// signing with a key after another one rotated it.
+	keyringRefreshInterval = 10 * time.Second
+	// keyringMinRefreshInterval bounds reloads caused by tokens naming keys
+	// not loaded yet.
+	keyringMinRefreshInterval = time.Second
+)
+
+// SessionKey signs the session tokens of one generation of a keyring.
+type SessionKey struct {
+	Kid             string
+	Generation      int64
+	Method          jwt.SigningMethod
+	SigningKey      any
+	VerificationKey any
+}
+
+// SessionKeyMaker builds the key of a generation from its secret material,
+// an HMAC secret or an Ed25519 seed of SessionKeyMaterialSize bytes.
+type SessionKeyMaker func(generation int64, material []byte) (*SessionKey, error)
+
+// SessionKeyMaterialSize is the size of the random material of the keys
+// rotated in.
+const SessionKeyMaterialSize = 32
+
+// SessionKeyring signs session tokens with the key of its latest generation
+// and verifies them with the keys of previous generations too, until the
+// grace period after they were rotated out has passed. Generation 0 is made
+// from fixed material and active until the first rotation. Later generations
+// are random, stored sealed in a hash next to the activation times so that
+// the nodes sharing the keyring use the same keys.
+type SessionKeyring struct {
+	redisClient *redis.Client
+	redisKey    string
+	makeKey     SessionKeyMaker
+	initial     []byte
+	sealer      cipher.AEAD
+	gracePeriod time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	current  *SessionKey
+	keys     map[string]*SessionKey
+	// Keys made before, by their sealed material
+	made    map[string]*SessionKey
+	initKey *SessionKey
+}
+
+// NewSessionKeyring returns a keyring stored at redisKey. Generation 0 is
+// made from initialMaterial, the material of later generations is sealed with
+// sealKey, an AES-256 key.
+func NewSessionKeyring(
+	redisClient *redis.Client,
+	redisKey string,
+	makeKey SessionKeyMaker,
+	initialMaterial []byte,
+	sealKey []byte,
+	gracePeriod time.Duration,
+) (*SessionKeyring, error) {
+	block, err := aes.NewCipher(sealKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring seal key: %v", err)
+	}
+	sealer, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring seal key: %v", err)
+	}
+	return &SessionKeyring{
+		redisClient: redisClient,
+		redisKey:    redisKey,
+		makeKey:     makeKey,
+		initial:     initialMaterial,
+		sealer:      sealer,
+		gracePeriod: gracePeriod,
+		made:        make(map[string]*SessionKey),
+	}, nil
+}
+
+// materialKey is the hash holding the sealed material of every generation
+// rotated in.
+func (k *SessionKeyring) materialKey() string {
+	return k.redisKey + ":material"
+}
+
+// seal encrypts material bound to the keyring, so that it cannot be moved to
+// another one.
+func (k *SessionKeyring) seal(material []byte) ([]byte, error) {
+	nonce := make([]byte, k.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.sealer.Seal(nonce, nonce, material, []byte(k.redisKey)), nil
+}
+
+func (k *SessionKeyring) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < k.sealer.NonceSize() {
+		return nil, fmt.Errorf("sealed key material too short")
+	}
+	nonce, ciphertext := sealed[:k.sealer.NonceSize()], sealed[k.sealer.NonceSize():]
+	return k.sealer.Open(nil, nonce, ciphertext, []byte(k.redisKey))
+}
+
+// keyOf returns the key of a generation, opening its sealed material unless
+// it is generation 0.
+func (k *SessionKeyring) keyOf(generation int64, sealed string) (*SessionKey, error) {
+	if generation == 0 {
+		if k.initKey == nil {
+			key, err := k.makeKey(0, k.initial)
+			if err != nil {
+				return nil, fmt.Errorf("failed to make key of generation 0: %v", err)
+			}
+			k.initKey = key
+		}
+		return k.initKey, nil
+	}
+	if key, ok := k.made[sealed]; ok {
+		return key, nil
+	}
+	material, err := k.open([]byte(sealed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key material of generation %d: %v", generation, err)
+	}
+	key, err := k.makeKey(generation, material)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make key of generation %d: %v", generation, err)
+	}
+	k.made[sealed] = key
+	return key, nil
+}
+
+// load reads the activation times of the generations, must hold mu.
+func (k *SessionKeyring) load(ctx context.Context) error {
+	pipe := k.redisClient.Pipeline()
+	entriesCmd := pipe.ZRangeWithScores(ctx, k.redisKey, 0, -1)
+	materialCmd := pipe.HGetAll(ctx, k.materialKey())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to load keyring: %v", err)
+	}
+	entries := entriesCmd.Val()
+	material := materialCmd.Val()
+	type activation struct {
+		generation  int64
+		activatedAt int64
+	}
+	activations := []activation{{generation: 0, activatedAt: 0}}
+	for _, entry := range entries {
+		generation, err := strconv.ParseInt(entry.Member.(string), 10, 64)
+		if err != nil || generation <= 0 {
+			return fmt.Errorf("invalid keyring generation %v", entry.Member)
+		}
+		activations = append(activations, activation{generation: generation, activatedAt: int64(entry.Score)})
+	}
+
+	now := time.Now()
+	keys := make(map[string]*SessionKey, len(activations))
+	var current *SessionKey
+	for i, a := range activations {
+		if i+1 < len(activations) &&
+			now.Sub(time.UnixMilli(activations[i+1].activatedAt)) >= k.gracePeriod {
+			continue
+		}
+		sealed, found := material[strconv.FormatInt(a.generation, 10)]
+		if a.generation > 0 && !found {
+			// Rotated in before keys were stored, rotating again replaces it
+			continue
+		}
+		key, err := k.keyOf(a.generation, sealed)
+		if err != nil {
+			return err
+		}
+		keys[key.Kid] = key
+		current = key
+	}
+	if current == nil {
+		return fmt.Errorf("no usable session key, the keyring needs rotating")
+	}
+	k.current = current
+	k.keys = keys
+	k.loadedAt = now
+	return nil
+}
+
+// ensureLoaded reloads the keyring when stale, must hold mu. Keys loaded
+// before are kept if Redis is unreachable.
+func (k *SessionKeyring) ensureLoaded(ctx context.Context) error {
+	if k.current != nil && time.Since(k.loadedAt) < keyringRefreshInterval {
+		return nil
+	}
+	if err := k.load(ctx); err != nil && k.current == nil {
+		return err
+	}
+	return nil
+}
+
+// Current returns the key new session tokens are signed with.
+func (k *SessionKeyring) Current(ctx context.Context) (*SessionKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	return k.current, nil
+}
+
+func (k *SessionKeyring) lookup(ctx context.Context, kid string) (*SessionKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	if kid == "" {
+		// Tokens issued before key ids named generations, like the account
+		// tokens of genjwt, were signed by generation 0
+		for _, key := range k.keys {
+			if key.Generation == 0 {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("tokens without key id are retired")
+	}
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(k.loadedAt) >= keyringMinRefreshInterval {
+		// Another node sharing the keyring may have rotated it
+		if err := k.load(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := k.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown or retired key %s", kid)
+}
+
+// Sign signs claims with the current key, naming it in the kid header.
+func (k *SessionKeyring) Sign(ctx context.Context, claims jwt.Claims) (string, error) {
+	key, err := k.Current(ctx)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.SigningKey)
+}
+
+// KeyFunc verifies tokens signed by a key of the keyring with the algorithm
+// of that key.
+func (k *SessionKeyring) KeyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := k.lookup(context.Background(), kid)
+	if err != nil {
+		return nil, status.Errorf(
+			codes.Unauthenticated,
+			"failed to find signing key: %v",
+			err,
+		)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, status.Errorf(
+			codes.Unauthenticated,
+			"invalid signing method %s",
+			token.Method.Alg(),
+		)
+	}
+	return key.VerificationKey, nil
+}
+
+// rotateScript stores the sealed material of the generation after the latest
+// one and activates it.
+var rotateScript = redis.NewScript(`
+local latest = redis.call("ZREVRANGE", KEYS[1], 0, 0)
+local generation = 1
+if #latest > 0 then
+	generation = tonumber(latest[1]) + 1
+end
+redis.call("HSET", KEYS[2], generation, ARGV[2])
+redis.call("ZADD", KEYS[1], ARGV[1], generation)
+return generation
+`)
+
+// Rotate activates a new generation with a random key for every node sharing
+// the keyring. Tokens signed by the previous one keep verifying for the grace
+// period.
+func (k *SessionKeyring) Rotate(ctx context.Context) (*SessionKey, error) {
+	material := make([]byte, SessionKeyMaterialSize)
+	if _, err := rand.Read(material); err != nil {
+		return nil, fmt.Errorf("failed to generate key material: %v", err)
+	}
+	sealed, err := k.seal(material)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal key material: %v", err)
+	}
+	generation, err := rotateScript.Run(
+		ctx, k.redisClient, []string{k.redisKey, k.materialKey()}, time.Now().UnixMilli(), sealed,
+	).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate keyring: %v", err)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.load(ctx); err != nil {
+		return nil, err
+	}
+	if k.current.Generation != generation {
+		return nil, fmt.Errorf("rotated to generation %d but %d is current", generation, k.current.Generation)
+	}
+	return k.current, nil
+}
+
+// JWKS returns the Ed25519 keys tokens are verified with as a JSON web key
+// set, for services verifying session tokens without the secret of the node.
+func (k *SessionKeyring) JWKS(ctx context.Context) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{Keys: make([]jsonWebKey, 0, len(k.keys))}
+	for _, key := range k.keys {
+		publicKey, ok := key.VerificationKey.(ed25519.PublicKey)
+		if !ok {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jsonWebKey{
+			Kid: key.Kid,
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		})
+	}
+	return json.Marshal(jwks)
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"connectrpc.com/connect"
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
@@ -10,9 +12,25 @@ import (
 
 type IPricingManager interface {
 	GetPrice(req connect.AnyRequest) (int64, error)
+	// Streaming calls, and values priced by their stored size, are charged
+	// by the handler once the size is known
+	GetStreamPrice(procedure string, size int64) (int64, error)
+	// Charged on top of GetPrice once the response is known, for calls
+	// whose price depends on what is returned
+	GetResponsePrice(req connect.AnyRequest, resp connect.AnyResponse) (int64, error)
+	// Price of keeping size bytes stored for ttl longer
+	GetStoragePrice(size int64, ttl time.Duration) (int64, error)
 }
 
+const (
+	PRICING_SIZE_BASIS_LOGICAL = "logical"
+	PRICING_SIZE_BASIS_STORED  = "stored"
+)
+
 type PricingManager struct {
+	// Values are charged by their size as uploaded, or as stored after
+	// compression which is only known once the value is stored
+	SizeBasis string
 }
 
 // TODO: protect against too large values eating memory
@@ -30,6 +48,9 @@ func (p *PricingManager) GetPrice(req connect.AnyRequest) (int64, error) {
 	case pbconnect.KvStoreServiceCreateValueProcedure:
 		if r, ok := req.Any().(*pb.CreateValueRequest); !ok {
 			return 0, fmt.Errorf("failed to parse request")
+		} else if p.SizeBasis == PRICING_SIZE_BASIS_STORED {
+			// Charged by the handler once compressed, before it is written
+			return 1, nil
 		} else {
 			// TODO: use reader for large objects
 			return int64(BYTE_PRICE * len(r.GetValue())), nil
@@ -46,7 +67,76 @@ func (p *PricingManager) GetPrice(req connect.AnyRequest) (int64, error) {
 		} else {
 			return int64(STREAM_BYTE_PRICE) * int64(len(r.GetValue())), nil
 		}
+	case pbconnect.KvStoreServiceChallengeValueProcedure:
+		// Audits are charged per segment or chunk rather than by the size of
+		// the value, returned chunks are charged by GetResponsePrice
+		CHALLENGE_PRICE := 1
+		if r, ok := req.Any().(*pb.ChallengeValueRequest); !ok {
+			return 0, fmt.Errorf("failed to parse request")
+		} else {
+			return int64(CHALLENGE_PRICE * max(1, len(r.GetOffsets())+len(r.GetChunkIndices()))), nil
+		}
+	default:
+		return 1, nil
+	}
+}
+
+func (p *PricingManager) GetResponsePrice(req connect.AnyRequest, resp connect.AnyResponse) (int64, error) {
+	BYTE_PRICE := 1
+	switch req.Spec().Procedure {
+	case pbconnect.KvStoreServiceBatchGetValuesProcedure:
+		if r, ok := resp.Any().(*pb.BatchGetValuesResponse); !ok {
+			return 0, fmt.Errorf("failed to parse response")
+		} else {
+			size := 0
+			for _, result := range r.GetResults() {
+				size += len(result.GetValue())
+			}
+			return int64(BYTE_PRICE * size), nil
+		}
+	case pbconnect.KvStoreServiceChallengeValueProcedure:
+		if r, ok := resp.Any().(*pb.ChallengeValueResponse); !ok {
+			return 0, fmt.Errorf("failed to parse response")
+		} else {
+			size := 0
+			for _, proof := range r.GetChunkProofs() {
+				size += len(proof.GetChunk())
+			}
+			return int64(BYTE_PRICE * size), nil
+		}
+	default:
+		return 0, nil
+	}
+}
+
+func (p *PricingManager) GetStreamPrice(procedure string, size int64) (int64, error) {
+	BYTE_PRICE := 1
+	switch procedure {
+	case pbconnect.KvStoreServiceImportCarProcedure:
+		return int64(BYTE_PRICE) * size, nil
+	case pbconnect.KvStoreServiceCreateValueProcedure:
+		// Only with the stored size basis, size is the compressed size
+		return int64(BYTE_PRICE) * size, nil
+	case pbconnect.KvStoreServiceExportCarProcedure:
+		// Charged for every block sent, even empty ones
+		return int64(BYTE_PRICE) * max(size, 1), nil
 	default:
 		return 1, nil
 	}
 }
+
+// GetStoragePrice charges by byte-seconds, one BYTE_PRICE per byte-day
+// rounded up.
+func (p *PricingManager) GetStoragePrice(size int64, ttl time.Duration) (int64, error) {
+	BYTE_PRICE := int64(1)
+	BYTE_DAY_SECONDS := int64(24 * 60 * 60)
+	seconds := int64(ttl.Seconds())
+	if size < 0 || seconds < 0 {
+		return 0, fmt.Errorf("size and ttl must not be negative")
+	}
+	if seconds > 0 && size > math.MaxInt64/seconds/BYTE_PRICE {
+		return 0, fmt.Errorf("%d bytes for %d seconds overflows the price", size, seconds)
+	}
+	byteSeconds := size * seconds
+	return BYTE_PRICE * ((byteSeconds + BYTE_DAY_SECONDS - 1) / BYTE_DAY_SECONDS), nil
+}
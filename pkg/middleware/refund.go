@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RefundVoucherClaims return the balance left in a closed session to the
+// exchange which issued its quota token. The token id is the one of the
+// quota token, so that an exchange redeems at most one voucher per token,
+// and the issuer is the did:key of the exchange account of the node.
+type RefundVoucherClaims struct {
+	*SessionJwtClaims
+	Quantity int64 `json:"quantity"`
+}
+
+// SignRefundVoucher issues a voucher for refund signed by the exchange
+// account key of the node, identified as issuerDid.
+func SignRefundVoucher(
+	refund *pb.SessionRefund, issuerDid string, key ed25519.PrivateKey, ttl time.Duration,
+) (string, error) {
+	if refund.GetIssuer() == "" || refund.GetJti() == "" {
+		return "", fmt.Errorf("refund of session %s has no quota token to refund", refund.GetSessionId())
+	}
+	now := time.Now()
+	claims := RefundVoucherClaims{
+		SessionJwtClaims: &SessionJwtClaims{
+			RegisteredClaims: &jwt.RegisteredClaims{
+				Issuer:    issuerDid,
+				Audience:  jwt.ClaimStrings{refund.GetIssuer()},
+				Subject:   refund.GetSessionId(),
+				ID:        refund.GetJti(),
+				ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+			},
+			Usage: pb.JwtUsage_JWT_USAGE_REFUND,
+		},
+		Quantity: refund.GetBalance(),
+	}
+	voucher, err := jwt.NewWithClaims(&jwt.SigningMethodEd25519{}, claims).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign refund voucher: %v", err)
+	}
+	return voucher, nil
+}
+
+// VerifyRefundVoucher checks a voucher presented to the exchange identified
+// as audience. Vouchers are signed by the did:key they are issued by, which
+// must be one of the node accounts in trustedIssuers, and the exchange
+// credits the account of that did.
+func VerifyRefundVoucher(
+	voucher string, audience string, trustedIssuers []string, leeway time.Duration,
+) (*RefundVoucherClaims, error) {
+	keyFunc := func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, fmt.Errorf("invalid signing method %s", token.Method.Alg())
+		}
+		issuer, err := token.Claims.GetIssuer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get issuer: %v", err)
+		}
+		if !slices.Contains(trustedIssuers, issuer) {
+			return nil, fmt.Errorf("voucher issuer %s is not a trusted node account", issuer)
+		}
+		multibase, found := strings.CutPrefix(issuer, "did:key:")
+		if !found {
+			return nil, fmt.Errorf("voucher issuer %s is not a did:key", issuer)
+		}
+		return parseEd25519Multibase(multibase)
+	}
+	claims := &RefundVoucherClaims{}
+	_, err := jwt.ParseWithClaims(
+		voucher,
+		claims,
+		keyFunc,
+		jwt.WithAudience(audience),
+		jwt.WithLeeway(leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refund voucher: %v", err)
+	}
+	if claims.Usage != pb.JwtUsage_JWT_USAGE_REFUND {
+		return nil, fmt.Errorf(
+			"expected token usage %d but got %d", pb.JwtUsage_JWT_USAGE_REFUND, claims.Usage,
+		)
+	}
+	if claims.ID == "" || claims.Quantity <= 0 {
+		return nil, fmt.Errorf("voucher refunds nothing")
+	}
+	return claims, nil
+}
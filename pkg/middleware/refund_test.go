@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"time"
+
+	"github.com/atticplaygroup/pkv/pkg/middleware"
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Refund vouchers", func() {
+	const exchangeDid = "did:example:exchange"
+	var key ed25519.PrivateKey
+	var nodeDid string
+	var refund *pb.SessionRefund
+	BeforeEach(func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		key = privateKey
+		nodeDid = "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+		refund = &pb.SessionRefund{
+			SessionId: "session",
+			Issuer:    exchangeDid,
+			Jti:       uuid.NewString(),
+			Balance:   42,
+		}
+	})
+
+	It("should be redeemable at the exchange of the quota token only", func() {
+		voucher, err := middleware.SignRefundVoucher(refund, nodeDid, key, time.Hour)
+		Expect(err).To(BeNil())
+
+		claims, err := middleware.VerifyRefundVoucher(voucher, exchangeDid, []string{nodeDid}, 0)
+		Expect(err).To(BeNil())
+		Expect(claims.Issuer).To(Equal(nodeDid))
+		Expect(claims.ID).To(Equal(refund.GetJti()))
+		Expect(claims.Quantity).To(Equal(int64(42)))
+
+		_, err = middleware.VerifyRefundVoucher(voucher, "did:example:other-exchange", []string{nodeDid}, 0)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should refuse vouchers of untrusted node accounts", func() {
+		voucher, err := middleware.SignRefundVoucher(refund, nodeDid, key, time.Hour)
+		Expect(err).To(BeNil())
+		_, err = middleware.VerifyRefundVoucher(voucher, exchangeDid, []string{"did:example:other-node"}, 0)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should refuse vouchers not signed by their issuer", func() {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		voucher, err := middleware.SignRefundVoucher(refund, nodeDid, otherKey, time.Hour)
+		Expect(err).To(BeNil())
+		_, err = middleware.VerifyRefundVoucher(voucher, exchangeDid, []string{nodeDid}, 0)
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("should refuse expired vouchers", func() {
+		voucher, err := middleware.SignRefundVoucher(refund, nodeDid, key, -time.Minute)
+		Expect(err).To(BeNil())
+		_, err = middleware.VerifyRefundVoucher(voucher, exchangeDid, []string{nodeDid}, 0)
+		Expect(err).To(Not(BeNil()))
+	})
+})
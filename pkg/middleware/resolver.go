@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mr-tron/base58"
+)
+
+// maxKeyDocumentSize bounds did documents and JWKS fetched from exchanges.
+const maxKeyDocumentSize = 1 << 20
+
+// ExchangeKeys are the ed25519 verification keys of an exchange by key id.
+// Keys without id, like the one of a did:key, have an empty id.
+type ExchangeKeys map[string]ed25519.PublicKey
+
+type cachedExchangeKeys struct {
+	keys      ExchangeKeys
+	fetchedAt time.Time
+}
+
+// KeyResolver resolves the keys exchanges sign quota tokens with from their
+// did:key, did:web document or JWKS URL. Fetched keys are cached for
+// cacheTtl and refetched earlier when a token names an unknown key id, at
+// most once per minRefreshInterval, so that rotated keys are picked up.
+type KeyResolver struct {
+	httpClient         *http.Client
+	cacheTtl           time.Duration
+	minRefreshInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedExchangeKeys
+}
+
+func NewKeyResolver(
+	httpClient *http.Client, cacheTtl time.Duration, minRefreshInterval time.Duration,
+) *KeyResolver {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &KeyResolver{
+		httpClient:         httpClient,
+		cacheTtl:           cacheTtl,
+		minRefreshInterval: minRefreshInterval,
+		cache:              make(map[string]cachedExchangeKeys),
+	}
+}
+
+// ParseTrustedExchanges parses exchanges given as a DID, or as a DID and the
+// JWKS URL of its keys separated by "=".
+func ParseTrustedExchanges(specs []string) (map[string]string, error) {
+	ret := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		did, jwksUrl, _ := strings.Cut(spec, "=")
+		if !strings.HasPrefix(did, "did:") {
+			return nil, fmt.Errorf("invalid exchange did %s", did)
+		}
+		if jwksUrl == "" && !strings.HasPrefix(did, "did:key:") && !strings.HasPrefix(did, "did:web:") {
+			return nil, fmt.Errorf("exchange %s is neither did:key nor did:web and has no jwks url", did)
+		}
+		ret[did] = jwksUrl
+	}
+	return ret, nil
+}
+
+func parseEd25519Multibase(multibase string) (ed25519.PublicKey, error) {
+	base58Str, found := strings.CutPrefix(multibase, "z")
+	if !found {
+		return nil, fmt.Errorf("multibase %s is not base58btc", multibase)
+	}
+	decoded, err := base58.Decode(base58Str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %v", err)
+	}
+	if len(decoded) != 2+ed25519.PublicKeySize || decoded[0] != 0xed || decoded[1] != 0x01 {
+		return nil, fmt.Errorf("multibase %s is not an ed25519 public key", multibase)
+	}
+	return ed25519.PublicKey(decoded[2:]), nil
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+func (k *jsonWebKey) ed25519() (ed25519.PublicKey, error) {
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("key %s is %s %s instead of OKP Ed25519", k.Kid, k.Kty, k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("key %s has invalid x", k.Kid)
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+type didDocument struct {
+	Id                 string `json:"id"`
+	VerificationMethod []struct {
+		Id                 string      `json:"id"`
+		PublicKeyMultibase string      `json:"publicKeyMultibase"`
+		PublicKeyJwk       *jsonWebKey `json:"publicKeyJwk"`
+	} `json:"verificationMethod"`
+}
+
+// DidWebUrl returns the URL of the did document of a did:web.
+func DidWebUrl(did string) (string, error) {
+	specific, found := strings.CutPrefix(did, "did:web:")
+	if !found || specific == "" {
+		return "", fmt.Errorf("invalid did:web %s", did)
+	}
+	segments := strings.Split(specific, ":")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil || decoded == "" || strings.Contains(decoded, "/") {
+			return "", fmt.Errorf("invalid did:web %s", did)
+		}
+		segments[i] = decoded
+	}
+	if len(segments) == 1 {
+		return fmt.Sprintf("https://%s/.well-known/did.json", segments[0]), nil
+	}
+	return fmt.Sprintf("https://%s/did.json", strings.Join(segments, "/")), nil
+}
+
+func (r *KeyResolver) fetchJson(ctx context.Context, location string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request to %s: %v", location, err)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: status %d", location, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxKeyDocumentSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", location, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", location, err)
+	}
+	return nil
+}
+
+func (r *KeyResolver) fetchDidWebKeys(ctx context.Context, did string) (ExchangeKeys, error) {
+	location, err := DidWebUrl(did)
+	if err != nil {
+		return nil, err
+	}
+	var document didDocument
+	if err := r.fetchJson(ctx, location, &document); err != nil {
+		return nil, err
+	}
+	if document.Id != did {
+		return nil, fmt.Errorf("did document of %s has id %s", did, document.Id)
+	}
+	keys := make(ExchangeKeys)
+	for _, method := range document.VerificationMethod {
+		var publicKey ed25519.PublicKey
+		var err error
+		if method.PublicKeyMultibase != "" {
+			publicKey, err = parseEd25519Multibase(method.PublicKeyMultibase)
+		} else if method.PublicKeyJwk != nil {
+			publicKey, err = method.PublicKeyJwk.ed25519()
+		} else {
+			continue
+		}
+		if err != nil {
+			log.Printf("skipping verification method %s of %s: %v", method.Id, did, err)
+			continue
+		}
+		keys[method.Id] = publicKey
+	}
+	return keys, nil
+}
+
+func (r *KeyResolver) fetchJwksKeys(ctx context.Context, jwksUrl string) (ExchangeKeys, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := r.fetchJson(ctx, jwksUrl, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(ExchangeKeys)
+	for _, key := range jwks.Keys {
+		publicKey, err := key.ed25519()
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = publicKey
+	}
+	return keys, nil
+}
+
+func (r *KeyResolver) fetchKeys(ctx context.Context, did string, jwksUrl string) (ExchangeKeys, error) {
+	if jwksUrl != "" {
+		return r.fetchJwksKeys(ctx, jwksUrl)
+	}
+	if strings.HasPrefix(did, "did:web:") {
+		return r.fetchDidWebKeys(ctx, did)
+	}
+	return nil, fmt.Errorf("no way to resolve keys of %s", did)
+}
+
+// normalizeKid expands key ids relative to the did document of did, like
+// "#key-1", to the ids of its verification methods.
+func normalizeKid(did string, jwksUrl string, kid string) string {
+	if jwksUrl != "" || kid == "" || strings.HasPrefix(kid, "did:") {
+		return kid
+	}
+	return did + "#" + strings.TrimPrefix(kid, "#")
+}
+
+// Resolve returns the keys of an exchange able to verify a token with kid,
+// every key if kid is empty.
+func (r *KeyResolver) Resolve(
+	ctx context.Context, did string, jwksUrl string, kid string,
+) ([]ed25519.PublicKey, error) {
+	if jwksUrl == "" && strings.HasPrefix(did, "did:key:") {
+		publicKey, err := parseEd25519Multibase(strings.TrimPrefix(did, "did:key:"))
+		if err != nil {
+			return nil, err
+		}
+		return []ed25519.PublicKey{publicKey}, nil
+	}
+	kid = normalizeKid(did, jwksUrl, kid)
+
+	r.mu.Lock()
+	cached, found := r.cache[did]
+	r.mu.Unlock()
+	age := time.Since(cached.fetchedAt)
+	_, knownKid := cached.keys[kid]
+	if !found || age >= r.cacheTtl || (kid != "" && !knownKid && age >= r.minRefreshInterval) {
+		keys, err := r.fetchKeys(ctx, did, jwksUrl)
+		if err != nil && !found {
+			return nil, err
+		} else if err != nil {
+			// Stale keys are better than refusing every token while the
+			// exchange is unreachable
+			log.Printf("failed to refresh keys of %s: %v", did, err)
+		} else {
+			cached = cachedExchangeKeys{keys: keys, fetchedAt: time.Now()}
+			r.mu.Lock()
+			r.cache[did] = cached
+			r.mu.Unlock()
+		}
+	}
+
+	if kid != "" {
+		if publicKey, ok := cached.keys[kid]; ok {
+			return []ed25519.PublicKey{publicKey}, nil
+		}
+		return nil, fmt.Errorf("unknown key %s of %s", kid, did)
+	}
+	ret := make([]ed25519.PublicKey, 0, len(cached.keys))
+	for _, publicKey := range cached.keys {
+		ret = append(ret, publicKey)
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no ed25519 keys found for %s", did)
+	}
+	return ret, nil
+}
@@ -2,10 +2,10 @@ package middleware
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
 	"strconv"
 	"time"
 
@@ -14,61 +14,201 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type ISessionManager interface {
-	CreateSession(ctx context.Context, jti string, balance int64, ttl time.Duration) (*pb.Session, error)
+	CreateSession(ctx context.Context, issuer string, jti string, balance int64, ttl time.Duration) (*pb.Session, error)
 	DeductSessionBalance(ctx context.Context, sessionId string, amount int64) (*pb.Session, error)
+	GetSession(ctx context.Context, sessionId string) (*pb.Session, error)
+	// RevokeSession invalidates the tokens of a session and records its
+	// leftover balance for refund
+	RevokeSession(ctx context.Context, sessionId string) (*pb.SessionRefund, error)
+	IsSessionRevoked(ctx context.Context, sessionId string) (bool, error)
+	GetSessionRefund(ctx context.Context, sessionId string) (*pb.SessionRefund, error)
 }
 
+// ErrInsufficientBalance is returned when a session cannot pay a deduction.
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// refundRetention is how long the refund of a revoked session is kept for the
+// issuing exchange to settle it.
+const refundRetention = 30 * 24 * time.Hour
+
 type RedisSessionManager struct {
 	redisClient *redis.Client
 	sessionSalt []byte
 }
 
-func NewRedisSessionManager(redisClient *redis.Client) *RedisSessionManager {
-	sessionSalt := make([]byte, 32)
-	_, err := rand.Read(sessionSalt)
-	if err != nil {
-		log.Fatal("failed to generate random sessionSalt")
-	}
+// NewRedisSessionManager derives session ids from quota token issuers and ids
+// salted with sessionSalt. The salt must stay the same across restarts, like
+// one derived from the secret seed, so that a token redeemed again maps to the
+// session it already created.
+func NewRedisSessionManager(redisClient *redis.Client, sessionSalt []byte) *RedisSessionManager {
 	return &RedisSessionManager{
 		redisClient: redisClient,
 		sessionSalt: sessionSalt,
 	}
 }
 
-func (s *RedisSessionManager) hashToSessionId(jti string) string {
-	buf := append(s.sessionSalt, []byte(jti)...)
+// hashToSessionId scopes token ids by their issuer, since issuers pick jti
+// independently of each other.
+func (s *RedisSessionManager) hashToSessionId(issuer string, jti string) string {
+	buf := append(slices.Clone(s.sessionSalt), []byte(issuer)...)
+	buf = append(buf, 0)
+	buf = append(buf, []byte(jti)...)
 	hashed := sha256.Sum256(buf)
 	return base58.Encode(hashed[:])
 }
 
-func (s *RedisSessionManager) CreateSession(ctx context.Context, jti string, balance int64, ttl time.Duration) (*pb.Session, error) {
-	sessionId := s.hashToSessionId(jti)
-	session := &pb.Session{
-		SessionId: sessionId,
-		Balance:   balance,
+func sessionKey(sessionId string) string {
+	return fmt.Sprintf("session:%s", sessionId)
+}
+
+// spentJtiKey records the quota token behind a session once redeemed. Being
+// keyed by session id it is scoped to the issuer of the token too. It outlives
+// the session balance if that is removed and expires with the token.
+func spentJtiKey(sessionId string) string {
+	return fmt.Sprintf("session:spent:%s", sessionId)
+}
+
+// revokedSessionKey denylists the tokens of a revoked session until they
+// expire.
+func revokedSessionKey(sessionId string) string {
+	return fmt.Sprintf("session:revoked:%s", sessionId)
+}
+
+func sessionRefundKey(sessionId string) string {
+	return fmt.Sprintf("session:refund:%s", sessionId)
+}
+
+// createSessionScript marks the token spent and creates its session at once,
+// or returns the balance of the session the token already created, false if
+// that session is gone.
+var createSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	redis.call("HSET", KEYS[1], "issuer", ARGV[3], "jti", ARGV[4])
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	redis.call("SET", KEYS[2], ARGV[1], "PX", ARGV[2])
+	return ARGV[1]
+end
+return redis.call("GET", KEYS[2])
+`)
+
+func (s *RedisSessionManager) CreateSession(
+	ctx context.Context, issuer string, jti string, balance int64, ttl time.Duration,
+) (*pb.Session, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("session ttl must be positive but got %v", ttl)
 	}
-	redisKey := fmt.Sprintf("session:%s", sessionId)
+	sessionId := s.hashToSessionId(issuer, jti)
+	result, err := createSessionScript.Run(
+		ctx,
+		s.redisClient,
+		[]string{spentJtiKey(sessionId), sessionKey(sessionId)},
+		balance,
+		max(ttl.Milliseconds(), 1),
+		issuer,
+		jti,
+	).Text()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("quota token already redeemed")
+	} else if err != nil {
+		return nil, err
+	}
+	latestBalance, err := strconv.ParseInt(result, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse balance: %s", result)
+	}
+	return &pb.Session{
+		SessionId: sessionId,
+		Balance:   latestBalance,
+	}, nil
+}
+
+// revokeSessionScript removes the balance of a session, denylists it for as
+// long as the balance would have lived and records the refund. Sessions
+// already gone return false.
+var revokeSessionScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl == -2 then
+	return false
+elseif ttl == -1 then
+	ttl = tonumber(ARGV[2])
+end
+local balance = redis.call("GET", KEYS[1])
+redis.call("DEL", KEYS[1])
+redis.call("SET", KEYS[3], "1", "PX", ttl)
+local issuer = redis.call("HGET", KEYS[2], "issuer") or ""
+local jti = redis.call("HGET", KEYS[2], "jti") or ""
+redis.call("HSET", KEYS[4], "balance", balance, "issuer", issuer, "jti", jti, "revoked_at", ARGV[1])
+redis.call("PEXPIRE", KEYS[4], ARGV[2])
+return {balance, issuer, jti}
+`)
 
-	result, err := s.redisClient.Do(
+func (s *RedisSessionManager) RevokeSession(ctx context.Context, sessionId string) (*pb.SessionRefund, error) {
+	now := time.Now()
+	result, err := revokeSessionScript.Run(
 		ctx,
-		"SET", redisKey, session.GetBalance(), "NX", "GET",
-	).Result()
+		s.redisClient,
+		[]string{
+			sessionKey(sessionId),
+			spentJtiKey(sessionId),
+			revokedSessionKey(sessionId),
+			sessionRefundKey(sessionId),
+		},
+		now.UnixMilli(),
+		refundRetention.Milliseconds(),
+	).StringSlice()
 	if err == redis.Nil {
-		return session, nil
+		return nil, fmt.Errorf("session not found or expired")
+	} else if err != nil {
+		return nil, err
+	}
+	balance, err := strconv.ParseInt(result[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse balance: %s", result[0])
+	}
+	return &pb.SessionRefund{
+		SessionId:  sessionId,
+		Issuer:     result[1],
+		Jti:        result[2],
+		Balance:    max(balance, 0),
+		RevokeTime: timestamppb.New(now),
+	}, nil
+}
+
+func (s *RedisSessionManager) IsSessionRevoked(ctx context.Context, sessionId string) (bool, error) {
+	count, err := s.redisClient.Exists(ctx, revokedSessionKey(sessionId)).Result()
+	if err != nil {
+		return false, err
 	}
+	return count > 0, nil
+}
+
+// GetSessionRefund returns the refund recorded when a session was revoked.
+func (s *RedisSessionManager) GetSessionRefund(ctx context.Context, sessionId string) (*pb.SessionRefund, error) {
+	fields, err := s.redisClient.HGetAll(ctx, sessionRefundKey(sessionId)).Result()
 	if err != nil {
 		return nil, err
 	}
-	latestBalance, err := strconv.Atoi(result.(string))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no refund recorded for session %s", sessionId)
+	}
+	balance, err := strconv.ParseInt(fields["balance"], 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse balance: %s", result)
+		return nil, fmt.Errorf("failed to parse balance: %s", fields["balance"])
 	}
-	return &pb.Session{
-		SessionId: session.GetSessionId(),
-		Balance:   int64(latestBalance),
+	revokedAt, err := strconv.ParseInt(fields["revoked_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revoke time: %s", fields["revoked_at"])
+	}
+	return &pb.SessionRefund{
+		SessionId:  sessionId,
+		Issuer:     fields["issuer"],
+		Jti:        fields["jti"],
+		Balance:    max(balance, 0),
+		RevokeTime: timestamppb.New(time.UnixMilli(revokedAt)),
 	}, nil
 }
 
@@ -82,22 +222,43 @@ type CreateSessionJwtClaims struct {
 	Quantity int64 `json:"quantity"`
 }
 
+// deductScript decrements the balance of existing sessions only, so that
+// charging a revoked or expired session does not recreate it.
+var deductScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+return redis.call("DECRBY", KEYS[1], ARGV[1])
+`)
+
 func (s *RedisSessionManager) DeductSessionBalance(ctx context.Context, sessionId string, amount int64) (*pb.Session, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("amount must be positive but got %d", amount)
 	}
-	redisKey := fmt.Sprintf("session:%s", sessionId)
-	newBalance, err := s.redisClient.DecrBy(ctx, redisKey, amount).Result()
+	newBalance, err := deductScript.Run(ctx, s.redisClient, []string{sessionKey(sessionId)}, amount).Int64()
 	if err == redis.Nil {
 		return nil, fmt.Errorf("session not found or expired")
 	} else if err != nil {
 		return nil, err
 	}
 	if newBalance < 0 {
-		return nil, fmt.Errorf("insufficient balance: %d", newBalance)
+		return nil, fmt.Errorf("%w: %d", ErrInsufficientBalance, newBalance)
 	}
 	return &pb.Session{
 		Balance:   newBalance,
 		SessionId: sessionId,
 	}, nil
 }
+
+func (s *RedisSessionManager) GetSession(ctx context.Context, sessionId string) (*pb.Session, error) {
+	balance, err := s.redisClient.Get(ctx, sessionKey(sessionId)).Int64()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("session not found or expired")
+	} else if err != nil {
+		return nil, err
+	}
+	return &pb.Session{
+		Balance:   balance,
+		SessionId: sessionId,
+	}, nil
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 
@@ -15,12 +16,43 @@ import (
 type CtxKeyType int
 
 const (
+	// Verified claims of the account token of a call, see
+	// NewConnectAccountInterceptor
 	KeyAuthClaims CtxKeyType = iota
 	KeySession
+	// Running total of what a call was charged, see RecordCharge
+	KeyCharged
 )
 
+// WithChargeRecord returns a context in which RecordCharge totals the
+// charges of a call.
+func WithChargeRecord(ctx context.Context) context.Context {
+	return context.WithValue(ctx, KeyCharged, new(int64))
+}
+
+// RecordCharge adds price to the total charged for the call of ctx, if any
+// is being recorded.
+func RecordCharge(ctx context.Context, price int64) {
+	if charged, ok := ctx.Value(KeyCharged).(*int64); ok {
+		*charged += price
+	}
+}
+
+// ChargedPrice is the total charged so far for the call of ctx.
+func ChargedPrice(ctx context.Context) int64 {
+	if charged, ok := ctx.Value(KeyCharged).(*int64); ok {
+		return *charged
+	}
+	return 0
+}
+
+// ACCOUNT_AUTHORIZATION_HEADER carries the account identity token beside the
+// session token in the Authorization header.
+const ACCOUNT_AUTHORIZATION_HEADER = "X-Account-Authorization"
+
+// Segments may be DIDs like did:web:example.com%3A8443
 func parseResourceName(name string, prefixes []string) ([]string, error) {
-	pattern := strings.Join(prefixes, `/([0-9a-zA-Z\-@\.]+)/`) + `/([0-9a-zA-Z\-@\.]+)`
+	pattern := strings.Join(prefixes, `/([0-9a-zA-Z\-@\.:%]+)/`) + `/([0-9a-zA-Z\-@\.:%]+)`
 	r := regexp.MustCompile(pattern)
 	matches := r.FindStringSubmatch(name)
 
@@ -50,10 +82,8 @@ func ParseResourceName(name string, fields []string) ([]string, error) {
 	return idSegments, nil
 }
 
-// Adapted from grpcauth.AuthFromMD but allows other than authorization header
-func TokenFromMD(ctx context.Context, headerField string) (string, error) {
+func bearerToken(vals []string) (string, error) {
 	expectedScheme := "bearer"
-	vals := metadata.ValueFromIncomingContext(ctx, headerField)
 	if len(vals) == 0 {
 		return "", status.Error(codes.Unauthenticated, "Request unauthenticated with "+expectedScheme)
 	}
@@ -67,6 +97,17 @@ func TokenFromMD(ctx context.Context, headerField string) (string, error) {
 	return token, nil
 }
 
+// Adapted from grpcauth.AuthFromMD but allows other than authorization header
+func TokenFromMD(ctx context.Context, headerField string) (string, error) {
+	return bearerToken(metadata.ValueFromIncomingContext(ctx, headerField))
+}
+
+// TokenFromHeader is TokenFromMD for the headers of Connect and plain HTTP
+// requests.
+func TokenFromHeader(header http.Header, headerField string) (string, error) {
+	return bearerToken(header.Values(headerField))
+}
+
 func ParseHeaderJwt(
 	ctx context.Context,
 	claims jwt.Claims,
@@ -75,6 +116,9 @@ func ParseHeaderJwt(
 	witness any,
 	headerField string,
 	withValdidation bool,
+	// Checked unless empty when validating claims
+	expectedAudience string,
+	expectedIssuer string,
 ) (interface{}, error) {
 	tokenString, err := TokenFromMD(ctx, headerField)
 	if err != nil {
@@ -86,8 +130,14 @@ func ParseHeaderJwt(
 	}
 	options := []jwt.ParserOption{}
 	if !withValdidation {
-		// TODO: add jwt.WithAudience and WithIssuer to check them
 		options = append(options, jwt.WithoutClaimsValidation())
+	} else {
+		if expectedAudience != "" {
+			options = append(options, jwt.WithAudience(expectedAudience))
+		}
+		if expectedIssuer != "" {
+			options = append(options, jwt.WithIssuer(expectedIssuer))
+		}
 	}
 	token, err := jwt.ParseWithClaims(
 		tokenString, claims, func(token *jwt.Token) (interface{}, error) {
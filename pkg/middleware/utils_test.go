@@ -1,6 +1,8 @@
 package middleware_test
 
 import (
+	"net/http"
+
 	"github.com/atticplaygroup/pkv/pkg/middleware"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -20,4 +22,32 @@ var _ = Describe("Test ParseResourceName", func() {
 		Expect(fields[1]).To(Equal("274d80f7-2ebb-4b68-9a13-44ee703aff38"))
 		Expect(fields[2]).To(Equal("398963a9-7d38-48bc-9657-1dada3eb7390"))
 	})
+
+	It("Should parse accounts named by DIDs", func() {
+		fields, err := middleware.ParseResourceName(
+			"accounts/did:web:example.com%3A8443/streams/inbox", []string{"accounts", "streams"},
+		)
+		Expect(err).To(BeNil())
+		Expect(fields).To(Equal([]string{"did:web:example.com%3A8443", "inbox"}))
+	})
+})
+
+var _ = Describe("Test TokenFromHeader", func() {
+	It("Should read the bearer token of the header", func() {
+		header := http.Header{}
+		header.Set(middleware.ACCOUNT_AUTHORIZATION_HEADER, "Bearer account-token")
+		token, err := middleware.TokenFromHeader(header, middleware.ACCOUNT_AUTHORIZATION_HEADER)
+		Expect(err).To(BeNil())
+		Expect(token).To(Equal("account-token"))
+	})
+
+	It("Should reject missing or malformed headers", func() {
+		_, err := middleware.TokenFromHeader(http.Header{}, middleware.ACCOUNT_AUTHORIZATION_HEADER)
+		Expect(err).To(Not(BeNil()))
+
+		header := http.Header{}
+		header.Set(middleware.ACCOUNT_AUTHORIZATION_HEADER, "Basic a")
+		_, err = middleware.TokenFromHeader(header, middleware.ACCOUNT_AUTHORIZATION_HEADER)
+		Expect(err).To(Not(BeNil()))
+	})
 })
@@ -126,13 +126,64 @@ func (CoinEnvironment) EnumDescriptor() ([]byte, []int) {
 	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{1}
 }
 
+type StreamOperation int32
+
+const (
+	StreamOperation_STREAM_OPERATION_UNSPECIFIED StreamOperation = 0
+	StreamOperation_STREAM_OPERATION_GET         StreamOperation = 1
+	StreamOperation_STREAM_OPERATION_LIST        StreamOperation = 2
+)
+
+// Enum value maps for StreamOperation.
+var (
+	StreamOperation_name = map[int32]string{
+		0: "STREAM_OPERATION_UNSPECIFIED",
+		1: "STREAM_OPERATION_GET",
+		2: "STREAM_OPERATION_LIST",
+	}
+	StreamOperation_value = map[string]int32{
+		"STREAM_OPERATION_UNSPECIFIED": 0,
+		"STREAM_OPERATION_GET":         1,
+		"STREAM_OPERATION_LIST":        2,
+	}
+)
+
+func (x StreamOperation) Enum() *StreamOperation {
+	p := new(StreamOperation)
+	*p = x
+	return p
+}
+
+func (x StreamOperation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamOperation) Descriptor() protoreflect.EnumDescriptor {
+	return file_kvstore_v1_kvstore_proto_enumTypes[2].Descriptor()
+}
+
+func (StreamOperation) Type() protoreflect.EnumType {
+	return &file_kvstore_v1_kvstore_proto_enumTypes[2]
+}
+
+func (x StreamOperation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamOperation.Descriptor instead.
+func (StreamOperation) EnumDescriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{2}
+}
+
 // TODO: Use the same proto file
 type JwtUsage int32
 
 const (
-	JwtUsage_JWT_USAGE_UNSPECIFIED    JwtUsage = 0
-	JwtUsage_JWT_USAGE_CREATE_SESSION JwtUsage = 1
-	JwtUsage_JWT_USAGE_MANAGE_SESSION JwtUsage = 2
+	JwtUsage_JWT_USAGE_UNSPECIFIED     JwtUsage = 0
+	JwtUsage_JWT_USAGE_CREATE_SESSION  JwtUsage = 1
+	JwtUsage_JWT_USAGE_MANAGE_SESSION  JwtUsage = 2
+	JwtUsage_JWT_USAGE_REFUND          JwtUsage = 3
+	JwtUsage_JWT_USAGE_DELEGATE_STREAM JwtUsage = 4
 )
 
 // Enum value maps for JwtUsage.
@@ -141,11 +192,15 @@ var (
 		0: "JWT_USAGE_UNSPECIFIED",
 		1: "JWT_USAGE_CREATE_SESSION",
 		2: "JWT_USAGE_MANAGE_SESSION",
+		3: "JWT_USAGE_REFUND",
+		4: "JWT_USAGE_DELEGATE_STREAM",
 	}
 	JwtUsage_value = map[string]int32{
-		"JWT_USAGE_UNSPECIFIED":    0,
-		"JWT_USAGE_CREATE_SESSION": 1,
-		"JWT_USAGE_MANAGE_SESSION": 2,
+		"JWT_USAGE_UNSPECIFIED":     0,
+		"JWT_USAGE_CREATE_SESSION":  1,
+		"JWT_USAGE_MANAGE_SESSION":  2,
+		"JWT_USAGE_REFUND":          3,
+		"JWT_USAGE_DELEGATE_STREAM": 4,
 	}
 )
 
@@ -160,11 +215,11 @@ func (x JwtUsage) String() string {
 }
 
 func (JwtUsage) Descriptor() protoreflect.EnumDescriptor {
-	return file_kvstore_v1_kvstore_proto_enumTypes[2].Descriptor()
+	return file_kvstore_v1_kvstore_proto_enumTypes[3].Descriptor()
 }
 
 func (JwtUsage) Type() protoreflect.EnumType {
-	return &file_kvstore_v1_kvstore_proto_enumTypes[2]
+	return &file_kvstore_v1_kvstore_proto_enumTypes[3]
 }
 
 func (x JwtUsage) Number() protoreflect.EnumNumber {
@@ -173,7 +228,7 @@ func (x JwtUsage) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use JwtUsage.Descriptor instead.
 func (JwtUsage) EnumDescriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{2}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{3}
 }
 
 type CreateValueRequest_Codec int32
@@ -182,6 +237,8 @@ const (
 	CreateValueRequest_CODEC_UNSPECIFIED CreateValueRequest_Codec = 0
 	CreateValueRequest_CODEC_RAW         CreateValueRequest_Codec = 1
 	CreateValueRequest_CODEC_DAG_PB      CreateValueRequest_Codec = 2
+	CreateValueRequest_CODEC_DAG_CBOR    CreateValueRequest_Codec = 3
+	CreateValueRequest_CODEC_DAG_JSON    CreateValueRequest_Codec = 4
 )
 
 // Enum value maps for CreateValueRequest_Codec.
@@ -190,11 +247,15 @@ var (
 		0: "CODEC_UNSPECIFIED",
 		1: "CODEC_RAW",
 		2: "CODEC_DAG_PB",
+		3: "CODEC_DAG_CBOR",
+		4: "CODEC_DAG_JSON",
 	}
 	CreateValueRequest_Codec_value = map[string]int32{
 		"CODEC_UNSPECIFIED": 0,
 		"CODEC_RAW":         1,
 		"CODEC_DAG_PB":      2,
+		"CODEC_DAG_CBOR":    3,
+		"CODEC_DAG_JSON":    4,
 	}
 )
 
@@ -209,11 +270,11 @@ func (x CreateValueRequest_Codec) String() string {
 }
 
 func (CreateValueRequest_Codec) Descriptor() protoreflect.EnumDescriptor {
-	return file_kvstore_v1_kvstore_proto_enumTypes[3].Descriptor()
+	return file_kvstore_v1_kvstore_proto_enumTypes[4].Descriptor()
 }
 
 func (CreateValueRequest_Codec) Type() protoreflect.EnumType {
-	return &file_kvstore_v1_kvstore_proto_enumTypes[3]
+	return &file_kvstore_v1_kvstore_proto_enumTypes[4]
 }
 
 func (x CreateValueRequest_Codec) Number() protoreflect.EnumNumber {
@@ -225,29 +286,87 @@ func (CreateValueRequest_Codec) EnumDescriptor() ([]byte, []int) {
 	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{16, 0}
 }
 
-type ProviderResult struct {
+// Multihash function of the value cid, sha2-256 when unspecified
+type CreateValueRequest_HashFunction int32
+
+const (
+	CreateValueRequest_HASH_FUNCTION_UNSPECIFIED CreateValueRequest_HashFunction = 0
+	CreateValueRequest_HASH_FUNCTION_SHA2_256    CreateValueRequest_HashFunction = 1
+	CreateValueRequest_HASH_FUNCTION_SHA2_512    CreateValueRequest_HashFunction = 2
+	CreateValueRequest_HASH_FUNCTION_BLAKE2B_256 CreateValueRequest_HashFunction = 3
+	CreateValueRequest_HASH_FUNCTION_BLAKE3      CreateValueRequest_HashFunction = 4
+)
+
+// Enum value maps for CreateValueRequest_HashFunction.
+var (
+	CreateValueRequest_HashFunction_name = map[int32]string{
+		0: "HASH_FUNCTION_UNSPECIFIED",
+		1: "HASH_FUNCTION_SHA2_256",
+		2: "HASH_FUNCTION_SHA2_512",
+		3: "HASH_FUNCTION_BLAKE2B_256",
+		4: "HASH_FUNCTION_BLAKE3",
+	}
+	CreateValueRequest_HashFunction_value = map[string]int32{
+		"HASH_FUNCTION_UNSPECIFIED": 0,
+		"HASH_FUNCTION_SHA2_256":    1,
+		"HASH_FUNCTION_SHA2_512":    2,
+		"HASH_FUNCTION_BLAKE2B_256": 3,
+		"HASH_FUNCTION_BLAKE3":      4,
+	}
+)
+
+func (x CreateValueRequest_HashFunction) Enum() *CreateValueRequest_HashFunction {
+	p := new(CreateValueRequest_HashFunction)
+	*p = x
+	return p
+}
+
+func (x CreateValueRequest_HashFunction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CreateValueRequest_HashFunction) Descriptor() protoreflect.EnumDescriptor {
+	return file_kvstore_v1_kvstore_proto_enumTypes[5].Descriptor()
+}
+
+func (CreateValueRequest_HashFunction) Type() protoreflect.EnumType {
+	return &file_kvstore_v1_kvstore_proto_enumTypes[5]
+}
+
+func (x CreateValueRequest_HashFunction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CreateValueRequest_HashFunction.Descriptor instead.
+func (CreateValueRequest_HashFunction) EnumDescriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{16, 1}
+}
+
+// Peer schema record of the IPFS Delegated Routing V1 HTTP API
+type PeerRecord struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ContextId     string                 `protobuf:"bytes,1,opt,name=context_id,json=ContextID,proto3" json:"context_id,omitempty"`
-	Metadata      string                 `protobuf:"bytes,2,opt,name=metadata,json=Metadata,proto3" json:"metadata,omitempty"`
-	Provider      *Instance              `protobuf:"bytes,3,opt,name=provider,json=Provider,proto3" json:"provider,omitempty"`
+	Schema        string                 `protobuf:"bytes,1,opt,name=schema,json=Schema,proto3" json:"schema,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,json=ID,proto3" json:"id,omitempty"`
+	Addrs         []string               `protobuf:"bytes,3,rep,name=addrs,json=Addrs,proto3" json:"addrs,omitempty"`
+	Protocols     []string               `protobuf:"bytes,4,rep,name=protocols,json=Protocols,proto3" json:"protocols,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProviderResult) Reset() {
-	*x = ProviderResult{}
+func (x *PeerRecord) Reset() {
+	*x = PeerRecord{}
 	mi := &file_kvstore_v1_kvstore_proto_msgTypes[0]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProviderResult) String() string {
+func (x *PeerRecord) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProviderResult) ProtoMessage() {}
+func (*PeerRecord) ProtoMessage() {}
 
-func (x *ProviderResult) ProtoReflect() protoreflect.Message {
+func (x *PeerRecord) ProtoReflect() protoreflect.Message {
 	mi := &file_kvstore_v1_kvstore_proto_msgTypes[0]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -259,94 +378,49 @@ func (x *ProviderResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProviderResult.ProtoReflect.Descriptor instead.
-func (*ProviderResult) Descriptor() ([]byte, []int) {
+// Deprecated: Use PeerRecord.ProtoReflect.Descriptor instead.
+func (*PeerRecord) Descriptor() ([]byte, []int) {
 	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *ProviderResult) GetContextId() string {
+func (x *PeerRecord) GetSchema() string {
 	if x != nil {
-		return x.ContextId
+		return x.Schema
 	}
 	return ""
 }
 
-func (x *ProviderResult) GetMetadata() string {
+func (x *PeerRecord) GetId() string {
 	if x != nil {
-		return x.Metadata
+		return x.Id
 	}
 	return ""
 }
 
-func (x *ProviderResult) GetProvider() *Instance {
+func (x *PeerRecord) GetAddrs() []string {
 	if x != nil {
-		return x.Provider
+		return x.Addrs
 	}
 	return nil
 }
 
-type MultihashResult struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Multihash       string                 `protobuf:"bytes,1,opt,name=multihash,json=Multihash,proto3" json:"multihash,omitempty"`
-	ProviderResults []*ProviderResult      `protobuf:"bytes,2,rep,name=provider_results,json=ProviderResults,proto3" json:"provider_results,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
-}
-
-func (x *MultihashResult) Reset() {
-	*x = MultihashResult{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[1]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *MultihashResult) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*MultihashResult) ProtoMessage() {}
-
-func (x *MultihashResult) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[1]
-	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use MultihashResult.ProtoReflect.Descriptor instead.
-func (*MultihashResult) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{1}
-}
-
-func (x *MultihashResult) GetMultihash() string {
-	if x != nil {
-		return x.Multihash
-	}
-	return ""
-}
-
-func (x *MultihashResult) GetProviderResults() []*ProviderResult {
+func (x *PeerRecord) GetProtocols() []string {
 	if x != nil {
-		return x.ProviderResults
+		return x.Protocols
 	}
 	return nil
 }
 
 type DelegatedRoutingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Providers     []*Instance            `protobuf:"bytes,1,rep,name=providers,json=Providers,proto3" json:"providers,omitempty"` // repeated MultihashResult multihash_results = 1 [ json_name = "MultihashResults" ];
+	Providers     []*PeerRecord          `protobuf:"bytes,2,rep,name=providers,json=Providers,proto3" json:"providers,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DelegatedRoutingResponse) Reset() {
 	*x = DelegatedRoutingResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[2]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -358,7 +432,7 @@ func (x *DelegatedRoutingResponse) String() string {
 func (*DelegatedRoutingResponse) ProtoMessage() {}
 
 func (x *DelegatedRoutingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[2]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -371,10 +445,10 @@ func (x *DelegatedRoutingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DelegatedRoutingResponse.ProtoReflect.Descriptor instead.
 func (*DelegatedRoutingResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{2}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *DelegatedRoutingResponse) GetProviders() []*Instance {
+func (x *DelegatedRoutingResponse) GetProviders() []*PeerRecord {
 	if x != nil {
 		return x.Providers
 	}
@@ -390,7 +464,7 @@ type DelegatedRoutingRequest struct {
 
 func (x *DelegatedRoutingRequest) Reset() {
 	*x = DelegatedRoutingRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[3]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -402,7 +476,7 @@ func (x *DelegatedRoutingRequest) String() string {
 func (*DelegatedRoutingRequest) ProtoMessage() {}
 
 func (x *DelegatedRoutingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[3]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -415,7 +489,7 @@ func (x *DelegatedRoutingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DelegatedRoutingRequest.ProtoReflect.Descriptor instead.
 func (*DelegatedRoutingRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{3}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *DelegatedRoutingRequest) GetCid() string {
@@ -435,7 +509,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[4]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -447,7 +521,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[4]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -460,7 +534,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{4}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PingRequest) GetDummy() int64 {
@@ -479,7 +553,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[5]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -491,7 +565,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[5]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -504,7 +578,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{5}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *PingResponse) GetPong() string {
@@ -528,7 +602,7 @@ type GlobalLink struct {
 
 func (x *GlobalLink) Reset() {
 	*x = GlobalLink{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[6]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -540,7 +614,7 @@ func (x *GlobalLink) String() string {
 func (*GlobalLink) ProtoMessage() {}
 
 func (x *GlobalLink) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[6]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -553,7 +627,7 @@ func (x *GlobalLink) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GlobalLink.ProtoReflect.Descriptor instead.
 func (*GlobalLink) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{6}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GlobalLink) GetName() string {
@@ -608,7 +682,7 @@ type VirtualService struct {
 
 func (x *VirtualService) Reset() {
 	*x = VirtualService{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[7]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -620,7 +694,7 @@ func (x *VirtualService) String() string {
 func (*VirtualService) ProtoMessage() {}
 
 func (x *VirtualService) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[7]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -633,7 +707,7 @@ func (x *VirtualService) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VirtualService.ProtoReflect.Descriptor instead.
 func (*VirtualService) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{7}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *VirtualService) GetBehaviorLink() *GlobalLink {
@@ -665,13 +739,16 @@ type ProviderAdvertise struct {
 	Signature       string                 `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
 	// virtual service dependent extra information
 	PricingDetails string `protobuf:"bytes,11,opt,name=pricing_details,json=pricingDetails,proto3" json:"pricing_details,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// The i-th proof shows the i-th CID is a leaf of the variant root. Required
+	// by behaviors serving only part of a variant, like serve_subset.
+	InclusionProofs []*MerkleInclusionProof `protobuf:"bytes,12,rep,name=inclusion_proofs,json=inclusionProofs,proto3" json:"inclusion_proofs,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ProviderAdvertise) Reset() {
 	*x = ProviderAdvertise{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[8]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -683,7 +760,7 @@ func (x *ProviderAdvertise) String() string {
 func (*ProviderAdvertise) ProtoMessage() {}
 
 func (x *ProviderAdvertise) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[8]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -696,7 +773,7 @@ func (x *ProviderAdvertise) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProviderAdvertise.ProtoReflect.Descriptor instead.
 func (*ProviderAdvertise) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{8}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ProviderAdvertise) GetProviderInstance() *Instance {
@@ -776,6 +853,13 @@ func (x *ProviderAdvertise) GetPricingDetails() string {
 	return ""
 }
 
+func (x *ProviderAdvertise) GetInclusionProofs() []*MerkleInclusionProof {
+	if x != nil {
+		return x.InclusionProofs
+	}
+	return nil
+}
+
 type SearchCidRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Cid           string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
@@ -785,7 +869,7 @@ type SearchCidRequest struct {
 
 func (x *SearchCidRequest) Reset() {
 	*x = SearchCidRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[9]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -797,7 +881,7 @@ func (x *SearchCidRequest) String() string {
 func (*SearchCidRequest) ProtoMessage() {}
 
 func (x *SearchCidRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[9]
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -810,7 +894,7 @@ func (x *SearchCidRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchCidRequest.ProtoReflect.Descriptor instead.
 func (*SearchCidRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{9}
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SearchCidRequest) GetCid() string {
@@ -820,12 +904,80 @@ func (x *SearchCidRequest) GetCid() string {
 	return ""
 }
 
+// Proves a CID is a leaf of the keccak-256 merkle tree whose root is the
+// variant link name of a virtual service.
+type MerkleInclusionProof struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CID of the merkle root, same as the variant link name
+	Root      string `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	LeafIndex uint64 `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	// Sibling hashes from the leaf up to the root
+	Hashes        [][]byte `protobuf:"bytes,3,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MerkleInclusionProof) Reset() {
+	*x = MerkleInclusionProof{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MerkleInclusionProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MerkleInclusionProof) ProtoMessage() {}
+
+func (x *MerkleInclusionProof) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MerkleInclusionProof.ProtoReflect.Descriptor instead.
+func (*MerkleInclusionProof) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MerkleInclusionProof) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *MerkleInclusionProof) GetLeafIndex() uint64 {
+	if x != nil {
+		return x.LeafIndex
+	}
+	return 0
+}
+
+func (x *MerkleInclusionProof) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
 type SearchCidResponse struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	VirtualServices  []*VirtualService      `protobuf:"bytes,1,rep,name=virtual_services,json=virtualServices,proto3" json:"virtual_services,omitempty"`
-	StorageInstances []*ProviderAdvertise   `protobuf:"bytes,2,rep,name=storage_instances,json=storageInstances,proto3" json:"storage_instances,omitempty"` // repeated Instance index_instances = 3;
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	StorageInstances []*ProviderAdvertise   `protobuf:"bytes,2,rep,name=storage_instances,json=storageInstances,proto3" json:"storage_instances,omitempty"`
+	// repeated Instance index_instances = 3;
+	// The i-th proof is for the i-th virtual service. A proof is left empty
+	// when the index does not know the full CID set of the variant.
+	InclusionProofs []*MerkleInclusionProof `protobuf:"bytes,4,rep,name=inclusion_proofs,json=inclusionProofs,proto3" json:"inclusion_proofs,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *SearchCidResponse) Reset() {
@@ -872,6 +1024,13 @@ func (x *SearchCidResponse) GetStorageInstances() []*ProviderAdvertise {
 	return nil
 }
 
+func (x *SearchCidResponse) GetInclusionProofs() []*MerkleInclusionProof {
+	if x != nil {
+		return x.InclusionProofs
+	}
+	return nil
+}
+
 type SearchInstanceRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	VirtualService *VirtualService        `protobuf:"bytes,1,opt,name=virtual_service,json=virtualService,proto3" json:"virtual_service,omitempty"`
@@ -1052,8 +1211,11 @@ type Instance struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Did   string                 `protobuf:"bytes,1,opt,name=did,proto3" json:"did,omitempty"`
 	// libp2p peer id. Not essential for our app but needed to be compatible with helia
-	PeerId        string   `protobuf:"bytes,2,opt,name=peer_id,json=ID,proto3" json:"peer_id,omitempty"`
-	Multiaddrs    []string `protobuf:"bytes,3,rep,name=multiaddrs,json=Addrs,proto3" json:"multiaddrs,omitempty"`
+	PeerId     string   `protobuf:"bytes,2,opt,name=peer_id,json=ID,proto3" json:"peer_id,omitempty"`
+	Multiaddrs []string `protobuf:"bytes,3,rep,name=multiaddrs,json=Addrs,proto3" json:"multiaddrs,omitempty"`
+	// Transfer protocols served at the multiaddrs, like transport-ipfs-gateway-http.
+	// The pkv Connect service is assumed when empty.
+	Protocols     []string `protobuf:"bytes,4,rep,name=protocols,json=Protocols,proto3" json:"protocols,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1109,11 +1271,19 @@ func (x *Instance) GetMultiaddrs() []string {
 	return nil
 }
 
+func (x *Instance) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
 type CreateValueRequest struct {
-	state         protoimpl.MessageState   `protogen:"open.v1"`
-	Codec         CreateValueRequest_Codec `protobuf:"varint,1,opt,name=codec,proto3,enum=kvstore.v1.CreateValueRequest_Codec" json:"codec,omitempty"`
-	Value         []byte                   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	Ttl           *durationpb.Duration     `protobuf:"bytes,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	state         protoimpl.MessageState          `protogen:"open.v1"`
+	Codec         CreateValueRequest_Codec        `protobuf:"varint,1,opt,name=codec,proto3,enum=kvstore.v1.CreateValueRequest_Codec" json:"codec,omitempty"`
+	Value         []byte                          `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Ttl           *durationpb.Duration            `protobuf:"bytes,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	HashFunction  CreateValueRequest_HashFunction `protobuf:"varint,4,opt,name=hash_function,json=hashFunction,proto3,enum=kvstore.v1.CreateValueRequest_HashFunction" json:"hash_function,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1169,10 +1339,25 @@ func (x *CreateValueRequest) GetTtl() *durationpb.Duration {
 	return nil
 }
 
+func (x *CreateValueRequest) GetHashFunction() CreateValueRequest_HashFunction {
+	if x != nil {
+		return x.HashFunction
+	}
+	return CreateValueRequest_HASH_FUNCTION_UNSPECIFIED
+}
+
 type CreateValueResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ttl   *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Bytes taken by the value after compression
+	StoredSize int64 `protobuf:"varint,3,opt,name=stored_size,json=storedSize,proto3" json:"stored_size,omitempty"`
+	// Compression the value is stored with, like zstd, or none
+	Encoding string `protobuf:"bytes,4,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	// Keccak-256 merkle root over the chunks of the value, for later challenges.
+	// Chunks are hashed prefixed by 0x00 and branches by 0x01
+	ChunkRoot     []byte                `protobuf:"bytes,5,opt,name=chunk_root,json=chunkRoot,proto3" json:"chunk_root,omitempty"`
+	Receipt       *SignedStorageReceipt `protobuf:"bytes,6,opt,name=receipt,proto3" json:"receipt,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1221,6 +1406,34 @@ func (x *CreateValueResponse) GetTtl() *durationpb.Duration {
 	return nil
 }
 
+func (x *CreateValueResponse) GetStoredSize() int64 {
+	if x != nil {
+		return x.StoredSize
+	}
+	return 0
+}
+
+func (x *CreateValueResponse) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *CreateValueResponse) GetChunkRoot() []byte {
+	if x != nil {
+		return x.ChunkRoot
+	}
+	return nil
+}
+
+func (x *CreateValueResponse) GetReceipt() *SignedStorageReceipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
 type CreateStreamValueRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Parent        string                 `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
@@ -1326,11 +1539,15 @@ func (x *CreateStreamValueResponse) GetTtl() *durationpb.Duration {
 }
 
 type GetStreamValueRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Deprecated: send the account token in the X-Account-Authorization
+	// header instead, which is used when both are given
+	AuthToken string `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	// Grants the account of the call access when it does not own the stream
+	DelegationToken string `protobuf:"bytes,3,opt,name=delegation_token,json=delegationToken,proto3" json:"delegation_token,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *GetStreamValueRequest) Reset() {
@@ -1377,6 +1594,13 @@ func (x *GetStreamValueRequest) GetAuthToken() string {
 	return ""
 }
 
+func (x *GetStreamValueRequest) GetDelegationToken() string {
+	if x != nil {
+		return x.DelegationToken
+	}
+	return ""
+}
+
 type StreamValueInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
@@ -1474,13 +1698,17 @@ func (x *GetStreamValueResponse) GetStreamValueInfo() *StreamValueInfo {
 }
 
 type ListStreamValuesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Parent        string                 `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
-	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
-	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	PageToken     string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Parent string                 `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
+	// Deprecated: send the account token in the X-Account-Authorization
+	// header instead, which is used when both are given
+	AuthToken string `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	PageSize  int32  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Grants the account of the call access when it does not own the stream
+	DelegationToken string `protobuf:"bytes,5,opt,name=delegation_token,json=delegationToken,proto3" json:"delegation_token,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ListStreamValuesRequest) Reset() {
@@ -1541,29 +1769,1674 @@ func (x *ListStreamValuesRequest) GetPageToken() string {
 	return ""
 }
 
-type ListStreamValuesResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	StreamValueInfo []*StreamValueInfo     `protobuf:"bytes,1,rep,name=stream_value_info,json=streamValueInfo,proto3" json:"stream_value_info,omitempty"`
-	PageToken       string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+func (x *ListStreamValuesRequest) GetDelegationToken() string {
+	if x != nil {
+		return x.DelegationToken
+	}
+	return ""
+}
+
+type DelegateStreamAccessRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Parent string                 `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
+	// Deprecated: send the account token of the delegator, who owns the
+	// stream or holds proof, in the X-Account-Authorization header instead
+	AuthToken string `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	// Subject of the identity tokens the delegate will present
+	Delegate   string               `protobuf:"bytes,3,opt,name=delegate,proto3" json:"delegate,omitempty"`
+	Operations []StreamOperation    `protobuf:"varint,4,rep,packed,name=operations,proto3,enum=kvstore.v1.StreamOperation" json:"operations,omitempty"`
+	Ttl        *durationpb.Duration `protobuf:"bytes,5,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Delegation token of the delegator when it does not own the stream. The
+	// operations and expiry granted cannot exceed those of the proof.
+	Proof         string `protobuf:"bytes,6,opt,name=proof,proto3" json:"proof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DelegateStreamAccessRequest) Reset() {
+	*x = DelegateStreamAccessRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DelegateStreamAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelegateStreamAccessRequest) ProtoMessage() {}
+
+func (x *DelegateStreamAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelegateStreamAccessRequest.ProtoReflect.Descriptor instead.
+func (*DelegateStreamAccessRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DelegateStreamAccessRequest) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
+func (x *DelegateStreamAccessRequest) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+func (x *DelegateStreamAccessRequest) GetDelegate() string {
+	if x != nil {
+		return x.Delegate
+	}
+	return ""
+}
+
+func (x *DelegateStreamAccessRequest) GetOperations() []StreamOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *DelegateStreamAccessRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *DelegateStreamAccessRequest) GetProof() string {
+	if x != nil {
+		return x.Proof
+	}
+	return ""
+}
+
+type DelegateStreamAccessResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DelegationToken string                 `protobuf:"bytes,1,opt,name=delegation_token,json=delegationToken,proto3" json:"delegation_token,omitempty"`
+	ExpireTime      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DelegateStreamAccessResponse) Reset() {
+	*x = DelegateStreamAccessResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DelegateStreamAccessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelegateStreamAccessResponse) ProtoMessage() {}
+
+func (x *DelegateStreamAccessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelegateStreamAccessResponse.ProtoReflect.Descriptor instead.
+func (*DelegateStreamAccessResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DelegateStreamAccessResponse) GetDelegationToken() string {
+	if x != nil {
+		return x.DelegationToken
+	}
+	return ""
+}
+
+func (x *DelegateStreamAccessResponse) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+type ListStreamValuesResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	StreamValueInfo []*StreamValueInfo     `protobuf:"bytes,1,rep,name=stream_value_info,json=streamValueInfo,proto3" json:"stream_value_info,omitempty"`
+	PageToken       string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
 
-func (x *ListStreamValuesResponse) Reset() {
-	*x = ListStreamValuesResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[24]
+func (x *ListStreamValuesResponse) Reset() {
+	*x = ListStreamValuesResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStreamValuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStreamValuesResponse) ProtoMessage() {}
+
+func (x *ListStreamValuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStreamValuesResponse.ProtoReflect.Descriptor instead.
+func (*ListStreamValuesResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListStreamValuesResponse) GetStreamValueInfo() []*StreamValueInfo {
+	if x != nil {
+		return x.StreamValueInfo
+	}
+	return nil
+}
+
+func (x *ListStreamValuesResponse) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type GetValueRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Encodings the client can decode, like zstd or snappy. Values stored
+	// compressed with one of them are returned as stored.
+	AcceptedEncodings []string `protobuf:"bytes,2,rep,name=accepted_encodings,json=acceptedEncodings,proto3" json:"accepted_encodings,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetValueRequest) Reset() {
+	*x = GetValueRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetValueRequest) ProtoMessage() {}
+
+func (x *GetValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetValueRequest.ProtoReflect.Descriptor instead.
+func (*GetValueRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetValueRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetValueRequest) GetAcceptedEncodings() []string {
+	if x != nil {
+		return x.AcceptedEncodings
+	}
+	return nil
+}
+
+type GetValueResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// Encoding of value, empty when it is returned uncompressed
+	Encoding      string `protobuf:"bytes,2,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetValueResponse) Reset() {
+	*x = GetValueResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetValueResponse) ProtoMessage() {}
+
+func (x *GetValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetValueResponse.ProtoReflect.Descriptor instead.
+func (*GetValueResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetValueResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *GetValueResponse) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+type GetValueMetadataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetValueMetadataRequest) Reset() {
+	*x = GetValueMetadataRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetValueMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetValueMetadataRequest) ProtoMessage() {}
+
+func (x *GetValueMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetValueMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetValueMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetValueMetadataRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ValueMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Size of the value as uploaded, regardless of how it is stored
+	Size int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Multicodec code of the value cid, e.g. 0x55 for raw
+	Codec uint64 `protobuf:"varint,3,opt,name=codec,proto3" json:"codec,omitempty"`
+	// Unset for values which do not expire
+	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// Unset for values stored before creation times were recorded
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// Bytes taken by the value after compression
+	StoredSize int64 `protobuf:"varint,6,opt,name=stored_size,json=storedSize,proto3" json:"stored_size,omitempty"`
+	// Compression the value is stored with, like zstd, or none
+	Encoding string `protobuf:"bytes,7,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	// Keccak-256 merkle root over the chunks of the value recorded at upload,
+	// empty for values stored before roots were recorded
+	ChunkRoot     []byte `protobuf:"bytes,8,opt,name=chunk_root,json=chunkRoot,proto3" json:"chunk_root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueMetadata) Reset() {
+	*x = ValueMetadata{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueMetadata) ProtoMessage() {}
+
+func (x *ValueMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueMetadata.ProtoReflect.Descriptor instead.
+func (*ValueMetadata) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ValueMetadata) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ValueMetadata) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ValueMetadata) GetCodec() uint64 {
+	if x != nil {
+		return x.Codec
+	}
+	return 0
+}
+
+func (x *ValueMetadata) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+func (x *ValueMetadata) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *ValueMetadata) GetStoredSize() int64 {
+	if x != nil {
+		return x.StoredSize
+	}
+	return 0
+}
+
+func (x *ValueMetadata) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *ValueMetadata) GetChunkRoot() []byte {
+	if x != nil {
+		return x.ChunkRoot
+	}
+	return nil
+}
+
+// Failure of a single item of a batch, laid out like google.rpc.Status
+type ResultStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// google.rpc.Code, e.g. 5 for NOT_FOUND
+	Code          int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultStatus) Reset() {
+	*x = ResultStatus{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultStatus) ProtoMessage() {}
+
+func (x *ResultStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultStatus.ProtoReflect.Descriptor instead.
+func (*ResultStatus) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ResultStatus) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ResultStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type BatchGetValuesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetValuesRequest) Reset() {
+	*x = BatchGetValuesRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetValuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetValuesRequest) ProtoMessage() {}
+
+func (x *BatchGetValuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetValuesRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetValuesRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *BatchGetValuesRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type BatchGetValuesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// In the order of the requested names
+	Results       []*BatchGetValuesResponse_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetValuesResponse) Reset() {
+	*x = BatchGetValuesResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetValuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetValuesResponse) ProtoMessage() {}
+
+func (x *BatchGetValuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetValuesResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetValuesResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *BatchGetValuesResponse) GetResults() []*BatchGetValuesResponse_Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type StatValuesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatValuesRequest) Reset() {
+	*x = StatValuesRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatValuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatValuesRequest) ProtoMessage() {}
+
+func (x *StatValuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatValuesRequest.ProtoReflect.Descriptor instead.
+func (*StatValuesRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *StatValuesRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type ValueStat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Size of the value in bytes
+	Size int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Remaining time to live
+	Ttl *durationpb.Duration `protobuf:"bytes,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Multicodec code of the value cid, e.g. 0x55 for raw
+	Codec         uint64 `protobuf:"varint,4,opt,name=codec,proto3" json:"codec,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueStat) Reset() {
+	*x = ValueStat{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueStat) ProtoMessage() {}
+
+func (x *ValueStat) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueStat.ProtoReflect.Descriptor instead.
+func (*ValueStat) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ValueStat) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ValueStat) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ValueStat) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *ValueStat) GetCodec() uint64 {
+	if x != nil {
+		return x.Codec
+	}
+	return 0
+}
+
+type StatValuesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// In the order of the requested names
+	Results       []*StatValuesResponse_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatValuesResponse) Reset() {
+	*x = StatValuesResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatValuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatValuesResponse) ProtoMessage() {}
+
+func (x *StatValuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatValuesResponse.ProtoReflect.Descriptor instead.
+func (*StatValuesResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *StatValuesResponse) GetResults() []*StatValuesResponse_Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ProlongValueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	MaxSize       int64                  `protobuf:"varint,3,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProlongValueRequest) Reset() {
+	*x = ProlongValueRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProlongValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProlongValueRequest) ProtoMessage() {}
+
+func (x *ProlongValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProlongValueRequest.ProtoReflect.Descriptor instead.
+func (*ProlongValueRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ProlongValueRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProlongValueRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *ProlongValueRequest) GetMaxSize() int64 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+type ImportCarRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Next chunk of the CAR file
+	Chunk []byte `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	// TTL of every imported block, only read from the first message
+	Ttl           *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportCarRequest) Reset() {
+	*x = ImportCarRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportCarRequest) ProtoMessage() {}
+
+func (x *ImportCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportCarRequest.ProtoReflect.Descriptor instead.
+func (*ImportCarRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ImportCarRequest) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *ImportCarRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+type ImportCarResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resource names of the roots in the CAR header
+	Roots      []string `protobuf:"bytes,1,rep,name=roots,proto3" json:"roots,omitempty"`
+	BlockCount int64    `protobuf:"varint,2,opt,name=block_count,json=blockCount,proto3" json:"block_count,omitempty"`
+	// Total size of the imported blocks in bytes
+	TotalSize     int64                `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	Ttl           *durationpb.Duration `protobuf:"bytes,4,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportCarResponse) Reset() {
+	*x = ImportCarResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportCarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportCarResponse) ProtoMessage() {}
+
+func (x *ImportCarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportCarResponse.ProtoReflect.Descriptor instead.
+func (*ImportCarResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ImportCarResponse) GetRoots() []string {
+	if x != nil {
+		return x.Roots
+	}
+	return nil
+}
+
+func (x *ImportCarResponse) GetBlockCount() int64 {
+	if x != nil {
+		return x.BlockCount
+	}
+	return 0
+}
+
+func (x *ImportCarResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *ImportCarResponse) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+type ExportCarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportCarRequest) Reset() {
+	*x = ExportCarRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportCarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportCarRequest) ProtoMessage() {}
+
+func (x *ExportCarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportCarRequest.ProtoReflect.Descriptor instead.
+func (*ExportCarRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ExportCarRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ExportCarResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Next chunk of the CARv1 file
+	Chunk         []byte `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportCarResponse) Reset() {
+	*x = ExportCarResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportCarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportCarResponse) ProtoMessage() {}
+
+func (x *ExportCarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportCarResponse.ProtoReflect.Descriptor instead.
+func (*ExportCarResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ExportCarResponse) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+type ProlongValueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Receipt       *SignedStorageReceipt  `protobuf:"bytes,3,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProlongValueResponse) Reset() {
+	*x = ProlongValueResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProlongValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProlongValueResponse) ProtoMessage() {}
+
+func (x *ProlongValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProlongValueResponse.ProtoReflect.Descriptor instead.
+func (*ProlongValueResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ProlongValueResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProlongValueResponse) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *ProlongValueResponse) GetReceipt() *SignedStorageReceipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+// Promise of a node to keep a value stored until expire_time, which users
+// can present if the value disappears earlier
+type StorageReceipt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Cid   string                 `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Size of the value as uploaded
+	Size       int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// Price charged to the session for the call issuing the receipt
+	Price   int64  `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	NodeDid string `protobuf:"bytes,5,opt,name=node_did,json=nodeDid,proto3" json:"node_did,omitempty"`
+	// did:key of the ed25519 key signing the receipt, the libp2p identity of
+	// the node
+	Signer        string                 `protobuf:"bytes,6,opt,name=signer,proto3" json:"signer,omitempty"`
+	IssueTime     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=issue_time,json=issueTime,proto3" json:"issue_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageReceipt) Reset() {
+	*x = StorageReceipt{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageReceipt) ProtoMessage() {}
+
+func (x *StorageReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageReceipt.ProtoReflect.Descriptor instead.
+func (*StorageReceipt) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *StorageReceipt) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *StorageReceipt) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *StorageReceipt) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+func (x *StorageReceipt) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *StorageReceipt) GetNodeDid() string {
+	if x != nil {
+		return x.NodeDid
+	}
+	return ""
+}
+
+func (x *StorageReceipt) GetSigner() string {
+	if x != nil {
+		return x.Signer
+	}
+	return ""
+}
+
+func (x *StorageReceipt) GetIssueTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssueTime
+	}
+	return nil
+}
+
+type SignedStorageReceipt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Serialized StorageReceipt, kept as signed
+	Receipt []byte `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	// Ed25519 signature of the receipt prefixed by the receipt signing domain
+	Signature     []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedStorageReceipt) Reset() {
+	*x = SignedStorageReceipt{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedStorageReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedStorageReceipt) ProtoMessage() {}
+
+func (x *SignedStorageReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedStorageReceipt.ProtoReflect.Descriptor instead.
+func (*SignedStorageReceipt) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *SignedStorageReceipt) GetReceipt() []byte {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *SignedStorageReceipt) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type ProlongDagRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Root of the DAG, every value reachable from it is prolonged
+	Name string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ttl  *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Upper bound of the total size of the DAG
+	MaxSize       int64 `protobuf:"varint,3,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProlongDagRequest) Reset() {
+	*x = ProlongDagRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProlongDagRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProlongDagRequest) ProtoMessage() {}
+
+func (x *ProlongDagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProlongDagRequest.ProtoReflect.Descriptor instead.
+func (*ProlongDagRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ProlongDagRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProlongDagRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *ProlongDagRequest) GetMaxSize() int64 {
+	if x != nil {
+		return x.MaxSize
+	}
+	return 0
+}
+
+type ProlongDagResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Number of values prolonged
+	BlockCount int64 `protobuf:"varint,2,opt,name=block_count,json=blockCount,proto3" json:"block_count,omitempty"`
+	// Total size of the values prolonged
+	TotalSize int64 `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	// Names of linked values which are not stored anymore, their links are not
+	// followed
+	Missing       []string `protobuf:"bytes,4,rep,name=missing,proto3" json:"missing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProlongDagResponse) Reset() {
+	*x = ProlongDagResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProlongDagResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProlongDagResponse) ProtoMessage() {}
+
+func (x *ProlongDagResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProlongDagResponse.ProtoReflect.Descriptor instead.
+func (*ProlongDagResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ProlongDagResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProlongDagResponse) GetBlockCount() int64 {
+	if x != nil {
+		return x.BlockCount
+	}
+	return 0
+}
+
+func (x *ProlongDagResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *ProlongDagResponse) GetMissing() []string {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+type ChallengeValueRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Fresh random bytes salting the segments digest so that it cannot be
+	// answered from a digest computed earlier
+	Nonce []byte `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// Offsets of the segments hashed with the nonce
+	Offsets []int64 `protobuf:"varint,3,rep,packed,name=offsets,proto3" json:"offsets,omitempty"`
+	// Length of each segment, shorter at the end of the value
+	SegmentSize int64 `protobuf:"varint,4,opt,name=segment_size,json=segmentSize,proto3" json:"segment_size,omitempty"`
+	// Indices of the chunks to return with merkle proofs against the chunk root
+	ChunkIndices  []uint64 `protobuf:"varint,5,rep,packed,name=chunk_indices,json=chunkIndices,proto3" json:"chunk_indices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChallengeValueRequest) Reset() {
+	*x = ChallengeValueRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChallengeValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChallengeValueRequest) ProtoMessage() {}
+
+func (x *ChallengeValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChallengeValueRequest.ProtoReflect.Descriptor instead.
+func (*ChallengeValueRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ChallengeValueRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ChallengeValueRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *ChallengeValueRequest) GetOffsets() []int64 {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+func (x *ChallengeValueRequest) GetSegmentSize() int64 {
+	if x != nil {
+		return x.SegmentSize
+	}
+	return 0
+}
+
+func (x *ChallengeValueRequest) GetChunkIndices() []uint64 {
+	if x != nil {
+		return x.ChunkIndices
+	}
+	return nil
+}
+
+type ChunkProof struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index uint64                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Chunk []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	// Sibling hashes from the chunk up to the root
+	Hashes        [][]byte `protobuf:"bytes,3,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChunkProof) Reset() {
+	*x = ChunkProof{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkProof) ProtoMessage() {}
+
+func (x *ChunkProof) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkProof.ProtoReflect.Descriptor instead.
+func (*ChunkProof) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ChunkProof) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ChunkProof) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *ChunkProof) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+type ChallengeValueResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// sha256 of the nonce followed by the requested segments, empty without
+	// offsets
+	SegmentsDigest []byte `protobuf:"bytes,2,opt,name=segments_digest,json=segmentsDigest,proto3" json:"segments_digest,omitempty"`
+	// Chunk root recorded at upload
+	ChunkRoot     []byte        `protobuf:"bytes,3,opt,name=chunk_root,json=chunkRoot,proto3" json:"chunk_root,omitempty"`
+	ChunkProofs   []*ChunkProof `protobuf:"bytes,4,rep,name=chunk_proofs,json=chunkProofs,proto3" json:"chunk_proofs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChallengeValueResponse) Reset() {
+	*x = ChallengeValueResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChallengeValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChallengeValueResponse) ProtoMessage() {}
+
+func (x *ChallengeValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChallengeValueResponse.ProtoReflect.Descriptor instead.
+func (*ChallengeValueResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ChallengeValueResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ChallengeValueResponse) GetSegmentsDigest() []byte {
+	if x != nil {
+		return x.SegmentsDigest
+	}
+	return nil
+}
+
+func (x *ChallengeValueResponse) GetChunkRoot() []byte {
+	if x != nil {
+		return x.ChunkRoot
+	}
+	return nil
+}
+
+func (x *ChallengeValueResponse) GetChunkProofs() []*ChunkProof {
+	if x != nil {
+		return x.ChunkProofs
+	}
+	return nil
+}
+
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Balance       int64                  `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *Session) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Session) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+type CreateSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jwt           string                 `protobuf:"bytes,1,opt,name=jwt,proto3" json:"jwt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionRequest) Reset() {
+	*x = CreateSessionRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListStreamValuesResponse) String() string {
+func (x *CreateSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListStreamValuesResponse) ProtoMessage() {}
+func (*CreateSessionRequest) ProtoMessage() {}
 
-func (x *ListStreamValuesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[24]
+func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1574,47 +3447,41 @@ func (x *ListStreamValuesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListStreamValuesResponse.ProtoReflect.Descriptor instead.
-func (*ListStreamValuesResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{24}
-}
-
-func (x *ListStreamValuesResponse) GetStreamValueInfo() []*StreamValueInfo {
-	if x != nil {
-		return x.StreamValueInfo
-	}
-	return nil
+// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *ListStreamValuesResponse) GetPageToken() string {
+func (x *CreateSessionRequest) GetJwt() string {
 	if x != nil {
-		return x.PageToken
+		return x.Jwt
 	}
 	return ""
 }
 
-type GetValueRequest struct {
+type CreateSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Session       *Session               `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Jwt           string                 `protobuf:"bytes,2,opt,name=jwt,proto3" json:"jwt,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetValueRequest) Reset() {
-	*x = GetValueRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[25]
+func (x *CreateSessionResponse) Reset() {
+	*x = CreateSessionResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetValueRequest) String() string {
+func (x *CreateSessionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetValueRequest) ProtoMessage() {}
+func (*CreateSessionResponse) ProtoMessage() {}
 
-func (x *GetValueRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[25]
+func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1625,40 +3492,46 @@ func (x *GetValueRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetValueRequest.ProtoReflect.Descriptor instead.
-func (*GetValueRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use CreateSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateSessionResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *GetValueRequest) GetName() string {
+func (x *CreateSessionResponse) GetSession() *Session {
 	if x != nil {
-		return x.Name
+		return x.Session
+	}
+	return nil
+}
+
+func (x *CreateSessionResponse) GetJwt() string {
+	if x != nil {
+		return x.Jwt
 	}
 	return ""
 }
 
-type GetValueResponse struct {
+type RevokeSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetValueResponse) Reset() {
-	*x = GetValueResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[26]
+func (x *RevokeSessionRequest) Reset() {
+	*x = RevokeSessionRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetValueResponse) String() string {
+func (x *RevokeSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetValueResponse) ProtoMessage() {}
+func (*RevokeSessionRequest) ProtoMessage() {}
 
-func (x *GetValueResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[26]
+func (x *RevokeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1669,42 +3542,41 @@ func (x *GetValueResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetValueResponse.ProtoReflect.Descriptor instead.
-func (*GetValueResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{26}
-}
-
-func (x *GetValueResponse) GetValue() []byte {
-	if x != nil {
-		return x.Value
-	}
-	return nil
+// Deprecated: Use RevokeSessionRequest.ProtoReflect.Descriptor instead.
+func (*RevokeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{53}
 }
 
-type ProlongValueRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
-	MaxSize       int64                  `protobuf:"varint,3,opt,name=max_size,json=maxSize,proto3" json:"max_size,omitempty"`
+// Balance left in a revoked session, to be refunded through the exchange
+// which issued the quota token of the session
+type SessionRefund struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// DID of the exchange the quota token was redeemed from
+	Issuer string `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	// Id of the redeemed quota token
+	Jti           string                 `protobuf:"bytes,3,opt,name=jti,proto3" json:"jti,omitempty"`
+	Balance       int64                  `protobuf:"varint,4,opt,name=balance,proto3" json:"balance,omitempty"`
+	RevokeTime    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=revoke_time,json=revokeTime,proto3" json:"revoke_time,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProlongValueRequest) Reset() {
-	*x = ProlongValueRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[27]
+func (x *SessionRefund) Reset() {
+	*x = SessionRefund{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProlongValueRequest) String() string {
+func (x *SessionRefund) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProlongValueRequest) ProtoMessage() {}
+func (*SessionRefund) ProtoMessage() {}
 
-func (x *ProlongValueRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[27]
+func (x *SessionRefund) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1715,55 +3587,71 @@ func (x *ProlongValueRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProlongValueRequest.ProtoReflect.Descriptor instead.
-func (*ProlongValueRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{27}
+// Deprecated: Use SessionRefund.ProtoReflect.Descriptor instead.
+func (*SessionRefund) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *ProlongValueRequest) GetName() string {
+func (x *SessionRefund) GetSessionId() string {
 	if x != nil {
-		return x.Name
+		return x.SessionId
 	}
 	return ""
 }
 
-func (x *ProlongValueRequest) GetTtl() *durationpb.Duration {
+func (x *SessionRefund) GetIssuer() string {
 	if x != nil {
-		return x.Ttl
+		return x.Issuer
 	}
-	return nil
+	return ""
 }
 
-func (x *ProlongValueRequest) GetMaxSize() int64 {
+func (x *SessionRefund) GetJti() string {
 	if x != nil {
-		return x.MaxSize
+		return x.Jti
+	}
+	return ""
+}
+
+func (x *SessionRefund) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
 	}
 	return 0
 }
 
-type ProlongValueResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+func (x *SessionRefund) GetRevokeTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RevokeTime
+	}
+	return nil
+}
+
+type RevokeSessionResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Refund *SessionRefund         `protobuf:"bytes,1,opt,name=refund,proto3" json:"refund,omitempty"`
+	// Voucher for the refund like the one of CloseSessionResponse. Empty when
+	// no balance is left.
+	Voucher       string `protobuf:"bytes,2,opt,name=voucher,proto3" json:"voucher,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProlongValueResponse) Reset() {
-	*x = ProlongValueResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[28]
+func (x *RevokeSessionResponse) Reset() {
+	*x = RevokeSessionResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProlongValueResponse) String() string {
+func (x *RevokeSessionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProlongValueResponse) ProtoMessage() {}
+func (*RevokeSessionResponse) ProtoMessage() {}
 
-func (x *ProlongValueResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[28]
+func (x *RevokeSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1774,48 +3662,46 @@ func (x *ProlongValueResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProlongValueResponse.ProtoReflect.Descriptor instead.
-func (*ProlongValueResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{28}
+// Deprecated: Use RevokeSessionResponse.ProtoReflect.Descriptor instead.
+func (*RevokeSessionResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *ProlongValueResponse) GetName() string {
+func (x *RevokeSessionResponse) GetRefund() *SessionRefund {
 	if x != nil {
-		return x.Name
+		return x.Refund
 	}
-	return ""
+	return nil
 }
 
-func (x *ProlongValueResponse) GetTtl() *durationpb.Duration {
+func (x *RevokeSessionResponse) GetVoucher() string {
 	if x != nil {
-		return x.Ttl
+		return x.Voucher
 	}
-	return nil
+	return ""
 }
 
-type Session struct {
+type CloseSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Balance       int64                  `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Session) Reset() {
-	*x = Session{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[29]
+func (x *CloseSessionRequest) Reset() {
+	*x = CloseSessionRequest{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Session) String() string {
+func (x *CloseSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Session) ProtoMessage() {}
+func (*CloseSessionRequest) ProtoMessage() {}
 
-func (x *Session) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[29]
+func (x *CloseSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1826,47 +3712,91 @@ func (x *Session) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Session.ProtoReflect.Descriptor instead.
-func (*Session) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{29}
+// Deprecated: Use CloseSessionRequest.ProtoReflect.Descriptor instead.
+func (*CloseSessionRequest) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *Session) GetSessionId() string {
+type CloseSessionResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Refund *SessionRefund         `protobuf:"bytes,1,opt,name=refund,proto3" json:"refund,omitempty"`
+	// JWT signed by the exchange account key of this node, with usage
+	// JWT_USAGE_REFUND and the refund issuer as audience. Empty when no
+	// balance is left.
+	Voucher       string `protobuf:"bytes,2,opt,name=voucher,proto3" json:"voucher,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseSessionResponse) Reset() {
+	*x = CloseSessionResponse{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseSessionResponse) ProtoMessage() {}
+
+func (x *CloseSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[57]
 	if x != nil {
-		return x.SessionId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Session) GetBalance() int64 {
+// Deprecated: Use CloseSessionResponse.ProtoReflect.Descriptor instead.
+func (*CloseSessionResponse) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *CloseSessionResponse) GetRefund() *SessionRefund {
 	if x != nil {
-		return x.Balance
+		return x.Refund
 	}
-	return 0
+	return nil
 }
 
-type CreateSessionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Jwt           string                 `protobuf:"bytes,1,opt,name=jwt,proto3" json:"jwt,omitempty"`
+func (x *CloseSessionResponse) GetVoucher() string {
+	if x != nil {
+		return x.Voucher
+	}
+	return ""
+}
+
+type BatchGetValuesResponse_Result struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Set instead of value when the value cannot be returned, e.g. NOT_FOUND
+	Status        *ResultStatus `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateSessionRequest) Reset() {
-	*x = CreateSessionRequest{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[30]
+func (x *BatchGetValuesResponse_Result) Reset() {
+	*x = BatchGetValuesResponse_Result{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateSessionRequest) String() string {
+func (x *BatchGetValuesResponse_Result) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateSessionRequest) ProtoMessage() {}
+func (*BatchGetValuesResponse_Result) ProtoMessage() {}
 
-func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[30]
+func (x *BatchGetValuesResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1877,41 +3807,57 @@ func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
-func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{30}
+// Deprecated: Use BatchGetValuesResponse_Result.ProtoReflect.Descriptor instead.
+func (*BatchGetValuesResponse_Result) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{33, 0}
 }
 
-func (x *CreateSessionRequest) GetJwt() string {
+func (x *BatchGetValuesResponse_Result) GetName() string {
 	if x != nil {
-		return x.Jwt
+		return x.Name
 	}
 	return ""
 }
 
-type CreateSessionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Session       *Session               `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
-	Jwt           string                 `protobuf:"bytes,2,opt,name=jwt,proto3" json:"jwt,omitempty"`
+func (x *BatchGetValuesResponse_Result) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *BatchGetValuesResponse_Result) GetStatus() *ResultStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type StatValuesResponse_Result struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Stat  *ValueStat             `protobuf:"bytes,2,opt,name=stat,proto3" json:"stat,omitempty"`
+	// Set instead of stat when the value cannot be found
+	Status        *ResultStatus `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateSessionResponse) Reset() {
-	*x = CreateSessionResponse{}
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[31]
+func (x *StatValuesResponse_Result) Reset() {
+	*x = StatValuesResponse_Result{}
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateSessionResponse) String() string {
+func (x *StatValuesResponse_Result) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateSessionResponse) ProtoMessage() {}
+func (*StatValuesResponse_Result) ProtoMessage() {}
 
-func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_kvstore_v1_kvstore_proto_msgTypes[31]
+func (x *StatValuesResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_kvstore_v1_kvstore_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1922,23 +3868,30 @@ func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateSessionResponse.ProtoReflect.Descriptor instead.
-func (*CreateSessionResponse) Descriptor() ([]byte, []int) {
-	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{31}
+// Deprecated: Use StatValuesResponse_Result.ProtoReflect.Descriptor instead.
+func (*StatValuesResponse_Result) Descriptor() ([]byte, []int) {
+	return file_kvstore_v1_kvstore_proto_rawDescGZIP(), []int{36, 0}
 }
 
-func (x *CreateSessionResponse) GetSession() *Session {
+func (x *StatValuesResponse_Result) GetName() string {
 	if x != nil {
-		return x.Session
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StatValuesResponse_Result) GetStat() *ValueStat {
+	if x != nil {
+		return x.Stat
 	}
 	return nil
 }
 
-func (x *CreateSessionResponse) GetJwt() string {
+func (x *StatValuesResponse_Result) GetStatus() *ResultStatus {
 	if x != nil {
-		return x.Jwt
+		return x.Status
 	}
-	return ""
+	return nil
 }
 
 var File_kvstore_v1_kvstore_proto protoreflect.FileDescriptor
@@ -1946,17 +3899,15 @@ var File_kvstore_v1_kvstore_proto protoreflect.FileDescriptor
 const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"\n" +
 	"\x18kvstore/v1/kvstore.proto\x12\n" +
-	"kvstore.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bbuf/validate/validate.proto\"}\n" +
-	"\x0eProviderResult\x12\x1d\n" +
+	"kvstore.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bbuf/validate/validate.proto\"h\n" +
 	"\n" +
-	"context_id\x18\x01 \x01(\tR\tContextID\x12\x1a\n" +
-	"\bmetadata\x18\x02 \x01(\tR\bMetadata\x120\n" +
-	"\bprovider\x18\x03 \x01(\v2\x14.kvstore.v1.InstanceR\bProvider\"v\n" +
-	"\x0fMultihashResult\x12\x1c\n" +
-	"\tmultihash\x18\x01 \x01(\tR\tMultihash\x12E\n" +
-	"\x10provider_results\x18\x02 \x03(\v2\x1a.kvstore.v1.ProviderResultR\x0fProviderResults\"N\n" +
-	"\x18DelegatedRoutingResponse\x122\n" +
-	"\tproviders\x18\x01 \x03(\v2\x14.kvstore.v1.InstanceR\tProviders\"9\n" +
+	"PeerRecord\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\tR\x06Schema\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02ID\x12\x14\n" +
+	"\x05addrs\x18\x03 \x03(\tR\x05Addrs\x12\x1c\n" +
+	"\tprotocols\x18\x04 \x03(\tR\tProtocols\"V\n" +
+	"\x18DelegatedRoutingResponse\x124\n" +
+	"\tproviders\x18\x02 \x03(\v2\x16.kvstore.v1.PeerRecordR\tProvidersJ\x04\b\x01\x10\x02\"9\n" +
 	"\x17DelegatedRoutingRequest\x12\x1e\n" +
 	"\x03cid\x18\x01 \x01(\tB\f\xe0A\x02\xbaH\x06r\x04\x10.\x18;R\x03cid\",\n" +
 	"\vPingRequest\x12\x1d\n" +
@@ -1975,7 +3926,7 @@ const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"\tsignature\x18\x06 \x01(\tR\tsignature\"\x9e\x01\n" +
 	"\x0eVirtualService\x12F\n" +
 	"\rbehavior_link\x18\x01 \x01(\v2\x16.kvstore.v1.GlobalLinkB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\fbehaviorLink\x12D\n" +
-	"\fvariant_link\x18\x02 \x01(\v2\x16.kvstore.v1.GlobalLinkB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\vvariantLink\"\xaf\x05\n" +
+	"\fvariant_link\x18\x02 \x01(\v2\x16.kvstore.v1.GlobalLinkB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\vvariantLink\"\xfc\x05\n" +
 	"\x11ProviderAdvertise\x12L\n" +
 	"\x11provider_instance\x18\x01 \x01(\v2\x14.kvstore.v1.InstanceB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\x10providerInstance\x12N\n" +
 	"\x0fvirtual_service\x18\x02 \x01(\v2\x1a.kvstore.v1.VirtualServiceB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\x0evirtualService\x12&\n" +
@@ -1991,12 +3942,19 @@ const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"updateTime\x12\x1c\n" +
 	"\tsignature\x18\n" +
 	" \x01(\tR\tsignature\x12'\n" +
-	"\x0fpricing_details\x18\v \x01(\tR\x0epricingDetails\"5\n" +
+	"\x0fpricing_details\x18\v \x01(\tR\x0epricingDetails\x12K\n" +
+	"\x10inclusion_proofs\x18\f \x03(\v2 .kvstore.v1.MerkleInclusionProofR\x0finclusionProofs\"5\n" +
 	"\x10SearchCidRequest\x12!\n" +
-	"\x03cid\x18\x01 \x01(\tB\x0f\xe0A\x02\xbaH\t\xc8\x01\x01r\x04\x10.\x18;R\x03cid\"\xb6\x01\n" +
+	"\x03cid\x18\x01 \x01(\tB\x0f\xe0A\x02\xbaH\t\xc8\x01\x01r\x04\x10.\x18;R\x03cid\"a\n" +
+	"\x14MerkleInclusionProof\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x1d\n" +
+	"\n" +
+	"leaf_index\x18\x02 \x01(\x04R\tleafIndex\x12\x16\n" +
+	"\x06hashes\x18\x03 \x03(\fR\x06hashes\"\x83\x02\n" +
 	"\x11SearchCidResponse\x12U\n" +
 	"\x10virtual_services\x18\x01 \x03(\v2\x1a.kvstore.v1.VirtualServiceB\x0e\xe0A\x02\xbaH\b\xc8\x01\x01\x92\x01\x02\b\x01R\x0fvirtualServices\x12J\n" +
-	"\x11storage_instances\x18\x02 \x03(\v2\x1d.kvstore.v1.ProviderAdvertiseR\x10storageInstances\"g\n" +
+	"\x11storage_instances\x18\x02 \x03(\v2\x1d.kvstore.v1.ProviderAdvertiseR\x10storageInstances\x12K\n" +
+	"\x10inclusion_proofs\x18\x04 \x03(\v2 .kvstore.v1.MerkleInclusionProofR\x0finclusionProofs\"g\n" +
 	"\x15SearchInstanceRequest\x12N\n" +
 	"\x0fvirtual_service\x18\x01 \x01(\v2\x1a.kvstore.v1.VirtualServiceB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\x0evirtualService\"\xc7\x01\n" +
 	"\x16SearchInstanceResponse\x12N\n" +
@@ -2004,62 +3962,193 @@ const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"\x13instance_price_info\x18\x02 \x03(\v2\x1d.kvstore.v1.ProviderAdvertiseB\x0e\xe0A\x02\xbaH\b\xc8\x01\x01\x92\x01\x02\b\x01R\x11instancePriceInfo\"i\n" +
 	"\x17RegisterInstanceRequest\x12N\n" +
 	"\radvertisement\x18\x01 \x01(\v2\x1d.kvstore.v1.ProviderAdvertiseB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\radvertisement\"\x1a\n" +
-	"\x18RegisterInstanceResponse\"\x8f\x01\n" +
+	"\x18RegisterInstanceResponse\"\xbb\x01\n" +
 	"\bInstance\x12%\n" +
 	"\x03did\x18\x01 \x01(\tB\x13\xe0A\x02\xbaH\r\xc8\x01\x01r\b2\x06did:.*R\x03did\x12+\n" +
 	"\apeer_id\x18\x02 \x01(\tB\x16\xbaH\x13r\x112\x0f[0-9a-zA-Z]{52}R\x02ID\x12/\n" +
 	"\n" +
-	"multiaddrs\x18\x03 \x03(\tB\x14\xe0A\x02\xbaH\x0e\xc8\x01\x01\x92\x01\b\b\x01\"\x04r\x02\x10\x01R\x05Addrs\"\x8c\x02\n" +
+	"multiaddrs\x18\x03 \x03(\tB\x14\xe0A\x02\xbaH\x0e\xc8\x01\x01\x92\x01\b\b\x01\"\x04r\x02\x10\x01R\x05Addrs\x12*\n" +
+	"\tprotocols\x18\x04 \x03(\tB\f\xbaH\t\x92\x01\x06\"\x04r\x02\x10\x01R\tProtocols\"\xb1\x04\n" +
 	"\x12CreateValueRequest\x12D\n" +
 	"\x05codec\x18\x01 \x01(\x0e2$.kvstore.v1.CreateValueRequest.CodecB\b\xbaH\x05\x82\x01\x02\x10\x01R\x05codec\x12)\n" +
 	"\x05value\x18\x02 \x01(\fB\x13\xe0A\x02\xbaH\r\xc8\x01\x01z\b\x10\x01\x18\x80\x80\x80\x80\x04R\x05value\x12D\n" +
-	"\x03ttl\x18\x03 \x01(\v2\x19.google.protobuf.DurationB\x17\xe0A\x02\xbaH\x11\xc8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\"?\n" +
+	"\x03ttl\x18\x03 \x01(\v2\x19.google.protobuf.DurationB\x17\xe0A\x02\xbaH\x11\xc8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\x12Z\n" +
+	"\rhash_function\x18\x04 \x01(\x0e2+.kvstore.v1.CreateValueRequest.HashFunctionB\b\xbaH\x05\x82\x01\x02\x10\x01R\fhashFunction\"g\n" +
 	"\x05Codec\x12\x15\n" +
 	"\x11CODEC_UNSPECIFIED\x10\x00\x12\r\n" +
 	"\tCODEC_RAW\x10\x01\x12\x10\n" +
-	"\fCODEC_DAG_PB\x10\x02\"x\n" +
-	"\x13CreateValueResponse\x124\n" +
-	"\x04name\x18\x01 \x01(\tB \xe0A\x02\xbaH\x1a\xc8\x01\x01r\x152\x13values/[0-9a-z]{59}R\x04name\x12+\n" +
-	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"\x83\x01\n" +
+	"\fCODEC_DAG_PB\x10\x02\x12\x12\n" +
+	"\x0eCODEC_DAG_CBOR\x10\x03\x12\x12\n" +
+	"\x0eCODEC_DAG_JSON\x10\x04\"\x9e\x01\n" +
+	"\fHashFunction\x12\x1d\n" +
+	"\x19HASH_FUNCTION_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16HASH_FUNCTION_SHA2_256\x10\x01\x12\x1a\n" +
+	"\x16HASH_FUNCTION_SHA2_512\x10\x02\x12\x1d\n" +
+	"\x19HASH_FUNCTION_BLAKE2B_256\x10\x03\x12\x18\n" +
+	"\x14HASH_FUNCTION_BLAKE3\x10\x04\"\x91\x02\n" +
+	"\x13CreateValueResponse\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12\x1f\n" +
+	"\vstored_size\x18\x03 \x01(\x03R\n" +
+	"storedSize\x12\x1a\n" +
+	"\bencoding\x18\x04 \x01(\tR\bencoding\x12\x1d\n" +
+	"\n" +
+	"chunk_root\x18\x05 \x01(\fR\tchunkRoot\x12:\n" +
+	"\areceipt\x18\x06 \x01(\v2 .kvstore.v1.SignedStorageReceiptR\areceipt\"\x83\x01\n" +
 	"\x18CreateStreamValueRequest\x12?\n" +
 	"\x06parent\x18\x01 \x01(\tB'\xe0A\x02\xbaH!\xc8\x01\x01r\x1c2\x1aaccounts/did:.*/streams/.*R\x06parent\x12&\n" +
 	"\x05value\x18\x02 \x01(\fB\x10\xe0A\x02\xbaH\n" +
 	"\xc8\x01\x01z\x05\x10\x01\x18\x80 R\x05value\"\x8f\x01\n" +
 	"\x19CreateStreamValueResponse\x12E\n" +
 	"\x04name\x18\x01 \x01(\tB1\xe0A\x02\xbaH+\xc8\x01\x01r&2$accounts/did:.*/streams/.*/values/.*R\x04name\x12+\n" +
-	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"\x8c\x01\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"\xa8\x01\n" +
 	"\x15GetStreamValueRequest\x12E\n" +
-	"\x04name\x18\x01 \x01(\tB1\xe0A\x02\xbaH+\xc8\x01\x01r&2$accounts/did:.*/streams/.*/values/.*R\x04name\x12,\n" +
+	"\x04name\x18\x01 \x01(\tB1\xe0A\x02\xbaH+\xc8\x01\x01r&2$accounts/did:.*/streams/.*/values/.*R\x04name\x12\x1d\n" +
 	"\n" +
-	"auth_token\x18\x02 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\tauthToken\"z\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12)\n" +
+	"\x10delegation_token\x18\x03 \x01(\tR\x0fdelegationToken\"z\n" +
 	"\x0fStreamValueInfo\x12#\n" +
 	"\x05value\x18\x01 \x01(\fB\r\xe0A\x02\xbaH\a\xc8\x01\x01z\x02\x10\x01R\x05value\x12B\n" +
 	"\x0fstream_entry_id\x18\x02 \x01(\tB\x1a\xe0A\x02\xbaH\x14\xc8\x01\x01r\x0f2\r[0-9]+-[0-9]+R\rstreamEntryId\"l\n" +
 	"\x16GetStreamValueResponse\x12R\n" +
-	"\x11stream_value_info\x18\x01 \x01(\v2\x1b.kvstore.v1.StreamValueInfoB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\x0fstreamValueInfo\"\xd0\x01\n" +
+	"\x11stream_value_info\x18\x01 \x01(\v2\x1b.kvstore.v1.StreamValueInfoB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\x0fstreamValueInfo\"\xec\x01\n" +
 	"\x17ListStreamValuesRequest\x12?\n" +
-	"\x06parent\x18\x01 \x01(\tB'\xe0A\x02\xbaH!\xc8\x01\x01r\x1c2\x1aaccounts/did:.*/streams/.*R\x06parent\x12,\n" +
+	"\x06parent\x18\x01 \x01(\tB'\xe0A\x02\xbaH!\xc8\x01\x01r\x1c2\x1aaccounts/did:.*/streams/.*R\x06parent\x12\x1d\n" +
 	"\n" +
-	"auth_token\x18\x02 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\tauthToken\x12'\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12'\n" +
 	"\tpage_size\x18\x03 \x01(\x05B\n" +
 	"\xbaH\a\xd8\x01\x01\x1a\x02 \x00R\bpageSize\x12\x1d\n" +
 	"\n" +
-	"page_token\x18\x04 \x01(\tR\tpageToken\"\x82\x01\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12)\n" +
+	"\x10delegation_token\x18\x05 \x01(\tR\x0fdelegationToken\"\xd7\x02\n" +
+	"\x1bDelegateStreamAccessRequest\x12?\n" +
+	"\x06parent\x18\x01 \x01(\tB'\xe0A\x02\xbaH!\xc8\x01\x01r\x1c2\x1aaccounts/did:.*/streams/.*R\x06parent\x12\x1d\n" +
+	"\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12)\n" +
+	"\bdelegate\x18\x03 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\bdelegate\x12Q\n" +
+	"\n" +
+	"operations\x18\x04 \x03(\x0e2\x1b.kvstore.v1.StreamOperationB\x14\xe0A\x02\xbaH\x0e\x92\x01\v\b\x01\"\a\x82\x01\x04\x10\x01 \x00R\n" +
+	"operations\x12D\n" +
+	"\x03ttl\x18\x05 \x01(\v2\x19.google.protobuf.DurationB\x17\xe0A\x02\xbaH\x11\xc8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\x12\x14\n" +
+	"\x05proof\x18\x06 \x01(\tR\x05proof\"\x86\x01\n" +
+	"\x1cDelegateStreamAccessResponse\x12)\n" +
+	"\x10delegation_token\x18\x01 \x01(\tR\x0fdelegationToken\x12;\n" +
+	"\vexpire_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\"\x82\x01\n" +
 	"\x18ListStreamValuesResponse\x12G\n" +
 	"\x11stream_value_info\x18\x01 \x03(\v2\x1b.kvstore.v1.StreamValueInfoR\x0fstreamValueInfo\x12\x1d\n" +
 	"\n" +
-	"page_token\x18\x02 \x01(\tR\tpageToken\"G\n" +
-	"\x0fGetValueRequest\x124\n" +
-	"\x04name\x18\x01 \x01(\tB \xe0A\x02\xbaH\x1a\xc8\x01\x01r\x152\x13values/[0-9a-z]{59}R\x04name\"7\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"w\n" +
+	"\x0fGetValueRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\x12-\n" +
+	"\x12accepted_encodings\x18\x02 \x03(\tR\x11acceptedEncodings\"S\n" +
 	"\x10GetValueResponse\x12#\n" +
-	"\x05value\x18\x01 \x01(\fB\r\xe0A\x02\xbaH\a\xc8\x01\x01z\x02\x10\x01R\x05value\"\xbb\x01\n" +
-	"\x13ProlongValueRequest\x124\n" +
-	"\x04name\x18\x01 \x01(\tB \xe0A\x02\xbaH\x1a\xc8\x01\x01r\x152\x13values/[0-9a-z]{59}R\x04name\x12D\n" +
+	"\x05value\x18\x01 \x01(\fB\r\xe0A\x02\xbaH\a\xc8\x01\x01z\x02\x10\x01R\x05value\x12\x1a\n" +
+	"\bencoding\x18\x02 \x01(\tR\bencoding\"P\n" +
+	"\x17GetValueMetadataRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\"\xa3\x02\n" +
+	"\rValueMetadata\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x14\n" +
+	"\x05codec\x18\x03 \x01(\x04R\x05codec\x12;\n" +
+	"\vexpire_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\x12;\n" +
+	"\vcreate_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12\x1f\n" +
+	"\vstored_size\x18\x06 \x01(\x03R\n" +
+	"storedSize\x12\x1a\n" +
+	"\bencoding\x18\a \x01(\tR\bencoding\x12\x1d\n" +
+	"\n" +
+	"chunk_root\x18\b \x01(\fR\tchunkRoot\"<\n" +
+	"\fResultStatus\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"W\n" +
+	"\x15BatchGetValuesRequest\x12>\n" +
+	"\x05names\x18\x01 \x03(\tB(\xe0A\x02\xbaH\"\x92\x01\x1f\b\x01\x10\xe8\a\"\x18r\x162\x14values/[0-9a-z]{59,}R\x05names\"\xc3\x01\n" +
+	"\x16BatchGetValuesResponse\x12C\n" +
+	"\aresults\x18\x01 \x03(\v2).kvstore.v1.BatchGetValuesResponse.ResultR\aresults\x1ad\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x120\n" +
+	"\x06status\x18\x03 \x01(\v2\x18.kvstore.v1.ResultStatusR\x06status\"S\n" +
+	"\x11StatValuesRequest\x12>\n" +
+	"\x05names\x18\x01 \x03(\tB(\xe0A\x02\xbaH\"\x92\x01\x1f\b\x01\x10\xe8\a\"\x18r\x162\x14values/[0-9a-z]{59,}R\x05names\"v\n" +
+	"\tValueStat\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12+\n" +
+	"\x03ttl\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12\x14\n" +
+	"\x05codec\x18\x04 \x01(\x04R\x05codec\"\xd0\x01\n" +
+	"\x12StatValuesResponse\x12?\n" +
+	"\aresults\x18\x01 \x03(\v2%.kvstore.v1.StatValuesResponse.ResultR\aresults\x1ay\n" +
+	"\x06Result\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12)\n" +
+	"\x04stat\x18\x02 \x01(\v2\x15.kvstore.v1.ValueStatR\x04stat\x120\n" +
+	"\x06status\x18\x03 \x01(\v2\x18.kvstore.v1.ResultStatusR\x06status\"\xbc\x01\n" +
+	"\x13ProlongValueRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\x12D\n" +
 	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationB\x17\xe0A\x02\xbaH\x11\xc8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\x12(\n" +
-	"\bmax_size\x18\x03 \x01(\x03B\r\xe0A\x02\xbaH\a\xc8\x01\x01\"\x02 \x00R\amaxSize\"W\n" +
+	"\bmax_size\x18\x03 \x01(\x03B\r\xe0A\x02\xbaH\a\xc8\x01\x01\"\x02 \x00R\amaxSize\"w\n" +
+	"\x10ImportCarRequest\x12 \n" +
+	"\x05chunk\x18\x01 \x01(\fB\n" +
+	"\xbaH\az\x05\x18\x80\x80\x80\x02R\x05chunk\x12A\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationB\x14\xbaH\x11\xd8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\"\x96\x01\n" +
+	"\x11ImportCarResponse\x12\x14\n" +
+	"\x05roots\x18\x01 \x03(\tR\x05roots\x12\x1f\n" +
+	"\vblock_count\x18\x02 \x01(\x03R\n" +
+	"blockCount\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\x12+\n" +
+	"\x03ttl\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"I\n" +
+	"\x10ExportCarRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\")\n" +
+	"\x11ExportCarResponse\x12\x14\n" +
+	"\x05chunk\x18\x01 \x01(\fR\x05chunk\"\x93\x01\n" +
 	"\x14ProlongValueResponse\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
-	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"`\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12:\n" +
+	"\areceipt\x18\x03 \x01(\v2 .kvstore.v1.SignedStorageReceiptR\areceipt\"\xf7\x01\n" +
+	"\x0eStorageReceipt\x12\x10\n" +
+	"\x03cid\x18\x01 \x01(\tR\x03cid\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12;\n" +
+	"\vexpire_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"expireTime\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x03R\x05price\x12\x19\n" +
+	"\bnode_did\x18\x05 \x01(\tR\anodeDid\x12\x16\n" +
+	"\x06signer\x18\x06 \x01(\tR\x06signer\x129\n" +
+	"\n" +
+	"issue_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tissueTime\"N\n" +
+	"\x14SignedStorageReceipt\x12\x18\n" +
+	"\areceipt\x18\x01 \x01(\fR\areceipt\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\"\xba\x01\n" +
+	"\x11ProlongDagRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\x12D\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationB\x17\xe0A\x02\xbaH\x11\xc8\x01\x01\xaa\x01\v\"\x05\b\x80\xe7\x84\x0f2\x02\b\x01R\x03ttl\x12(\n" +
+	"\bmax_size\x18\x03 \x01(\x03B\r\xe0A\x02\xbaH\a\xc8\x01\x01\"\x02 \x00R\amaxSize\"\x82\x01\n" +
+	"\x12ProlongDagResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vblock_count\x18\x02 \x01(\x03R\n" +
+	"blockCount\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\x12\x18\n" +
+	"\amissing\x18\x04 \x03(\tR\amissing\"\xf5\x01\n" +
+	"\x15ChallengeValueRequest\x125\n" +
+	"\x04name\x18\x01 \x01(\tB!\xe0A\x02\xbaH\x1b\xc8\x01\x01r\x162\x14values/[0-9a-z]{59,}R\x04name\x12\x1d\n" +
+	"\x05nonce\x18\x02 \x01(\fB\a\xbaH\x04z\x02\x18@R\x05nonce\x12(\n" +
+	"\aoffsets\x18\x03 \x03(\x03B\x0e\xbaH\v\x92\x01\b\x10@\"\x04\"\x02(\x00R\aoffsets\x12-\n" +
+	"\fsegment_size\x18\x04 \x01(\x03B\n" +
+	"\xbaH\a\"\x05\x18\x80 (\x00R\vsegmentSize\x12-\n" +
+	"\rchunk_indices\x18\x05 \x03(\x04B\b\xbaH\x05\x92\x01\x02\x10@R\fchunkIndices\"P\n" +
+	"\n" +
+	"ChunkProof\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x14\n" +
+	"\x05chunk\x18\x02 \x01(\fR\x05chunk\x12\x16\n" +
+	"\x06hashes\x18\x03 \x03(\fR\x06hashes\"\xaf\x01\n" +
+	"\x16ChallengeValueResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12'\n" +
+	"\x0fsegments_digest\x18\x02 \x01(\fR\x0esegmentsDigest\x12\x1d\n" +
+	"\n" +
+	"chunk_root\x18\x03 \x01(\fR\tchunkRoot\x129\n" +
+	"\fchunk_proofs\x18\x04 \x03(\v2\x16.kvstore.v1.ChunkProofR\vchunkProofs\"`\n" +
 	"\aSession\x12,\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\tsessionId\x12'\n" +
@@ -2068,7 +4157,23 @@ const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"\x03jwt\x18\x01 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\x03jwt\"r\n" +
 	"\x15CreateSessionResponse\x128\n" +
 	"\asession\x18\x01 \x01(\v2\x13.kvstore.v1.SessionB\t\xe0A\x02\xbaH\x03\xc8\x01\x01R\asession\x12\x1f\n" +
-	"\x03jwt\x18\x02 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\x03jwt*8\n" +
+	"\x03jwt\x18\x02 \x01(\tB\r\xe0A\x02\xbaH\a\xc8\x01\x01r\x02\x10\x01R\x03jwt\"\x16\n" +
+	"\x14RevokeSessionRequest\"\xaf\x01\n" +
+	"\rSessionRefund\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\tR\x06issuer\x12\x10\n" +
+	"\x03jti\x18\x03 \x01(\tR\x03jti\x12\x18\n" +
+	"\abalance\x18\x04 \x01(\x03R\abalance\x12;\n" +
+	"\vrevoke_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"revokeTime\"d\n" +
+	"\x15RevokeSessionResponse\x121\n" +
+	"\x06refund\x18\x01 \x01(\v2\x19.kvstore.v1.SessionRefundR\x06refund\x12\x18\n" +
+	"\avoucher\x18\x02 \x01(\tR\avoucher\"\x15\n" +
+	"\x13CloseSessionRequest\"c\n" +
+	"\x14CloseSessionResponse\x121\n" +
+	"\x06refund\x18\x01 \x01(\v2\x19.kvstore.v1.SessionRefundR\x06refund\x12\x18\n" +
+	"\avoucher\x18\x02 \x01(\tR\avoucher*8\n" +
 	"\bCoinType\x12\x19\n" +
 	"\x15COIN_TYPE_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rCOIN_TYPE_SUI\x10\x01*\xab\x01\n" +
@@ -2077,24 +4182,42 @@ const file_kvstore_v1_kvstore_proto_rawDesc = "" +
 	"\x18COIN_ENVIRONMENT_MAINNET\x10\x01\x12\x1c\n" +
 	"\x18COIN_ENVIRONMENT_TESTNET\x10\x02\x12\x1b\n" +
 	"\x17COIN_ENVIRONMENT_DEVNET\x10\x03\x12\x1d\n" +
-	"\x19COIN_ENVIRONMENT_LOCALNET\x10\x04*a\n" +
+	"\x19COIN_ENVIRONMENT_LOCALNET\x10\x04*h\n" +
+	"\x0fStreamOperation\x12 \n" +
+	"\x1cSTREAM_OPERATION_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14STREAM_OPERATION_GET\x10\x01\x12\x19\n" +
+	"\x15STREAM_OPERATION_LIST\x10\x02*\x96\x01\n" +
 	"\bJwtUsage\x12\x19\n" +
 	"\x15JWT_USAGE_UNSPECIFIED\x10\x00\x12\x1c\n" +
 	"\x18JWT_USAGE_CREATE_SESSION\x10\x01\x12\x1c\n" +
-	"\x18JWT_USAGE_MANAGE_SESSION\x10\x022\xcf\v\n" +
+	"\x18JWT_USAGE_MANAGE_SESSION\x10\x02\x12\x14\n" +
+	"\x10JWT_USAGE_REFUND\x10\x03\x12\x1d\n" +
+	"\x19JWT_USAGE_DELEGATE_STREAM\x10\x042\x80\x15\n" +
 	"\x0eKvStoreService\x12p\n" +
 	"\vCreateValue\x12\x1e.kvstore.v1.CreateValueRequest\x1a\x1f.kvstore.v1.CreateValueResponse\" \x82\xd3\xe4\x93\x02\x1a:\x05value\"\x11/v1/values:create\x12\xa0\x01\n" +
 	"\x11CreateStreamValue\x12$.kvstore.v1.CreateStreamValueRequest\x1a%.kvstore.v1.CreateStreamValueResponse\">\x82\xd3\xe4\x93\x028:\x05value\"//v1/{parent=accounts/*/streams/*}/values:create\x12b\n" +
-	"\bGetValue\x12\x1b.kvstore.v1.GetValueRequest\x1a\x1c.kvstore.v1.GetValueResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/{name=values/*}\x12\x89\x01\n" +
+	"\bGetValue\x12\x1b.kvstore.v1.GetValueRequest\x1a\x1c.kvstore.v1.GetValueResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/{name=values/*}\x12x\n" +
+	"\x10GetValueMetadata\x12#.kvstore.v1.GetValueMetadataRequest\x1a\x19.kvstore.v1.ValueMetadata\"$\x82\xd3\xe4\x93\x02\x1e\x12\x1c/v1/{name=values/*}:metadata\x12t\n" +
+	"\x0eBatchGetValues\x12!.kvstore.v1.BatchGetValuesRequest\x1a\".kvstore.v1.BatchGetValuesResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/values:batchGet\x12d\n" +
+	"\n" +
+	"StatValues\x12\x1d.kvstore.v1.StatValuesRequest\x1a\x1e.kvstore.v1.StatValuesResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/values:stat\x12\x89\x01\n" +
 	"\x0eGetStreamValue\x12!.kvstore.v1.GetStreamValueRequest\x1a\".kvstore.v1.GetStreamValueResponse\"0\x82\xd3\xe4\x93\x02*\x12(/v1/{name=accounts/*/streams/*/values/*}\x12\x8f\x01\n" +
-	"\x10ListStreamValues\x12#.kvstore.v1.ListStreamValuesRequest\x1a$.kvstore.v1.ListStreamValuesResponse\"0\x82\xd3\xe4\x93\x02*\x12(/v1/{parent=accounts/*/streams/*}/values\x12\x84\x01\n" +
-	"\fProlongValue\x12\x1f.kvstore.v1.ProlongValueRequest\x1a .kvstore.v1.ProlongValueResponse\"1\x82\xd3\xe4\x93\x02+:\x01*\"&/v1/{name=accounts/*/values/*}:prolong\x12_\n" +
+	"\x10ListStreamValues\x12#.kvstore.v1.ListStreamValuesRequest\x1a$.kvstore.v1.ListStreamValuesResponse\"0\x82\xd3\xe4\x93\x02*\x12(/v1/{parent=accounts/*/streams/*}/values\x12\xa0\x01\n" +
+	"\x14DelegateStreamAccess\x12'.kvstore.v1.DelegateStreamAccessRequest\x1a(.kvstore.v1.DelegateStreamAccessResponse\"5\x82\xd3\xe4\x93\x02/:\x01*\"*/v1/{parent=accounts/*/streams/*}:delegate\x12y\n" +
+	"\fProlongValue\x12\x1f.kvstore.v1.ProlongValueRequest\x1a .kvstore.v1.ProlongValueResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/v1/{name=values/*}:prolong\x12v\n" +
+	"\n" +
+	"ProlongDag\x12\x1d.kvstore.v1.ProlongDagRequest\x1a\x1e.kvstore.v1.ProlongDagResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/{name=values/*}:prolongDag\x12\x81\x01\n" +
+	"\x0eChallengeValue\x12!.kvstore.v1.ChallengeValueRequest\x1a\".kvstore.v1.ChallengeValueResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/v1/{name=values/*}:challenge\x12_\n" +
 	"\tSearchCid\x12\x1c.kvstore.v1.SearchCidRequest\x1a\x1d.kvstore.v1.SearchCidResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/v1/searchCid\x12s\n" +
 	"\x0eSearchInstance\x12!.kvstore.v1.SearchInstanceRequest\x1a\".kvstore.v1.SearchInstanceResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/v1/SearchInstance\x12t\n" +
-	"\rCreateSession\x12 .kvstore.v1.CreateSessionRequest\x1a!.kvstore.v1.CreateSessionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/v1/sessions:create\x12\x7f\n" +
+	"\rCreateSession\x12 .kvstore.v1.CreateSessionRequest\x1a!.kvstore.v1.CreateSessionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/v1/sessions:create\x12t\n" +
+	"\rRevokeSession\x12 .kvstore.v1.RevokeSessionRequest\x1a!.kvstore.v1.RevokeSessionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/v1/sessions:revoke\x12p\n" +
+	"\fCloseSession\x12\x1f.kvstore.v1.CloseSessionRequest\x1a .kvstore.v1.CloseSessionResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/v1/sessions:close\x12\x7f\n" +
 	"\x10RegisterInstance\x12#.kvstore.v1.RegisterInstanceRequest\x1a$.kvstore.v1.RegisterInstanceResponse\" \x82\xd3\xe4\x93\x02\x1a:\x01*\"\x15/v1/instance:register\x12K\n" +
 	"\x04Ping\x12\x17.kvstore.v1.PingRequest\x1a\x18.kvstore.v1.PingResponse\"\x10\x82\xd3\xe4\x93\x02\n" +
-	"\x12\b/v1/ping\x12\x84\x01\n" +
+	"\x12\b/v1/ping\x12k\n" +
+	"\tImportCar\x12\x1c.kvstore.v1.ImportCarRequest\x1a\x1d.kvstore.v1.ImportCarResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/v1/values:importCar(\x01\x12q\n" +
+	"\tExportCar\x12\x1c.kvstore.v1.ExportCarRequest\x1a\x1d.kvstore.v1.ExportCarResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/v1/{name=values/*}:exportCar0\x01\x12\x84\x01\n" +
 	"\x10DelegatedRouting\x12#.kvstore.v1.DelegatedRoutingRequest\x1a$.kvstore.v1.DelegatedRoutingResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/routing/v1/providers/{cid=*}BCZAgithub.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1;kvstoreb\x06proto3"
 
 var (
@@ -2109,105 +4232,178 @@ func file_kvstore_v1_kvstore_proto_rawDescGZIP() []byte {
 	return file_kvstore_v1_kvstore_proto_rawDescData
 }
 
-var file_kvstore_v1_kvstore_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_kvstore_v1_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_kvstore_v1_kvstore_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_kvstore_v1_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 60)
 var file_kvstore_v1_kvstore_proto_goTypes = []any{
-	(CoinType)(0),                     // 0: kvstore.v1.CoinType
-	(CoinEnvironment)(0),              // 1: kvstore.v1.CoinEnvironment
-	(JwtUsage)(0),                     // 2: kvstore.v1.JwtUsage
-	(CreateValueRequest_Codec)(0),     // 3: kvstore.v1.CreateValueRequest.Codec
-	(*ProviderResult)(nil),            // 4: kvstore.v1.ProviderResult
-	(*MultihashResult)(nil),           // 5: kvstore.v1.MultihashResult
-	(*DelegatedRoutingResponse)(nil),  // 6: kvstore.v1.DelegatedRoutingResponse
-	(*DelegatedRoutingRequest)(nil),   // 7: kvstore.v1.DelegatedRoutingRequest
-	(*PingRequest)(nil),               // 8: kvstore.v1.PingRequest
-	(*PingResponse)(nil),              // 9: kvstore.v1.PingResponse
-	(*GlobalLink)(nil),                // 10: kvstore.v1.GlobalLink
-	(*VirtualService)(nil),            // 11: kvstore.v1.VirtualService
-	(*ProviderAdvertise)(nil),         // 12: kvstore.v1.ProviderAdvertise
-	(*SearchCidRequest)(nil),          // 13: kvstore.v1.SearchCidRequest
-	(*SearchCidResponse)(nil),         // 14: kvstore.v1.SearchCidResponse
-	(*SearchInstanceRequest)(nil),     // 15: kvstore.v1.SearchInstanceRequest
-	(*SearchInstanceResponse)(nil),    // 16: kvstore.v1.SearchInstanceResponse
-	(*RegisterInstanceRequest)(nil),   // 17: kvstore.v1.RegisterInstanceRequest
-	(*RegisterInstanceResponse)(nil),  // 18: kvstore.v1.RegisterInstanceResponse
-	(*Instance)(nil),                  // 19: kvstore.v1.Instance
-	(*CreateValueRequest)(nil),        // 20: kvstore.v1.CreateValueRequest
-	(*CreateValueResponse)(nil),       // 21: kvstore.v1.CreateValueResponse
-	(*CreateStreamValueRequest)(nil),  // 22: kvstore.v1.CreateStreamValueRequest
-	(*CreateStreamValueResponse)(nil), // 23: kvstore.v1.CreateStreamValueResponse
-	(*GetStreamValueRequest)(nil),     // 24: kvstore.v1.GetStreamValueRequest
-	(*StreamValueInfo)(nil),           // 25: kvstore.v1.StreamValueInfo
-	(*GetStreamValueResponse)(nil),    // 26: kvstore.v1.GetStreamValueResponse
-	(*ListStreamValuesRequest)(nil),   // 27: kvstore.v1.ListStreamValuesRequest
-	(*ListStreamValuesResponse)(nil),  // 28: kvstore.v1.ListStreamValuesResponse
-	(*GetValueRequest)(nil),           // 29: kvstore.v1.GetValueRequest
-	(*GetValueResponse)(nil),          // 30: kvstore.v1.GetValueResponse
-	(*ProlongValueRequest)(nil),       // 31: kvstore.v1.ProlongValueRequest
-	(*ProlongValueResponse)(nil),      // 32: kvstore.v1.ProlongValueResponse
-	(*Session)(nil),                   // 33: kvstore.v1.Session
-	(*CreateSessionRequest)(nil),      // 34: kvstore.v1.CreateSessionRequest
-	(*CreateSessionResponse)(nil),     // 35: kvstore.v1.CreateSessionResponse
-	(*timestamppb.Timestamp)(nil),     // 36: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),       // 37: google.protobuf.Duration
+	(CoinType)(0),                         // 0: kvstore.v1.CoinType
+	(CoinEnvironment)(0),                  // 1: kvstore.v1.CoinEnvironment
+	(StreamOperation)(0),                  // 2: kvstore.v1.StreamOperation
+	(JwtUsage)(0),                         // 3: kvstore.v1.JwtUsage
+	(CreateValueRequest_Codec)(0),         // 4: kvstore.v1.CreateValueRequest.Codec
+	(CreateValueRequest_HashFunction)(0),  // 5: kvstore.v1.CreateValueRequest.HashFunction
+	(*PeerRecord)(nil),                    // 6: kvstore.v1.PeerRecord
+	(*DelegatedRoutingResponse)(nil),      // 7: kvstore.v1.DelegatedRoutingResponse
+	(*DelegatedRoutingRequest)(nil),       // 8: kvstore.v1.DelegatedRoutingRequest
+	(*PingRequest)(nil),                   // 9: kvstore.v1.PingRequest
+	(*PingResponse)(nil),                  // 10: kvstore.v1.PingResponse
+	(*GlobalLink)(nil),                    // 11: kvstore.v1.GlobalLink
+	(*VirtualService)(nil),                // 12: kvstore.v1.VirtualService
+	(*ProviderAdvertise)(nil),             // 13: kvstore.v1.ProviderAdvertise
+	(*SearchCidRequest)(nil),              // 14: kvstore.v1.SearchCidRequest
+	(*MerkleInclusionProof)(nil),          // 15: kvstore.v1.MerkleInclusionProof
+	(*SearchCidResponse)(nil),             // 16: kvstore.v1.SearchCidResponse
+	(*SearchInstanceRequest)(nil),         // 17: kvstore.v1.SearchInstanceRequest
+	(*SearchInstanceResponse)(nil),        // 18: kvstore.v1.SearchInstanceResponse
+	(*RegisterInstanceRequest)(nil),       // 19: kvstore.v1.RegisterInstanceRequest
+	(*RegisterInstanceResponse)(nil),      // 20: kvstore.v1.RegisterInstanceResponse
+	(*Instance)(nil),                      // 21: kvstore.v1.Instance
+	(*CreateValueRequest)(nil),            // 22: kvstore.v1.CreateValueRequest
+	(*CreateValueResponse)(nil),           // 23: kvstore.v1.CreateValueResponse
+	(*CreateStreamValueRequest)(nil),      // 24: kvstore.v1.CreateStreamValueRequest
+	(*CreateStreamValueResponse)(nil),     // 25: kvstore.v1.CreateStreamValueResponse
+	(*GetStreamValueRequest)(nil),         // 26: kvstore.v1.GetStreamValueRequest
+	(*StreamValueInfo)(nil),               // 27: kvstore.v1.StreamValueInfo
+	(*GetStreamValueResponse)(nil),        // 28: kvstore.v1.GetStreamValueResponse
+	(*ListStreamValuesRequest)(nil),       // 29: kvstore.v1.ListStreamValuesRequest
+	(*DelegateStreamAccessRequest)(nil),   // 30: kvstore.v1.DelegateStreamAccessRequest
+	(*DelegateStreamAccessResponse)(nil),  // 31: kvstore.v1.DelegateStreamAccessResponse
+	(*ListStreamValuesResponse)(nil),      // 32: kvstore.v1.ListStreamValuesResponse
+	(*GetValueRequest)(nil),               // 33: kvstore.v1.GetValueRequest
+	(*GetValueResponse)(nil),              // 34: kvstore.v1.GetValueResponse
+	(*GetValueMetadataRequest)(nil),       // 35: kvstore.v1.GetValueMetadataRequest
+	(*ValueMetadata)(nil),                 // 36: kvstore.v1.ValueMetadata
+	(*ResultStatus)(nil),                  // 37: kvstore.v1.ResultStatus
+	(*BatchGetValuesRequest)(nil),         // 38: kvstore.v1.BatchGetValuesRequest
+	(*BatchGetValuesResponse)(nil),        // 39: kvstore.v1.BatchGetValuesResponse
+	(*StatValuesRequest)(nil),             // 40: kvstore.v1.StatValuesRequest
+	(*ValueStat)(nil),                     // 41: kvstore.v1.ValueStat
+	(*StatValuesResponse)(nil),            // 42: kvstore.v1.StatValuesResponse
+	(*ProlongValueRequest)(nil),           // 43: kvstore.v1.ProlongValueRequest
+	(*ImportCarRequest)(nil),              // 44: kvstore.v1.ImportCarRequest
+	(*ImportCarResponse)(nil),             // 45: kvstore.v1.ImportCarResponse
+	(*ExportCarRequest)(nil),              // 46: kvstore.v1.ExportCarRequest
+	(*ExportCarResponse)(nil),             // 47: kvstore.v1.ExportCarResponse
+	(*ProlongValueResponse)(nil),          // 48: kvstore.v1.ProlongValueResponse
+	(*StorageReceipt)(nil),                // 49: kvstore.v1.StorageReceipt
+	(*SignedStorageReceipt)(nil),          // 50: kvstore.v1.SignedStorageReceipt
+	(*ProlongDagRequest)(nil),             // 51: kvstore.v1.ProlongDagRequest
+	(*ProlongDagResponse)(nil),            // 52: kvstore.v1.ProlongDagResponse
+	(*ChallengeValueRequest)(nil),         // 53: kvstore.v1.ChallengeValueRequest
+	(*ChunkProof)(nil),                    // 54: kvstore.v1.ChunkProof
+	(*ChallengeValueResponse)(nil),        // 55: kvstore.v1.ChallengeValueResponse
+	(*Session)(nil),                       // 56: kvstore.v1.Session
+	(*CreateSessionRequest)(nil),          // 57: kvstore.v1.CreateSessionRequest
+	(*CreateSessionResponse)(nil),         // 58: kvstore.v1.CreateSessionResponse
+	(*RevokeSessionRequest)(nil),          // 59: kvstore.v1.RevokeSessionRequest
+	(*SessionRefund)(nil),                 // 60: kvstore.v1.SessionRefund
+	(*RevokeSessionResponse)(nil),         // 61: kvstore.v1.RevokeSessionResponse
+	(*CloseSessionRequest)(nil),           // 62: kvstore.v1.CloseSessionRequest
+	(*CloseSessionResponse)(nil),          // 63: kvstore.v1.CloseSessionResponse
+	(*BatchGetValuesResponse_Result)(nil), // 64: kvstore.v1.BatchGetValuesResponse.Result
+	(*StatValuesResponse_Result)(nil),     // 65: kvstore.v1.StatValuesResponse.Result
+	(*timestamppb.Timestamp)(nil),         // 66: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),           // 67: google.protobuf.Duration
 }
 var file_kvstore_v1_kvstore_proto_depIdxs = []int32{
-	19, // 0: kvstore.v1.ProviderResult.provider:type_name -> kvstore.v1.Instance
-	4,  // 1: kvstore.v1.MultihashResult.provider_results:type_name -> kvstore.v1.ProviderResult
-	19, // 2: kvstore.v1.DelegatedRoutingResponse.providers:type_name -> kvstore.v1.Instance
-	10, // 3: kvstore.v1.VirtualService.behavior_link:type_name -> kvstore.v1.GlobalLink
-	10, // 4: kvstore.v1.VirtualService.variant_link:type_name -> kvstore.v1.GlobalLink
-	19, // 5: kvstore.v1.ProviderAdvertise.provider_instance:type_name -> kvstore.v1.Instance
-	11, // 6: kvstore.v1.ProviderAdvertise.virtual_service:type_name -> kvstore.v1.VirtualService
-	0,  // 7: kvstore.v1.ProviderAdvertise.coin_type:type_name -> kvstore.v1.CoinType
-	1,  // 8: kvstore.v1.ProviderAdvertise.coin_environment:type_name -> kvstore.v1.CoinEnvironment
-	19, // 9: kvstore.v1.ProviderAdvertise.exchanges:type_name -> kvstore.v1.Instance
-	36, // 10: kvstore.v1.ProviderAdvertise.expire_time:type_name -> google.protobuf.Timestamp
-	36, // 11: kvstore.v1.ProviderAdvertise.update_time:type_name -> google.protobuf.Timestamp
-	11, // 12: kvstore.v1.SearchCidResponse.virtual_services:type_name -> kvstore.v1.VirtualService
-	12, // 13: kvstore.v1.SearchCidResponse.storage_instances:type_name -> kvstore.v1.ProviderAdvertise
-	11, // 14: kvstore.v1.SearchInstanceRequest.virtual_service:type_name -> kvstore.v1.VirtualService
-	11, // 15: kvstore.v1.SearchInstanceResponse.virtual_service:type_name -> kvstore.v1.VirtualService
-	12, // 16: kvstore.v1.SearchInstanceResponse.instance_price_info:type_name -> kvstore.v1.ProviderAdvertise
-	12, // 17: kvstore.v1.RegisterInstanceRequest.advertisement:type_name -> kvstore.v1.ProviderAdvertise
-	3,  // 18: kvstore.v1.CreateValueRequest.codec:type_name -> kvstore.v1.CreateValueRequest.Codec
-	37, // 19: kvstore.v1.CreateValueRequest.ttl:type_name -> google.protobuf.Duration
-	37, // 20: kvstore.v1.CreateValueResponse.ttl:type_name -> google.protobuf.Duration
-	37, // 21: kvstore.v1.CreateStreamValueResponse.ttl:type_name -> google.protobuf.Duration
-	25, // 22: kvstore.v1.GetStreamValueResponse.stream_value_info:type_name -> kvstore.v1.StreamValueInfo
-	25, // 23: kvstore.v1.ListStreamValuesResponse.stream_value_info:type_name -> kvstore.v1.StreamValueInfo
-	37, // 24: kvstore.v1.ProlongValueRequest.ttl:type_name -> google.protobuf.Duration
-	37, // 25: kvstore.v1.ProlongValueResponse.ttl:type_name -> google.protobuf.Duration
-	33, // 26: kvstore.v1.CreateSessionResponse.session:type_name -> kvstore.v1.Session
-	20, // 27: kvstore.v1.KvStoreService.CreateValue:input_type -> kvstore.v1.CreateValueRequest
-	22, // 28: kvstore.v1.KvStoreService.CreateStreamValue:input_type -> kvstore.v1.CreateStreamValueRequest
-	29, // 29: kvstore.v1.KvStoreService.GetValue:input_type -> kvstore.v1.GetValueRequest
-	24, // 30: kvstore.v1.KvStoreService.GetStreamValue:input_type -> kvstore.v1.GetStreamValueRequest
-	27, // 31: kvstore.v1.KvStoreService.ListStreamValues:input_type -> kvstore.v1.ListStreamValuesRequest
-	31, // 32: kvstore.v1.KvStoreService.ProlongValue:input_type -> kvstore.v1.ProlongValueRequest
-	13, // 33: kvstore.v1.KvStoreService.SearchCid:input_type -> kvstore.v1.SearchCidRequest
-	15, // 34: kvstore.v1.KvStoreService.SearchInstance:input_type -> kvstore.v1.SearchInstanceRequest
-	34, // 35: kvstore.v1.KvStoreService.CreateSession:input_type -> kvstore.v1.CreateSessionRequest
-	17, // 36: kvstore.v1.KvStoreService.RegisterInstance:input_type -> kvstore.v1.RegisterInstanceRequest
-	8,  // 37: kvstore.v1.KvStoreService.Ping:input_type -> kvstore.v1.PingRequest
-	7,  // 38: kvstore.v1.KvStoreService.DelegatedRouting:input_type -> kvstore.v1.DelegatedRoutingRequest
-	21, // 39: kvstore.v1.KvStoreService.CreateValue:output_type -> kvstore.v1.CreateValueResponse
-	23, // 40: kvstore.v1.KvStoreService.CreateStreamValue:output_type -> kvstore.v1.CreateStreamValueResponse
-	30, // 41: kvstore.v1.KvStoreService.GetValue:output_type -> kvstore.v1.GetValueResponse
-	26, // 42: kvstore.v1.KvStoreService.GetStreamValue:output_type -> kvstore.v1.GetStreamValueResponse
-	28, // 43: kvstore.v1.KvStoreService.ListStreamValues:output_type -> kvstore.v1.ListStreamValuesResponse
-	32, // 44: kvstore.v1.KvStoreService.ProlongValue:output_type -> kvstore.v1.ProlongValueResponse
-	14, // 45: kvstore.v1.KvStoreService.SearchCid:output_type -> kvstore.v1.SearchCidResponse
-	16, // 46: kvstore.v1.KvStoreService.SearchInstance:output_type -> kvstore.v1.SearchInstanceResponse
-	35, // 47: kvstore.v1.KvStoreService.CreateSession:output_type -> kvstore.v1.CreateSessionResponse
-	18, // 48: kvstore.v1.KvStoreService.RegisterInstance:output_type -> kvstore.v1.RegisterInstanceResponse
-	9,  // 49: kvstore.v1.KvStoreService.Ping:output_type -> kvstore.v1.PingResponse
-	6,  // 50: kvstore.v1.KvStoreService.DelegatedRouting:output_type -> kvstore.v1.DelegatedRoutingResponse
-	39, // [39:51] is the sub-list for method output_type
-	27, // [27:39] is the sub-list for method input_type
-	27, // [27:27] is the sub-list for extension type_name
-	27, // [27:27] is the sub-list for extension extendee
-	0,  // [0:27] is the sub-list for field type_name
+	6,  // 0: kvstore.v1.DelegatedRoutingResponse.providers:type_name -> kvstore.v1.PeerRecord
+	11, // 1: kvstore.v1.VirtualService.behavior_link:type_name -> kvstore.v1.GlobalLink
+	11, // 2: kvstore.v1.VirtualService.variant_link:type_name -> kvstore.v1.GlobalLink
+	21, // 3: kvstore.v1.ProviderAdvertise.provider_instance:type_name -> kvstore.v1.Instance
+	12, // 4: kvstore.v1.ProviderAdvertise.virtual_service:type_name -> kvstore.v1.VirtualService
+	0,  // 5: kvstore.v1.ProviderAdvertise.coin_type:type_name -> kvstore.v1.CoinType
+	1,  // 6: kvstore.v1.ProviderAdvertise.coin_environment:type_name -> kvstore.v1.CoinEnvironment
+	21, // 7: kvstore.v1.ProviderAdvertise.exchanges:type_name -> kvstore.v1.Instance
+	66, // 8: kvstore.v1.ProviderAdvertise.expire_time:type_name -> google.protobuf.Timestamp
+	66, // 9: kvstore.v1.ProviderAdvertise.update_time:type_name -> google.protobuf.Timestamp
+	15, // 10: kvstore.v1.ProviderAdvertise.inclusion_proofs:type_name -> kvstore.v1.MerkleInclusionProof
+	12, // 11: kvstore.v1.SearchCidResponse.virtual_services:type_name -> kvstore.v1.VirtualService
+	13, // 12: kvstore.v1.SearchCidResponse.storage_instances:type_name -> kvstore.v1.ProviderAdvertise
+	15, // 13: kvstore.v1.SearchCidResponse.inclusion_proofs:type_name -> kvstore.v1.MerkleInclusionProof
+	12, // 14: kvstore.v1.SearchInstanceRequest.virtual_service:type_name -> kvstore.v1.VirtualService
+	12, // 15: kvstore.v1.SearchInstanceResponse.virtual_service:type_name -> kvstore.v1.VirtualService
+	13, // 16: kvstore.v1.SearchInstanceResponse.instance_price_info:type_name -> kvstore.v1.ProviderAdvertise
+	13, // 17: kvstore.v1.RegisterInstanceRequest.advertisement:type_name -> kvstore.v1.ProviderAdvertise
+	4,  // 18: kvstore.v1.CreateValueRequest.codec:type_name -> kvstore.v1.CreateValueRequest.Codec
+	67, // 19: kvstore.v1.CreateValueRequest.ttl:type_name -> google.protobuf.Duration
+	5,  // 20: kvstore.v1.CreateValueRequest.hash_function:type_name -> kvstore.v1.CreateValueRequest.HashFunction
+	67, // 21: kvstore.v1.CreateValueResponse.ttl:type_name -> google.protobuf.Duration
+	50, // 22: kvstore.v1.CreateValueResponse.receipt:type_name -> kvstore.v1.SignedStorageReceipt
+	67, // 23: kvstore.v1.CreateStreamValueResponse.ttl:type_name -> google.protobuf.Duration
+	27, // 24: kvstore.v1.GetStreamValueResponse.stream_value_info:type_name -> kvstore.v1.StreamValueInfo
+	2,  // 25: kvstore.v1.DelegateStreamAccessRequest.operations:type_name -> kvstore.v1.StreamOperation
+	67, // 26: kvstore.v1.DelegateStreamAccessRequest.ttl:type_name -> google.protobuf.Duration
+	66, // 27: kvstore.v1.DelegateStreamAccessResponse.expire_time:type_name -> google.protobuf.Timestamp
+	27, // 28: kvstore.v1.ListStreamValuesResponse.stream_value_info:type_name -> kvstore.v1.StreamValueInfo
+	66, // 29: kvstore.v1.ValueMetadata.expire_time:type_name -> google.protobuf.Timestamp
+	66, // 30: kvstore.v1.ValueMetadata.create_time:type_name -> google.protobuf.Timestamp
+	64, // 31: kvstore.v1.BatchGetValuesResponse.results:type_name -> kvstore.v1.BatchGetValuesResponse.Result
+	67, // 32: kvstore.v1.ValueStat.ttl:type_name -> google.protobuf.Duration
+	65, // 33: kvstore.v1.StatValuesResponse.results:type_name -> kvstore.v1.StatValuesResponse.Result
+	67, // 34: kvstore.v1.ProlongValueRequest.ttl:type_name -> google.protobuf.Duration
+	67, // 35: kvstore.v1.ImportCarRequest.ttl:type_name -> google.protobuf.Duration
+	67, // 36: kvstore.v1.ImportCarResponse.ttl:type_name -> google.protobuf.Duration
+	67, // 37: kvstore.v1.ProlongValueResponse.ttl:type_name -> google.protobuf.Duration
+	50, // 38: kvstore.v1.ProlongValueResponse.receipt:type_name -> kvstore.v1.SignedStorageReceipt
+	66, // 39: kvstore.v1.StorageReceipt.expire_time:type_name -> google.protobuf.Timestamp
+	66, // 40: kvstore.v1.StorageReceipt.issue_time:type_name -> google.protobuf.Timestamp
+	67, // 41: kvstore.v1.ProlongDagRequest.ttl:type_name -> google.protobuf.Duration
+	54, // 42: kvstore.v1.ChallengeValueResponse.chunk_proofs:type_name -> kvstore.v1.ChunkProof
+	56, // 43: kvstore.v1.CreateSessionResponse.session:type_name -> kvstore.v1.Session
+	66, // 44: kvstore.v1.SessionRefund.revoke_time:type_name -> google.protobuf.Timestamp
+	60, // 45: kvstore.v1.RevokeSessionResponse.refund:type_name -> kvstore.v1.SessionRefund
+	60, // 46: kvstore.v1.CloseSessionResponse.refund:type_name -> kvstore.v1.SessionRefund
+	37, // 47: kvstore.v1.BatchGetValuesResponse.Result.status:type_name -> kvstore.v1.ResultStatus
+	41, // 48: kvstore.v1.StatValuesResponse.Result.stat:type_name -> kvstore.v1.ValueStat
+	37, // 49: kvstore.v1.StatValuesResponse.Result.status:type_name -> kvstore.v1.ResultStatus
+	22, // 50: kvstore.v1.KvStoreService.CreateValue:input_type -> kvstore.v1.CreateValueRequest
+	24, // 51: kvstore.v1.KvStoreService.CreateStreamValue:input_type -> kvstore.v1.CreateStreamValueRequest
+	33, // 52: kvstore.v1.KvStoreService.GetValue:input_type -> kvstore.v1.GetValueRequest
+	35, // 53: kvstore.v1.KvStoreService.GetValueMetadata:input_type -> kvstore.v1.GetValueMetadataRequest
+	38, // 54: kvstore.v1.KvStoreService.BatchGetValues:input_type -> kvstore.v1.BatchGetValuesRequest
+	40, // 55: kvstore.v1.KvStoreService.StatValues:input_type -> kvstore.v1.StatValuesRequest
+	26, // 56: kvstore.v1.KvStoreService.GetStreamValue:input_type -> kvstore.v1.GetStreamValueRequest
+	29, // 57: kvstore.v1.KvStoreService.ListStreamValues:input_type -> kvstore.v1.ListStreamValuesRequest
+	30, // 58: kvstore.v1.KvStoreService.DelegateStreamAccess:input_type -> kvstore.v1.DelegateStreamAccessRequest
+	43, // 59: kvstore.v1.KvStoreService.ProlongValue:input_type -> kvstore.v1.ProlongValueRequest
+	51, // 60: kvstore.v1.KvStoreService.ProlongDag:input_type -> kvstore.v1.ProlongDagRequest
+	53, // 61: kvstore.v1.KvStoreService.ChallengeValue:input_type -> kvstore.v1.ChallengeValueRequest
+	14, // 62: kvstore.v1.KvStoreService.SearchCid:input_type -> kvstore.v1.SearchCidRequest
+	17, // 63: kvstore.v1.KvStoreService.SearchInstance:input_type -> kvstore.v1.SearchInstanceRequest
+	57, // 64: kvstore.v1.KvStoreService.CreateSession:input_type -> kvstore.v1.CreateSessionRequest
+	59, // 65: kvstore.v1.KvStoreService.RevokeSession:input_type -> kvstore.v1.RevokeSessionRequest
+	62, // 66: kvstore.v1.KvStoreService.CloseSession:input_type -> kvstore.v1.CloseSessionRequest
+	19, // 67: kvstore.v1.KvStoreService.RegisterInstance:input_type -> kvstore.v1.RegisterInstanceRequest
+	9,  // 68: kvstore.v1.KvStoreService.Ping:input_type -> kvstore.v1.PingRequest
+	44, // 69: kvstore.v1.KvStoreService.ImportCar:input_type -> kvstore.v1.ImportCarRequest
+	46, // 70: kvstore.v1.KvStoreService.ExportCar:input_type -> kvstore.v1.ExportCarRequest
+	8,  // 71: kvstore.v1.KvStoreService.DelegatedRouting:input_type -> kvstore.v1.DelegatedRoutingRequest
+	23, // 72: kvstore.v1.KvStoreService.CreateValue:output_type -> kvstore.v1.CreateValueResponse
+	25, // 73: kvstore.v1.KvStoreService.CreateStreamValue:output_type -> kvstore.v1.CreateStreamValueResponse
+	34, // 74: kvstore.v1.KvStoreService.GetValue:output_type -> kvstore.v1.GetValueResponse
+	36, // 75: kvstore.v1.KvStoreService.GetValueMetadata:output_type -> kvstore.v1.ValueMetadata
+	39, // 76: kvstore.v1.KvStoreService.BatchGetValues:output_type -> kvstore.v1.BatchGetValuesResponse
+	42, // 77: kvstore.v1.KvStoreService.StatValues:output_type -> kvstore.v1.StatValuesResponse
+	28, // 78: kvstore.v1.KvStoreService.GetStreamValue:output_type -> kvstore.v1.GetStreamValueResponse
+	32, // 79: kvstore.v1.KvStoreService.ListStreamValues:output_type -> kvstore.v1.ListStreamValuesResponse
+	31, // 80: kvstore.v1.KvStoreService.DelegateStreamAccess:output_type -> kvstore.v1.DelegateStreamAccessResponse
+	48, // 81: kvstore.v1.KvStoreService.ProlongValue:output_type -> kvstore.v1.ProlongValueResponse
+	52, // 82: kvstore.v1.KvStoreService.ProlongDag:output_type -> kvstore.v1.ProlongDagResponse
+	55, // 83: kvstore.v1.KvStoreService.ChallengeValue:output_type -> kvstore.v1.ChallengeValueResponse
+	16, // 84: kvstore.v1.KvStoreService.SearchCid:output_type -> kvstore.v1.SearchCidResponse
+	18, // 85: kvstore.v1.KvStoreService.SearchInstance:output_type -> kvstore.v1.SearchInstanceResponse
+	58, // 86: kvstore.v1.KvStoreService.CreateSession:output_type -> kvstore.v1.CreateSessionResponse
+	61, // 87: kvstore.v1.KvStoreService.RevokeSession:output_type -> kvstore.v1.RevokeSessionResponse
+	63, // 88: kvstore.v1.KvStoreService.CloseSession:output_type -> kvstore.v1.CloseSessionResponse
+	20, // 89: kvstore.v1.KvStoreService.RegisterInstance:output_type -> kvstore.v1.RegisterInstanceResponse
+	10, // 90: kvstore.v1.KvStoreService.Ping:output_type -> kvstore.v1.PingResponse
+	45, // 91: kvstore.v1.KvStoreService.ImportCar:output_type -> kvstore.v1.ImportCarResponse
+	47, // 92: kvstore.v1.KvStoreService.ExportCar:output_type -> kvstore.v1.ExportCarResponse
+	7,  // 93: kvstore.v1.KvStoreService.DelegatedRouting:output_type -> kvstore.v1.DelegatedRoutingResponse
+	72, // [72:94] is the sub-list for method output_type
+	50, // [50:72] is the sub-list for method input_type
+	50, // [50:50] is the sub-list for extension type_name
+	50, // [50:50] is the sub-list for extension extendee
+	0,  // [0:50] is the sub-list for field type_name
 }
 
 func init() { file_kvstore_v1_kvstore_proto_init() }
@@ -2220,8 +4416,8 @@ func file_kvstore_v1_kvstore_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kvstore_v1_kvstore_proto_rawDesc), len(file_kvstore_v1_kvstore_proto_rawDesc)),
-			NumEnums:      4,
-			NumMessages:   32,
+			NumEnums:      6,
+			NumMessages:   60,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,18 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KvStoreService_CreateValue_FullMethodName       = "/kvstore.v1.KvStoreService/CreateValue"
-	KvStoreService_CreateStreamValue_FullMethodName = "/kvstore.v1.KvStoreService/CreateStreamValue"
-	KvStoreService_GetValue_FullMethodName          = "/kvstore.v1.KvStoreService/GetValue"
-	KvStoreService_GetStreamValue_FullMethodName    = "/kvstore.v1.KvStoreService/GetStreamValue"
-	KvStoreService_ListStreamValues_FullMethodName  = "/kvstore.v1.KvStoreService/ListStreamValues"
-	KvStoreService_ProlongValue_FullMethodName      = "/kvstore.v1.KvStoreService/ProlongValue"
-	KvStoreService_SearchCid_FullMethodName         = "/kvstore.v1.KvStoreService/SearchCid"
-	KvStoreService_SearchInstance_FullMethodName    = "/kvstore.v1.KvStoreService/SearchInstance"
-	KvStoreService_CreateSession_FullMethodName     = "/kvstore.v1.KvStoreService/CreateSession"
-	KvStoreService_RegisterInstance_FullMethodName  = "/kvstore.v1.KvStoreService/RegisterInstance"
-	KvStoreService_Ping_FullMethodName              = "/kvstore.v1.KvStoreService/Ping"
-	KvStoreService_DelegatedRouting_FullMethodName  = "/kvstore.v1.KvStoreService/DelegatedRouting"
+	KvStoreService_CreateValue_FullMethodName          = "/kvstore.v1.KvStoreService/CreateValue"
+	KvStoreService_CreateStreamValue_FullMethodName    = "/kvstore.v1.KvStoreService/CreateStreamValue"
+	KvStoreService_GetValue_FullMethodName             = "/kvstore.v1.KvStoreService/GetValue"
+	KvStoreService_GetValueMetadata_FullMethodName     = "/kvstore.v1.KvStoreService/GetValueMetadata"
+	KvStoreService_BatchGetValues_FullMethodName       = "/kvstore.v1.KvStoreService/BatchGetValues"
+	KvStoreService_StatValues_FullMethodName           = "/kvstore.v1.KvStoreService/StatValues"
+	KvStoreService_GetStreamValue_FullMethodName       = "/kvstore.v1.KvStoreService/GetStreamValue"
+	KvStoreService_ListStreamValues_FullMethodName     = "/kvstore.v1.KvStoreService/ListStreamValues"
+	KvStoreService_DelegateStreamAccess_FullMethodName = "/kvstore.v1.KvStoreService/DelegateStreamAccess"
+	KvStoreService_ProlongValue_FullMethodName         = "/kvstore.v1.KvStoreService/ProlongValue"
+	KvStoreService_ProlongDag_FullMethodName           = "/kvstore.v1.KvStoreService/ProlongDag"
+	KvStoreService_ChallengeValue_FullMethodName       = "/kvstore.v1.KvStoreService/ChallengeValue"
+	KvStoreService_SearchCid_FullMethodName            = "/kvstore.v1.KvStoreService/SearchCid"
+	KvStoreService_SearchInstance_FullMethodName       = "/kvstore.v1.KvStoreService/SearchInstance"
+	KvStoreService_CreateSession_FullMethodName        = "/kvstore.v1.KvStoreService/CreateSession"
+	KvStoreService_RevokeSession_FullMethodName        = "/kvstore.v1.KvStoreService/RevokeSession"
+	KvStoreService_CloseSession_FullMethodName         = "/kvstore.v1.KvStoreService/CloseSession"
+	KvStoreService_RegisterInstance_FullMethodName     = "/kvstore.v1.KvStoreService/RegisterInstance"
+	KvStoreService_Ping_FullMethodName                 = "/kvstore.v1.KvStoreService/Ping"
+	KvStoreService_ImportCar_FullMethodName            = "/kvstore.v1.KvStoreService/ImportCar"
+	KvStoreService_ExportCar_FullMethodName            = "/kvstore.v1.KvStoreService/ExportCar"
+	KvStoreService_DelegatedRouting_FullMethodName     = "/kvstore.v1.KvStoreService/DelegatedRouting"
 )
 
 // KvStoreServiceClient is the client API for KvStoreService service.
@@ -40,14 +50,32 @@ type KvStoreServiceClient interface {
 	CreateValue(ctx context.Context, in *CreateValueRequest, opts ...grpc.CallOption) (*CreateValueResponse, error)
 	CreateStreamValue(ctx context.Context, in *CreateStreamValueRequest, opts ...grpc.CallOption) (*CreateStreamValueResponse, error)
 	GetValue(ctx context.Context, in *GetValueRequest, opts ...grpc.CallOption) (*GetValueResponse, error)
+	GetValueMetadata(ctx context.Context, in *GetValueMetadataRequest, opts ...grpc.CallOption) (*ValueMetadata, error)
+	BatchGetValues(ctx context.Context, in *BatchGetValuesRequest, opts ...grpc.CallOption) (*BatchGetValuesResponse, error)
+	StatValues(ctx context.Context, in *StatValuesRequest, opts ...grpc.CallOption) (*StatValuesResponse, error)
 	GetStreamValue(ctx context.Context, in *GetStreamValueRequest, opts ...grpc.CallOption) (*GetStreamValueResponse, error)
 	ListStreamValues(ctx context.Context, in *ListStreamValuesRequest, opts ...grpc.CallOption) (*ListStreamValuesResponse, error)
+	// Lets a delegate read a stream with its own identity token, for the
+	// operations and time granted by the owner or a delegate further down
+	DelegateStreamAccess(ctx context.Context, in *DelegateStreamAccessRequest, opts ...grpc.CallOption) (*DelegateStreamAccessResponse, error)
 	ProlongValue(ctx context.Context, in *ProlongValueRequest, opts ...grpc.CallOption) (*ProlongValueResponse, error)
+	ProlongDag(ctx context.Context, in *ProlongDagRequest, opts ...grpc.CallOption) (*ProlongDagResponse, error)
+	ChallengeValue(ctx context.Context, in *ChallengeValueRequest, opts ...grpc.CallOption) (*ChallengeValueResponse, error)
 	SearchCid(ctx context.Context, in *SearchCidRequest, opts ...grpc.CallOption) (*SearchCidResponse, error)
 	SearchInstance(ctx context.Context, in *SearchInstanceRequest, opts ...grpc.CallOption) (*SearchInstanceResponse, error)
 	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
+	// Revokes the session of the bearer token, like a logout
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
+	// Closes the session of the bearer token and returns its balance left as
+	// a voucher redeemable at the exchange which issued the quota token.
+	// Sessions already revoked get the voucher of their recorded refund.
+	CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error)
 	RegisterInstance(ctx context.Context, in *RegisterInstanceRequest, opts ...grpc.CallOption) (*RegisterInstanceResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	// Stores every block of a CARv1 or CARv2 file uploaded in chunks
+	ImportCar(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCarRequest, ImportCarResponse], error)
+	// Streams a CARv1 file of the dag-pb dag below a stored value
+	ExportCar(ctx context.Context, in *ExportCarRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportCarResponse], error)
 	DelegatedRouting(ctx context.Context, in *DelegatedRoutingRequest, opts ...grpc.CallOption) (*DelegatedRoutingResponse, error)
 }
 
@@ -89,6 +117,36 @@ func (c *kvStoreServiceClient) GetValue(ctx context.Context, in *GetValueRequest
 	return out, nil
 }
 
+func (c *kvStoreServiceClient) GetValueMetadata(ctx context.Context, in *GetValueMetadataRequest, opts ...grpc.CallOption) (*ValueMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValueMetadata)
+	err := c.cc.Invoke(ctx, KvStoreService_GetValueMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreServiceClient) BatchGetValues(ctx context.Context, in *BatchGetValuesRequest, opts ...grpc.CallOption) (*BatchGetValuesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetValuesResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_BatchGetValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreServiceClient) StatValues(ctx context.Context, in *StatValuesRequest, opts ...grpc.CallOption) (*StatValuesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatValuesResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_StatValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kvStoreServiceClient) GetStreamValue(ctx context.Context, in *GetStreamValueRequest, opts ...grpc.CallOption) (*GetStreamValueResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetStreamValueResponse)
@@ -109,6 +167,16 @@ func (c *kvStoreServiceClient) ListStreamValues(ctx context.Context, in *ListStr
 	return out, nil
 }
 
+func (c *kvStoreServiceClient) DelegateStreamAccess(ctx context.Context, in *DelegateStreamAccessRequest, opts ...grpc.CallOption) (*DelegateStreamAccessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DelegateStreamAccessResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_DelegateStreamAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kvStoreServiceClient) ProlongValue(ctx context.Context, in *ProlongValueRequest, opts ...grpc.CallOption) (*ProlongValueResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ProlongValueResponse)
@@ -119,6 +187,26 @@ func (c *kvStoreServiceClient) ProlongValue(ctx context.Context, in *ProlongValu
 	return out, nil
 }
 
+func (c *kvStoreServiceClient) ProlongDag(ctx context.Context, in *ProlongDagRequest, opts ...grpc.CallOption) (*ProlongDagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProlongDagResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_ProlongDag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreServiceClient) ChallengeValue(ctx context.Context, in *ChallengeValueRequest, opts ...grpc.CallOption) (*ChallengeValueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChallengeValueResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_ChallengeValue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kvStoreServiceClient) SearchCid(ctx context.Context, in *SearchCidRequest, opts ...grpc.CallOption) (*SearchCidResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SearchCidResponse)
@@ -149,6 +237,26 @@ func (c *kvStoreServiceClient) CreateSession(ctx context.Context, in *CreateSess
 	return out, nil
 }
 
+func (c *kvStoreServiceClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeSessionResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_RevokeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvStoreServiceClient) CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseSessionResponse)
+	err := c.cc.Invoke(ctx, KvStoreService_CloseSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kvStoreServiceClient) RegisterInstance(ctx context.Context, in *RegisterInstanceRequest, opts ...grpc.CallOption) (*RegisterInstanceResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RegisterInstanceResponse)
@@ -169,6 +277,38 @@ func (c *kvStoreServiceClient) Ping(ctx context.Context, in *PingRequest, opts .
 	return out, nil
 }
 
+func (c *kvStoreServiceClient) ImportCar(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCarRequest, ImportCarResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStoreService_ServiceDesc.Streams[0], KvStoreService_ImportCar_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportCarRequest, ImportCarResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStoreService_ImportCarClient = grpc.ClientStreamingClient[ImportCarRequest, ImportCarResponse]
+
+func (c *kvStoreServiceClient) ExportCar(ctx context.Context, in *ExportCarRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportCarResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KvStoreService_ServiceDesc.Streams[1], KvStoreService_ExportCar_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportCarRequest, ExportCarResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStoreService_ExportCarClient = grpc.ServerStreamingClient[ExportCarResponse]
+
 func (c *kvStoreServiceClient) DelegatedRouting(ctx context.Context, in *DelegatedRoutingRequest, opts ...grpc.CallOption) (*DelegatedRoutingResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DelegatedRoutingResponse)
@@ -186,14 +326,32 @@ type KvStoreServiceServer interface {
 	CreateValue(context.Context, *CreateValueRequest) (*CreateValueResponse, error)
 	CreateStreamValue(context.Context, *CreateStreamValueRequest) (*CreateStreamValueResponse, error)
 	GetValue(context.Context, *GetValueRequest) (*GetValueResponse, error)
+	GetValueMetadata(context.Context, *GetValueMetadataRequest) (*ValueMetadata, error)
+	BatchGetValues(context.Context, *BatchGetValuesRequest) (*BatchGetValuesResponse, error)
+	StatValues(context.Context, *StatValuesRequest) (*StatValuesResponse, error)
 	GetStreamValue(context.Context, *GetStreamValueRequest) (*GetStreamValueResponse, error)
 	ListStreamValues(context.Context, *ListStreamValuesRequest) (*ListStreamValuesResponse, error)
+	// Lets a delegate read a stream with its own identity token, for the
+	// operations and time granted by the owner or a delegate further down
+	DelegateStreamAccess(context.Context, *DelegateStreamAccessRequest) (*DelegateStreamAccessResponse, error)
 	ProlongValue(context.Context, *ProlongValueRequest) (*ProlongValueResponse, error)
+	ProlongDag(context.Context, *ProlongDagRequest) (*ProlongDagResponse, error)
+	ChallengeValue(context.Context, *ChallengeValueRequest) (*ChallengeValueResponse, error)
 	SearchCid(context.Context, *SearchCidRequest) (*SearchCidResponse, error)
 	SearchInstance(context.Context, *SearchInstanceRequest) (*SearchInstanceResponse, error)
 	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
+	// Revokes the session of the bearer token, like a logout
+	RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error)
+	// Closes the session of the bearer token and returns its balance left as
+	// a voucher redeemable at the exchange which issued the quota token.
+	// Sessions already revoked get the voucher of their recorded refund.
+	CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error)
 	RegisterInstance(context.Context, *RegisterInstanceRequest) (*RegisterInstanceResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	// Stores every block of a CARv1 or CARv2 file uploaded in chunks
+	ImportCar(grpc.ClientStreamingServer[ImportCarRequest, ImportCarResponse]) error
+	// Streams a CARv1 file of the dag-pb dag below a stored value
+	ExportCar(*ExportCarRequest, grpc.ServerStreamingServer[ExportCarResponse]) error
 	DelegatedRouting(context.Context, *DelegatedRoutingRequest) (*DelegatedRoutingResponse, error)
 	mustEmbedUnimplementedKvStoreServiceServer()
 }
@@ -214,15 +372,33 @@ func (UnimplementedKvStoreServiceServer) CreateStreamValue(context.Context, *Cre
 func (UnimplementedKvStoreServiceServer) GetValue(context.Context, *GetValueRequest) (*GetValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetValue not implemented")
 }
+func (UnimplementedKvStoreServiceServer) GetValueMetadata(context.Context, *GetValueMetadataRequest) (*ValueMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetValueMetadata not implemented")
+}
+func (UnimplementedKvStoreServiceServer) BatchGetValues(context.Context, *BatchGetValuesRequest) (*BatchGetValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetValues not implemented")
+}
+func (UnimplementedKvStoreServiceServer) StatValues(context.Context, *StatValuesRequest) (*StatValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatValues not implemented")
+}
 func (UnimplementedKvStoreServiceServer) GetStreamValue(context.Context, *GetStreamValueRequest) (*GetStreamValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStreamValue not implemented")
 }
 func (UnimplementedKvStoreServiceServer) ListStreamValues(context.Context, *ListStreamValuesRequest) (*ListStreamValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListStreamValues not implemented")
 }
+func (UnimplementedKvStoreServiceServer) DelegateStreamAccess(context.Context, *DelegateStreamAccessRequest) (*DelegateStreamAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DelegateStreamAccess not implemented")
+}
 func (UnimplementedKvStoreServiceServer) ProlongValue(context.Context, *ProlongValueRequest) (*ProlongValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ProlongValue not implemented")
 }
+func (UnimplementedKvStoreServiceServer) ProlongDag(context.Context, *ProlongDagRequest) (*ProlongDagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProlongDag not implemented")
+}
+func (UnimplementedKvStoreServiceServer) ChallengeValue(context.Context, *ChallengeValueRequest) (*ChallengeValueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChallengeValue not implemented")
+}
 func (UnimplementedKvStoreServiceServer) SearchCid(context.Context, *SearchCidRequest) (*SearchCidResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchCid not implemented")
 }
@@ -232,12 +408,24 @@ func (UnimplementedKvStoreServiceServer) SearchInstance(context.Context, *Search
 func (UnimplementedKvStoreServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
 }
+func (UnimplementedKvStoreServiceServer) RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedKvStoreServiceServer) CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
 func (UnimplementedKvStoreServiceServer) RegisterInstance(context.Context, *RegisterInstanceRequest) (*RegisterInstanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterInstance not implemented")
 }
 func (UnimplementedKvStoreServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedKvStoreServiceServer) ImportCar(grpc.ClientStreamingServer[ImportCarRequest, ImportCarResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportCar not implemented")
+}
+func (UnimplementedKvStoreServiceServer) ExportCar(*ExportCarRequest, grpc.ServerStreamingServer[ExportCarResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportCar not implemented")
+}
 func (UnimplementedKvStoreServiceServer) DelegatedRouting(context.Context, *DelegatedRoutingRequest) (*DelegatedRoutingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DelegatedRouting not implemented")
 }
@@ -316,6 +504,60 @@ func _KvStoreService_GetValue_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KvStoreService_GetValueMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetValueMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).GetValueMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_GetValueMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).GetValueMetadata(ctx, req.(*GetValueMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStoreService_BatchGetValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).BatchGetValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_BatchGetValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).BatchGetValues(ctx, req.(*BatchGetValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStoreService_StatValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).StatValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_StatValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).StatValues(ctx, req.(*StatValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KvStoreService_GetStreamValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetStreamValueRequest)
 	if err := dec(in); err != nil {
@@ -352,6 +594,24 @@ func _KvStoreService_ListStreamValues_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KvStoreService_DelegateStreamAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelegateStreamAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).DelegateStreamAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_DelegateStreamAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).DelegateStreamAccess(ctx, req.(*DelegateStreamAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KvStoreService_ProlongValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ProlongValueRequest)
 	if err := dec(in); err != nil {
@@ -370,6 +630,42 @@ func _KvStoreService_ProlongValue_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KvStoreService_ProlongDag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProlongDagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).ProlongDag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_ProlongDag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).ProlongDag(ctx, req.(*ProlongDagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStoreService_ChallengeValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChallengeValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).ChallengeValue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_ChallengeValue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).ChallengeValue(ctx, req.(*ChallengeValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KvStoreService_SearchCid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SearchCidRequest)
 	if err := dec(in); err != nil {
@@ -424,6 +720,42 @@ func _KvStoreService_CreateSession_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KvStoreService_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvStoreService_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvStoreServiceServer).CloseSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KvStoreService_CloseSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvStoreServiceServer).CloseSession(ctx, req.(*CloseSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KvStoreService_RegisterInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RegisterInstanceRequest)
 	if err := dec(in); err != nil {
@@ -460,6 +792,24 @@ func _KvStoreService_Ping_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KvStoreService_ImportCar_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KvStoreServiceServer).ImportCar(&grpc.GenericServerStream[ImportCarRequest, ImportCarResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStoreService_ImportCarServer = grpc.ClientStreamingServer[ImportCarRequest, ImportCarResponse]
+
+func _KvStoreService_ExportCar_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportCarRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvStoreServiceServer).ExportCar(m, &grpc.GenericServerStream[ExportCarRequest, ExportCarResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KvStoreService_ExportCarServer = grpc.ServerStreamingServer[ExportCarResponse]
+
 func _KvStoreService_DelegatedRouting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DelegatedRoutingRequest)
 	if err := dec(in); err != nil {
@@ -497,6 +847,18 @@ var KvStoreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetValue",
 			Handler:    _KvStoreService_GetValue_Handler,
 		},
+		{
+			MethodName: "GetValueMetadata",
+			Handler:    _KvStoreService_GetValueMetadata_Handler,
+		},
+		{
+			MethodName: "BatchGetValues",
+			Handler:    _KvStoreService_BatchGetValues_Handler,
+		},
+		{
+			MethodName: "StatValues",
+			Handler:    _KvStoreService_StatValues_Handler,
+		},
 		{
 			MethodName: "GetStreamValue",
 			Handler:    _KvStoreService_GetStreamValue_Handler,
@@ -505,10 +867,22 @@ var KvStoreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListStreamValues",
 			Handler:    _KvStoreService_ListStreamValues_Handler,
 		},
+		{
+			MethodName: "DelegateStreamAccess",
+			Handler:    _KvStoreService_DelegateStreamAccess_Handler,
+		},
 		{
 			MethodName: "ProlongValue",
 			Handler:    _KvStoreService_ProlongValue_Handler,
 		},
+		{
+			MethodName: "ProlongDag",
+			Handler:    _KvStoreService_ProlongDag_Handler,
+		},
+		{
+			MethodName: "ChallengeValue",
+			Handler:    _KvStoreService_ChallengeValue_Handler,
+		},
 		{
 			MethodName: "SearchCid",
 			Handler:    _KvStoreService_SearchCid_Handler,
@@ -521,6 +895,14 @@ var KvStoreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateSession",
 			Handler:    _KvStoreService_CreateSession_Handler,
 		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _KvStoreService_RevokeSession_Handler,
+		},
+		{
+			MethodName: "CloseSession",
+			Handler:    _KvStoreService_CloseSession_Handler,
+		},
 		{
 			MethodName: "RegisterInstance",
 			Handler:    _KvStoreService_RegisterInstance_Handler,
@@ -534,6 +916,17 @@ var KvStoreService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _KvStoreService_DelegatedRouting_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportCar",
+			Handler:       _KvStoreService_ImportCar_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportCar",
+			Handler:       _KvStoreService_ExportCar_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "kvstore/v1/kvstore.proto",
 }
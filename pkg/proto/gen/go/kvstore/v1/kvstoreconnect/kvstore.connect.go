@@ -41,15 +41,33 @@ const (
 	KvStoreServiceCreateStreamValueProcedure = "/kvstore.v1.KvStoreService/CreateStreamValue"
 	// KvStoreServiceGetValueProcedure is the fully-qualified name of the KvStoreService's GetValue RPC.
 	KvStoreServiceGetValueProcedure = "/kvstore.v1.KvStoreService/GetValue"
+	// KvStoreServiceGetValueMetadataProcedure is the fully-qualified name of the KvStoreService's
+	// GetValueMetadata RPC.
+	KvStoreServiceGetValueMetadataProcedure = "/kvstore.v1.KvStoreService/GetValueMetadata"
+	// KvStoreServiceBatchGetValuesProcedure is the fully-qualified name of the KvStoreService's
+	// BatchGetValues RPC.
+	KvStoreServiceBatchGetValuesProcedure = "/kvstore.v1.KvStoreService/BatchGetValues"
+	// KvStoreServiceStatValuesProcedure is the fully-qualified name of the KvStoreService's StatValues
+	// RPC.
+	KvStoreServiceStatValuesProcedure = "/kvstore.v1.KvStoreService/StatValues"
 	// KvStoreServiceGetStreamValueProcedure is the fully-qualified name of the KvStoreService's
 	// GetStreamValue RPC.
 	KvStoreServiceGetStreamValueProcedure = "/kvstore.v1.KvStoreService/GetStreamValue"
 	// KvStoreServiceListStreamValuesProcedure is the fully-qualified name of the KvStoreService's
 	// ListStreamValues RPC.
 	KvStoreServiceListStreamValuesProcedure = "/kvstore.v1.KvStoreService/ListStreamValues"
+	// KvStoreServiceDelegateStreamAccessProcedure is the fully-qualified name of the KvStoreService's
+	// DelegateStreamAccess RPC.
+	KvStoreServiceDelegateStreamAccessProcedure = "/kvstore.v1.KvStoreService/DelegateStreamAccess"
 	// KvStoreServiceProlongValueProcedure is the fully-qualified name of the KvStoreService's
 	// ProlongValue RPC.
 	KvStoreServiceProlongValueProcedure = "/kvstore.v1.KvStoreService/ProlongValue"
+	// KvStoreServiceProlongDagProcedure is the fully-qualified name of the KvStoreService's ProlongDag
+	// RPC.
+	KvStoreServiceProlongDagProcedure = "/kvstore.v1.KvStoreService/ProlongDag"
+	// KvStoreServiceChallengeValueProcedure is the fully-qualified name of the KvStoreService's
+	// ChallengeValue RPC.
+	KvStoreServiceChallengeValueProcedure = "/kvstore.v1.KvStoreService/ChallengeValue"
 	// KvStoreServiceSearchCidProcedure is the fully-qualified name of the KvStoreService's SearchCid
 	// RPC.
 	KvStoreServiceSearchCidProcedure = "/kvstore.v1.KvStoreService/SearchCid"
@@ -59,11 +77,23 @@ const (
 	// KvStoreServiceCreateSessionProcedure is the fully-qualified name of the KvStoreService's
 	// CreateSession RPC.
 	KvStoreServiceCreateSessionProcedure = "/kvstore.v1.KvStoreService/CreateSession"
+	// KvStoreServiceRevokeSessionProcedure is the fully-qualified name of the KvStoreService's
+	// RevokeSession RPC.
+	KvStoreServiceRevokeSessionProcedure = "/kvstore.v1.KvStoreService/RevokeSession"
+	// KvStoreServiceCloseSessionProcedure is the fully-qualified name of the KvStoreService's
+	// CloseSession RPC.
+	KvStoreServiceCloseSessionProcedure = "/kvstore.v1.KvStoreService/CloseSession"
 	// KvStoreServiceRegisterInstanceProcedure is the fully-qualified name of the KvStoreService's
 	// RegisterInstance RPC.
 	KvStoreServiceRegisterInstanceProcedure = "/kvstore.v1.KvStoreService/RegisterInstance"
 	// KvStoreServicePingProcedure is the fully-qualified name of the KvStoreService's Ping RPC.
 	KvStoreServicePingProcedure = "/kvstore.v1.KvStoreService/Ping"
+	// KvStoreServiceImportCarProcedure is the fully-qualified name of the KvStoreService's ImportCar
+	// RPC.
+	KvStoreServiceImportCarProcedure = "/kvstore.v1.KvStoreService/ImportCar"
+	// KvStoreServiceExportCarProcedure is the fully-qualified name of the KvStoreService's ExportCar
+	// RPC.
+	KvStoreServiceExportCarProcedure = "/kvstore.v1.KvStoreService/ExportCar"
 	// KvStoreServiceDelegatedRoutingProcedure is the fully-qualified name of the KvStoreService's
 	// DelegatedRouting RPC.
 	KvStoreServiceDelegatedRoutingProcedure = "/kvstore.v1.KvStoreService/DelegatedRouting"
@@ -74,14 +104,32 @@ type KvStoreServiceClient interface {
 	CreateValue(context.Context, *connect.Request[v1.CreateValueRequest]) (*connect.Response[v1.CreateValueResponse], error)
 	CreateStreamValue(context.Context, *connect.Request[v1.CreateStreamValueRequest]) (*connect.Response[v1.CreateStreamValueResponse], error)
 	GetValue(context.Context, *connect.Request[v1.GetValueRequest]) (*connect.Response[v1.GetValueResponse], error)
+	GetValueMetadata(context.Context, *connect.Request[v1.GetValueMetadataRequest]) (*connect.Response[v1.ValueMetadata], error)
+	BatchGetValues(context.Context, *connect.Request[v1.BatchGetValuesRequest]) (*connect.Response[v1.BatchGetValuesResponse], error)
+	StatValues(context.Context, *connect.Request[v1.StatValuesRequest]) (*connect.Response[v1.StatValuesResponse], error)
 	GetStreamValue(context.Context, *connect.Request[v1.GetStreamValueRequest]) (*connect.Response[v1.GetStreamValueResponse], error)
 	ListStreamValues(context.Context, *connect.Request[v1.ListStreamValuesRequest]) (*connect.Response[v1.ListStreamValuesResponse], error)
+	// Lets a delegate read a stream with its own identity token, for the
+	// operations and time granted by the owner or a delegate further down
+	DelegateStreamAccess(context.Context, *connect.Request[v1.DelegateStreamAccessRequest]) (*connect.Response[v1.DelegateStreamAccessResponse], error)
 	ProlongValue(context.Context, *connect.Request[v1.ProlongValueRequest]) (*connect.Response[v1.ProlongValueResponse], error)
+	ProlongDag(context.Context, *connect.Request[v1.ProlongDagRequest]) (*connect.Response[v1.ProlongDagResponse], error)
+	ChallengeValue(context.Context, *connect.Request[v1.ChallengeValueRequest]) (*connect.Response[v1.ChallengeValueResponse], error)
 	SearchCid(context.Context, *connect.Request[v1.SearchCidRequest]) (*connect.Response[v1.SearchCidResponse], error)
 	SearchInstance(context.Context, *connect.Request[v1.SearchInstanceRequest]) (*connect.Response[v1.SearchInstanceResponse], error)
 	CreateSession(context.Context, *connect.Request[v1.CreateSessionRequest]) (*connect.Response[v1.CreateSessionResponse], error)
+	// Revokes the session of the bearer token, like a logout
+	RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error)
+	// Closes the session of the bearer token and returns its balance left as
+	// a voucher redeemable at the exchange which issued the quota token.
+	// Sessions already revoked get the voucher of their recorded refund.
+	CloseSession(context.Context, *connect.Request[v1.CloseSessionRequest]) (*connect.Response[v1.CloseSessionResponse], error)
 	RegisterInstance(context.Context, *connect.Request[v1.RegisterInstanceRequest]) (*connect.Response[v1.RegisterInstanceResponse], error)
 	Ping(context.Context, *connect.Request[v1.PingRequest]) (*connect.Response[v1.PingResponse], error)
+	// Stores every block of a CARv1 or CARv2 file uploaded in chunks
+	ImportCar(context.Context) *connect.ClientStreamForClient[v1.ImportCarRequest, v1.ImportCarResponse]
+	// Streams a CARv1 file of the dag-pb dag below a stored value
+	ExportCar(context.Context, *connect.Request[v1.ExportCarRequest]) (*connect.ServerStreamForClient[v1.ExportCarResponse], error)
 	DelegatedRouting(context.Context, *connect.Request[v1.DelegatedRoutingRequest]) (*connect.Response[v1.DelegatedRoutingResponse], error)
 }
 
@@ -114,6 +162,24 @@ func NewKvStoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(kvStoreServiceMethods.ByName("GetValue")),
 			connect.WithClientOptions(opts...),
 		),
+		getValueMetadata: connect.NewClient[v1.GetValueMetadataRequest, v1.ValueMetadata](
+			httpClient,
+			baseURL+KvStoreServiceGetValueMetadataProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("GetValueMetadata")),
+			connect.WithClientOptions(opts...),
+		),
+		batchGetValues: connect.NewClient[v1.BatchGetValuesRequest, v1.BatchGetValuesResponse](
+			httpClient,
+			baseURL+KvStoreServiceBatchGetValuesProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("BatchGetValues")),
+			connect.WithClientOptions(opts...),
+		),
+		statValues: connect.NewClient[v1.StatValuesRequest, v1.StatValuesResponse](
+			httpClient,
+			baseURL+KvStoreServiceStatValuesProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("StatValues")),
+			connect.WithClientOptions(opts...),
+		),
 		getStreamValue: connect.NewClient[v1.GetStreamValueRequest, v1.GetStreamValueResponse](
 			httpClient,
 			baseURL+KvStoreServiceGetStreamValueProcedure,
@@ -126,12 +192,30 @@ func NewKvStoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(kvStoreServiceMethods.ByName("ListStreamValues")),
 			connect.WithClientOptions(opts...),
 		),
+		delegateStreamAccess: connect.NewClient[v1.DelegateStreamAccessRequest, v1.DelegateStreamAccessResponse](
+			httpClient,
+			baseURL+KvStoreServiceDelegateStreamAccessProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("DelegateStreamAccess")),
+			connect.WithClientOptions(opts...),
+		),
 		prolongValue: connect.NewClient[v1.ProlongValueRequest, v1.ProlongValueResponse](
 			httpClient,
 			baseURL+KvStoreServiceProlongValueProcedure,
 			connect.WithSchema(kvStoreServiceMethods.ByName("ProlongValue")),
 			connect.WithClientOptions(opts...),
 		),
+		prolongDag: connect.NewClient[v1.ProlongDagRequest, v1.ProlongDagResponse](
+			httpClient,
+			baseURL+KvStoreServiceProlongDagProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("ProlongDag")),
+			connect.WithClientOptions(opts...),
+		),
+		challengeValue: connect.NewClient[v1.ChallengeValueRequest, v1.ChallengeValueResponse](
+			httpClient,
+			baseURL+KvStoreServiceChallengeValueProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("ChallengeValue")),
+			connect.WithClientOptions(opts...),
+		),
 		searchCid: connect.NewClient[v1.SearchCidRequest, v1.SearchCidResponse](
 			httpClient,
 			baseURL+KvStoreServiceSearchCidProcedure,
@@ -150,6 +234,18 @@ func NewKvStoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(kvStoreServiceMethods.ByName("CreateSession")),
 			connect.WithClientOptions(opts...),
 		),
+		revokeSession: connect.NewClient[v1.RevokeSessionRequest, v1.RevokeSessionResponse](
+			httpClient,
+			baseURL+KvStoreServiceRevokeSessionProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("RevokeSession")),
+			connect.WithClientOptions(opts...),
+		),
+		closeSession: connect.NewClient[v1.CloseSessionRequest, v1.CloseSessionResponse](
+			httpClient,
+			baseURL+KvStoreServiceCloseSessionProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("CloseSession")),
+			connect.WithClientOptions(opts...),
+		),
 		registerInstance: connect.NewClient[v1.RegisterInstanceRequest, v1.RegisterInstanceResponse](
 			httpClient,
 			baseURL+KvStoreServiceRegisterInstanceProcedure,
@@ -162,6 +258,18 @@ func NewKvStoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(kvStoreServiceMethods.ByName("Ping")),
 			connect.WithClientOptions(opts...),
 		),
+		importCar: connect.NewClient[v1.ImportCarRequest, v1.ImportCarResponse](
+			httpClient,
+			baseURL+KvStoreServiceImportCarProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("ImportCar")),
+			connect.WithClientOptions(opts...),
+		),
+		exportCar: connect.NewClient[v1.ExportCarRequest, v1.ExportCarResponse](
+			httpClient,
+			baseURL+KvStoreServiceExportCarProcedure,
+			connect.WithSchema(kvStoreServiceMethods.ByName("ExportCar")),
+			connect.WithClientOptions(opts...),
+		),
 		delegatedRouting: connect.NewClient[v1.DelegatedRoutingRequest, v1.DelegatedRoutingResponse](
 			httpClient,
 			baseURL+KvStoreServiceDelegatedRoutingProcedure,
@@ -173,18 +281,28 @@ func NewKvStoreServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 
 // kvStoreServiceClient implements KvStoreServiceClient.
 type kvStoreServiceClient struct {
-	createValue       *connect.Client[v1.CreateValueRequest, v1.CreateValueResponse]
-	createStreamValue *connect.Client[v1.CreateStreamValueRequest, v1.CreateStreamValueResponse]
-	getValue          *connect.Client[v1.GetValueRequest, v1.GetValueResponse]
-	getStreamValue    *connect.Client[v1.GetStreamValueRequest, v1.GetStreamValueResponse]
-	listStreamValues  *connect.Client[v1.ListStreamValuesRequest, v1.ListStreamValuesResponse]
-	prolongValue      *connect.Client[v1.ProlongValueRequest, v1.ProlongValueResponse]
-	searchCid         *connect.Client[v1.SearchCidRequest, v1.SearchCidResponse]
-	searchInstance    *connect.Client[v1.SearchInstanceRequest, v1.SearchInstanceResponse]
-	createSession     *connect.Client[v1.CreateSessionRequest, v1.CreateSessionResponse]
-	registerInstance  *connect.Client[v1.RegisterInstanceRequest, v1.RegisterInstanceResponse]
-	ping              *connect.Client[v1.PingRequest, v1.PingResponse]
-	delegatedRouting  *connect.Client[v1.DelegatedRoutingRequest, v1.DelegatedRoutingResponse]
+	createValue          *connect.Client[v1.CreateValueRequest, v1.CreateValueResponse]
+	createStreamValue    *connect.Client[v1.CreateStreamValueRequest, v1.CreateStreamValueResponse]
+	getValue             *connect.Client[v1.GetValueRequest, v1.GetValueResponse]
+	getValueMetadata     *connect.Client[v1.GetValueMetadataRequest, v1.ValueMetadata]
+	batchGetValues       *connect.Client[v1.BatchGetValuesRequest, v1.BatchGetValuesResponse]
+	statValues           *connect.Client[v1.StatValuesRequest, v1.StatValuesResponse]
+	getStreamValue       *connect.Client[v1.GetStreamValueRequest, v1.GetStreamValueResponse]
+	listStreamValues     *connect.Client[v1.ListStreamValuesRequest, v1.ListStreamValuesResponse]
+	delegateStreamAccess *connect.Client[v1.DelegateStreamAccessRequest, v1.DelegateStreamAccessResponse]
+	prolongValue         *connect.Client[v1.ProlongValueRequest, v1.ProlongValueResponse]
+	prolongDag           *connect.Client[v1.ProlongDagRequest, v1.ProlongDagResponse]
+	challengeValue       *connect.Client[v1.ChallengeValueRequest, v1.ChallengeValueResponse]
+	searchCid            *connect.Client[v1.SearchCidRequest, v1.SearchCidResponse]
+	searchInstance       *connect.Client[v1.SearchInstanceRequest, v1.SearchInstanceResponse]
+	createSession        *connect.Client[v1.CreateSessionRequest, v1.CreateSessionResponse]
+	revokeSession        *connect.Client[v1.RevokeSessionRequest, v1.RevokeSessionResponse]
+	closeSession         *connect.Client[v1.CloseSessionRequest, v1.CloseSessionResponse]
+	registerInstance     *connect.Client[v1.RegisterInstanceRequest, v1.RegisterInstanceResponse]
+	ping                 *connect.Client[v1.PingRequest, v1.PingResponse]
+	importCar            *connect.Client[v1.ImportCarRequest, v1.ImportCarResponse]
+	exportCar            *connect.Client[v1.ExportCarRequest, v1.ExportCarResponse]
+	delegatedRouting     *connect.Client[v1.DelegatedRoutingRequest, v1.DelegatedRoutingResponse]
 }
 
 // CreateValue calls kvstore.v1.KvStoreService.CreateValue.
@@ -202,6 +320,21 @@ func (c *kvStoreServiceClient) GetValue(ctx context.Context, req *connect.Reques
 	return c.getValue.CallUnary(ctx, req)
 }
 
+// GetValueMetadata calls kvstore.v1.KvStoreService.GetValueMetadata.
+func (c *kvStoreServiceClient) GetValueMetadata(ctx context.Context, req *connect.Request[v1.GetValueMetadataRequest]) (*connect.Response[v1.ValueMetadata], error) {
+	return c.getValueMetadata.CallUnary(ctx, req)
+}
+
+// BatchGetValues calls kvstore.v1.KvStoreService.BatchGetValues.
+func (c *kvStoreServiceClient) BatchGetValues(ctx context.Context, req *connect.Request[v1.BatchGetValuesRequest]) (*connect.Response[v1.BatchGetValuesResponse], error) {
+	return c.batchGetValues.CallUnary(ctx, req)
+}
+
+// StatValues calls kvstore.v1.KvStoreService.StatValues.
+func (c *kvStoreServiceClient) StatValues(ctx context.Context, req *connect.Request[v1.StatValuesRequest]) (*connect.Response[v1.StatValuesResponse], error) {
+	return c.statValues.CallUnary(ctx, req)
+}
+
 // GetStreamValue calls kvstore.v1.KvStoreService.GetStreamValue.
 func (c *kvStoreServiceClient) GetStreamValue(ctx context.Context, req *connect.Request[v1.GetStreamValueRequest]) (*connect.Response[v1.GetStreamValueResponse], error) {
 	return c.getStreamValue.CallUnary(ctx, req)
@@ -212,11 +345,26 @@ func (c *kvStoreServiceClient) ListStreamValues(ctx context.Context, req *connec
 	return c.listStreamValues.CallUnary(ctx, req)
 }
 
+// DelegateStreamAccess calls kvstore.v1.KvStoreService.DelegateStreamAccess.
+func (c *kvStoreServiceClient) DelegateStreamAccess(ctx context.Context, req *connect.Request[v1.DelegateStreamAccessRequest]) (*connect.Response[v1.DelegateStreamAccessResponse], error) {
+	return c.delegateStreamAccess.CallUnary(ctx, req)
+}
+
 // ProlongValue calls kvstore.v1.KvStoreService.ProlongValue.
 func (c *kvStoreServiceClient) ProlongValue(ctx context.Context, req *connect.Request[v1.ProlongValueRequest]) (*connect.Response[v1.ProlongValueResponse], error) {
 	return c.prolongValue.CallUnary(ctx, req)
 }
 
+// ProlongDag calls kvstore.v1.KvStoreService.ProlongDag.
+func (c *kvStoreServiceClient) ProlongDag(ctx context.Context, req *connect.Request[v1.ProlongDagRequest]) (*connect.Response[v1.ProlongDagResponse], error) {
+	return c.prolongDag.CallUnary(ctx, req)
+}
+
+// ChallengeValue calls kvstore.v1.KvStoreService.ChallengeValue.
+func (c *kvStoreServiceClient) ChallengeValue(ctx context.Context, req *connect.Request[v1.ChallengeValueRequest]) (*connect.Response[v1.ChallengeValueResponse], error) {
+	return c.challengeValue.CallUnary(ctx, req)
+}
+
 // SearchCid calls kvstore.v1.KvStoreService.SearchCid.
 func (c *kvStoreServiceClient) SearchCid(ctx context.Context, req *connect.Request[v1.SearchCidRequest]) (*connect.Response[v1.SearchCidResponse], error) {
 	return c.searchCid.CallUnary(ctx, req)
@@ -232,6 +380,16 @@ func (c *kvStoreServiceClient) CreateSession(ctx context.Context, req *connect.R
 	return c.createSession.CallUnary(ctx, req)
 }
 
+// RevokeSession calls kvstore.v1.KvStoreService.RevokeSession.
+func (c *kvStoreServiceClient) RevokeSession(ctx context.Context, req *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error) {
+	return c.revokeSession.CallUnary(ctx, req)
+}
+
+// CloseSession calls kvstore.v1.KvStoreService.CloseSession.
+func (c *kvStoreServiceClient) CloseSession(ctx context.Context, req *connect.Request[v1.CloseSessionRequest]) (*connect.Response[v1.CloseSessionResponse], error) {
+	return c.closeSession.CallUnary(ctx, req)
+}
+
 // RegisterInstance calls kvstore.v1.KvStoreService.RegisterInstance.
 func (c *kvStoreServiceClient) RegisterInstance(ctx context.Context, req *connect.Request[v1.RegisterInstanceRequest]) (*connect.Response[v1.RegisterInstanceResponse], error) {
 	return c.registerInstance.CallUnary(ctx, req)
@@ -242,6 +400,16 @@ func (c *kvStoreServiceClient) Ping(ctx context.Context, req *connect.Request[v1
 	return c.ping.CallUnary(ctx, req)
 }
 
+// ImportCar calls kvstore.v1.KvStoreService.ImportCar.
+func (c *kvStoreServiceClient) ImportCar(ctx context.Context) *connect.ClientStreamForClient[v1.ImportCarRequest, v1.ImportCarResponse] {
+	return c.importCar.CallClientStream(ctx)
+}
+
+// ExportCar calls kvstore.v1.KvStoreService.ExportCar.
+func (c *kvStoreServiceClient) ExportCar(ctx context.Context, req *connect.Request[v1.ExportCarRequest]) (*connect.ServerStreamForClient[v1.ExportCarResponse], error) {
+	return c.exportCar.CallServerStream(ctx, req)
+}
+
 // DelegatedRouting calls kvstore.v1.KvStoreService.DelegatedRouting.
 func (c *kvStoreServiceClient) DelegatedRouting(ctx context.Context, req *connect.Request[v1.DelegatedRoutingRequest]) (*connect.Response[v1.DelegatedRoutingResponse], error) {
 	return c.delegatedRouting.CallUnary(ctx, req)
@@ -252,14 +420,32 @@ type KvStoreServiceHandler interface {
 	CreateValue(context.Context, *connect.Request[v1.CreateValueRequest]) (*connect.Response[v1.CreateValueResponse], error)
 	CreateStreamValue(context.Context, *connect.Request[v1.CreateStreamValueRequest]) (*connect.Response[v1.CreateStreamValueResponse], error)
 	GetValue(context.Context, *connect.Request[v1.GetValueRequest]) (*connect.Response[v1.GetValueResponse], error)
+	GetValueMetadata(context.Context, *connect.Request[v1.GetValueMetadataRequest]) (*connect.Response[v1.ValueMetadata], error)
+	BatchGetValues(context.Context, *connect.Request[v1.BatchGetValuesRequest]) (*connect.Response[v1.BatchGetValuesResponse], error)
+	StatValues(context.Context, *connect.Request[v1.StatValuesRequest]) (*connect.Response[v1.StatValuesResponse], error)
 	GetStreamValue(context.Context, *connect.Request[v1.GetStreamValueRequest]) (*connect.Response[v1.GetStreamValueResponse], error)
 	ListStreamValues(context.Context, *connect.Request[v1.ListStreamValuesRequest]) (*connect.Response[v1.ListStreamValuesResponse], error)
+	// Lets a delegate read a stream with its own identity token, for the
+	// operations and time granted by the owner or a delegate further down
+	DelegateStreamAccess(context.Context, *connect.Request[v1.DelegateStreamAccessRequest]) (*connect.Response[v1.DelegateStreamAccessResponse], error)
 	ProlongValue(context.Context, *connect.Request[v1.ProlongValueRequest]) (*connect.Response[v1.ProlongValueResponse], error)
+	ProlongDag(context.Context, *connect.Request[v1.ProlongDagRequest]) (*connect.Response[v1.ProlongDagResponse], error)
+	ChallengeValue(context.Context, *connect.Request[v1.ChallengeValueRequest]) (*connect.Response[v1.ChallengeValueResponse], error)
 	SearchCid(context.Context, *connect.Request[v1.SearchCidRequest]) (*connect.Response[v1.SearchCidResponse], error)
 	SearchInstance(context.Context, *connect.Request[v1.SearchInstanceRequest]) (*connect.Response[v1.SearchInstanceResponse], error)
 	CreateSession(context.Context, *connect.Request[v1.CreateSessionRequest]) (*connect.Response[v1.CreateSessionResponse], error)
+	// Revokes the session of the bearer token, like a logout
+	RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error)
+	// Closes the session of the bearer token and returns its balance left as
+	// a voucher redeemable at the exchange which issued the quota token.
+	// Sessions already revoked get the voucher of their recorded refund.
+	CloseSession(context.Context, *connect.Request[v1.CloseSessionRequest]) (*connect.Response[v1.CloseSessionResponse], error)
 	RegisterInstance(context.Context, *connect.Request[v1.RegisterInstanceRequest]) (*connect.Response[v1.RegisterInstanceResponse], error)
 	Ping(context.Context, *connect.Request[v1.PingRequest]) (*connect.Response[v1.PingResponse], error)
+	// Stores every block of a CARv1 or CARv2 file uploaded in chunks
+	ImportCar(context.Context, *connect.ClientStream[v1.ImportCarRequest]) (*connect.Response[v1.ImportCarResponse], error)
+	// Streams a CARv1 file of the dag-pb dag below a stored value
+	ExportCar(context.Context, *connect.Request[v1.ExportCarRequest], *connect.ServerStream[v1.ExportCarResponse]) error
 	DelegatedRouting(context.Context, *connect.Request[v1.DelegatedRoutingRequest]) (*connect.Response[v1.DelegatedRoutingResponse], error)
 }
 
@@ -288,6 +474,24 @@ func NewKvStoreServiceHandler(svc KvStoreServiceHandler, opts ...connect.Handler
 		connect.WithSchema(kvStoreServiceMethods.ByName("GetValue")),
 		connect.WithHandlerOptions(opts...),
 	)
+	kvStoreServiceGetValueMetadataHandler := connect.NewUnaryHandler(
+		KvStoreServiceGetValueMetadataProcedure,
+		svc.GetValueMetadata,
+		connect.WithSchema(kvStoreServiceMethods.ByName("GetValueMetadata")),
+		connect.WithHandlerOptions(opts...),
+	)
+	kvStoreServiceBatchGetValuesHandler := connect.NewUnaryHandler(
+		KvStoreServiceBatchGetValuesProcedure,
+		svc.BatchGetValues,
+		connect.WithSchema(kvStoreServiceMethods.ByName("BatchGetValues")),
+		connect.WithHandlerOptions(opts...),
+	)
+	kvStoreServiceStatValuesHandler := connect.NewUnaryHandler(
+		KvStoreServiceStatValuesProcedure,
+		svc.StatValues,
+		connect.WithSchema(kvStoreServiceMethods.ByName("StatValues")),
+		connect.WithHandlerOptions(opts...),
+	)
 	kvStoreServiceGetStreamValueHandler := connect.NewUnaryHandler(
 		KvStoreServiceGetStreamValueProcedure,
 		svc.GetStreamValue,
@@ -300,12 +504,30 @@ func NewKvStoreServiceHandler(svc KvStoreServiceHandler, opts ...connect.Handler
 		connect.WithSchema(kvStoreServiceMethods.ByName("ListStreamValues")),
 		connect.WithHandlerOptions(opts...),
 	)
+	kvStoreServiceDelegateStreamAccessHandler := connect.NewUnaryHandler(
+		KvStoreServiceDelegateStreamAccessProcedure,
+		svc.DelegateStreamAccess,
+		connect.WithSchema(kvStoreServiceMethods.ByName("DelegateStreamAccess")),
+		connect.WithHandlerOptions(opts...),
+	)
 	kvStoreServiceProlongValueHandler := connect.NewUnaryHandler(
 		KvStoreServiceProlongValueProcedure,
 		svc.ProlongValue,
 		connect.WithSchema(kvStoreServiceMethods.ByName("ProlongValue")),
 		connect.WithHandlerOptions(opts...),
 	)
+	kvStoreServiceProlongDagHandler := connect.NewUnaryHandler(
+		KvStoreServiceProlongDagProcedure,
+		svc.ProlongDag,
+		connect.WithSchema(kvStoreServiceMethods.ByName("ProlongDag")),
+		connect.WithHandlerOptions(opts...),
+	)
+	kvStoreServiceChallengeValueHandler := connect.NewUnaryHandler(
+		KvStoreServiceChallengeValueProcedure,
+		svc.ChallengeValue,
+		connect.WithSchema(kvStoreServiceMethods.ByName("ChallengeValue")),
+		connect.WithHandlerOptions(opts...),
+	)
 	kvStoreServiceSearchCidHandler := connect.NewUnaryHandler(
 		KvStoreServiceSearchCidProcedure,
 		svc.SearchCid,
@@ -324,6 +546,18 @@ func NewKvStoreServiceHandler(svc KvStoreServiceHandler, opts ...connect.Handler
 		connect.WithSchema(kvStoreServiceMethods.ByName("CreateSession")),
 		connect.WithHandlerOptions(opts...),
 	)
+	kvStoreServiceRevokeSessionHandler := connect.NewUnaryHandler(
+		KvStoreServiceRevokeSessionProcedure,
+		svc.RevokeSession,
+		connect.WithSchema(kvStoreServiceMethods.ByName("RevokeSession")),
+		connect.WithHandlerOptions(opts...),
+	)
+	kvStoreServiceCloseSessionHandler := connect.NewUnaryHandler(
+		KvStoreServiceCloseSessionProcedure,
+		svc.CloseSession,
+		connect.WithSchema(kvStoreServiceMethods.ByName("CloseSession")),
+		connect.WithHandlerOptions(opts...),
+	)
 	kvStoreServiceRegisterInstanceHandler := connect.NewUnaryHandler(
 		KvStoreServiceRegisterInstanceProcedure,
 		svc.RegisterInstance,
@@ -336,6 +570,18 @@ func NewKvStoreServiceHandler(svc KvStoreServiceHandler, opts ...connect.Handler
 		connect.WithSchema(kvStoreServiceMethods.ByName("Ping")),
 		connect.WithHandlerOptions(opts...),
 	)
+	kvStoreServiceImportCarHandler := connect.NewClientStreamHandler(
+		KvStoreServiceImportCarProcedure,
+		svc.ImportCar,
+		connect.WithSchema(kvStoreServiceMethods.ByName("ImportCar")),
+		connect.WithHandlerOptions(opts...),
+	)
+	kvStoreServiceExportCarHandler := connect.NewServerStreamHandler(
+		KvStoreServiceExportCarProcedure,
+		svc.ExportCar,
+		connect.WithSchema(kvStoreServiceMethods.ByName("ExportCar")),
+		connect.WithHandlerOptions(opts...),
+	)
 	kvStoreServiceDelegatedRoutingHandler := connect.NewUnaryHandler(
 		KvStoreServiceDelegatedRoutingProcedure,
 		svc.DelegatedRouting,
@@ -350,22 +596,42 @@ func NewKvStoreServiceHandler(svc KvStoreServiceHandler, opts ...connect.Handler
 			kvStoreServiceCreateStreamValueHandler.ServeHTTP(w, r)
 		case KvStoreServiceGetValueProcedure:
 			kvStoreServiceGetValueHandler.ServeHTTP(w, r)
+		case KvStoreServiceGetValueMetadataProcedure:
+			kvStoreServiceGetValueMetadataHandler.ServeHTTP(w, r)
+		case KvStoreServiceBatchGetValuesProcedure:
+			kvStoreServiceBatchGetValuesHandler.ServeHTTP(w, r)
+		case KvStoreServiceStatValuesProcedure:
+			kvStoreServiceStatValuesHandler.ServeHTTP(w, r)
 		case KvStoreServiceGetStreamValueProcedure:
 			kvStoreServiceGetStreamValueHandler.ServeHTTP(w, r)
 		case KvStoreServiceListStreamValuesProcedure:
 			kvStoreServiceListStreamValuesHandler.ServeHTTP(w, r)
+		case KvStoreServiceDelegateStreamAccessProcedure:
+			kvStoreServiceDelegateStreamAccessHandler.ServeHTTP(w, r)
 		case KvStoreServiceProlongValueProcedure:
 			kvStoreServiceProlongValueHandler.ServeHTTP(w, r)
+		case KvStoreServiceProlongDagProcedure:
+			kvStoreServiceProlongDagHandler.ServeHTTP(w, r)
+		case KvStoreServiceChallengeValueProcedure:
+			kvStoreServiceChallengeValueHandler.ServeHTTP(w, r)
 		case KvStoreServiceSearchCidProcedure:
 			kvStoreServiceSearchCidHandler.ServeHTTP(w, r)
 		case KvStoreServiceSearchInstanceProcedure:
 			kvStoreServiceSearchInstanceHandler.ServeHTTP(w, r)
 		case KvStoreServiceCreateSessionProcedure:
 			kvStoreServiceCreateSessionHandler.ServeHTTP(w, r)
+		case KvStoreServiceRevokeSessionProcedure:
+			kvStoreServiceRevokeSessionHandler.ServeHTTP(w, r)
+		case KvStoreServiceCloseSessionProcedure:
+			kvStoreServiceCloseSessionHandler.ServeHTTP(w, r)
 		case KvStoreServiceRegisterInstanceProcedure:
 			kvStoreServiceRegisterInstanceHandler.ServeHTTP(w, r)
 		case KvStoreServicePingProcedure:
 			kvStoreServicePingHandler.ServeHTTP(w, r)
+		case KvStoreServiceImportCarProcedure:
+			kvStoreServiceImportCarHandler.ServeHTTP(w, r)
+		case KvStoreServiceExportCarProcedure:
+			kvStoreServiceExportCarHandler.ServeHTTP(w, r)
 		case KvStoreServiceDelegatedRoutingProcedure:
 			kvStoreServiceDelegatedRoutingHandler.ServeHTTP(w, r)
 		default:
@@ -389,6 +655,18 @@ func (UnimplementedKvStoreServiceHandler) GetValue(context.Context, *connect.Req
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.GetValue is not implemented"))
 }
 
+func (UnimplementedKvStoreServiceHandler) GetValueMetadata(context.Context, *connect.Request[v1.GetValueMetadataRequest]) (*connect.Response[v1.ValueMetadata], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.GetValueMetadata is not implemented"))
+}
+
+func (UnimplementedKvStoreServiceHandler) BatchGetValues(context.Context, *connect.Request[v1.BatchGetValuesRequest]) (*connect.Response[v1.BatchGetValuesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.BatchGetValues is not implemented"))
+}
+
+func (UnimplementedKvStoreServiceHandler) StatValues(context.Context, *connect.Request[v1.StatValuesRequest]) (*connect.Response[v1.StatValuesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.StatValues is not implemented"))
+}
+
 func (UnimplementedKvStoreServiceHandler) GetStreamValue(context.Context, *connect.Request[v1.GetStreamValueRequest]) (*connect.Response[v1.GetStreamValueResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.GetStreamValue is not implemented"))
 }
@@ -397,10 +675,22 @@ func (UnimplementedKvStoreServiceHandler) ListStreamValues(context.Context, *con
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ListStreamValues is not implemented"))
 }
 
+func (UnimplementedKvStoreServiceHandler) DelegateStreamAccess(context.Context, *connect.Request[v1.DelegateStreamAccessRequest]) (*connect.Response[v1.DelegateStreamAccessResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.DelegateStreamAccess is not implemented"))
+}
+
 func (UnimplementedKvStoreServiceHandler) ProlongValue(context.Context, *connect.Request[v1.ProlongValueRequest]) (*connect.Response[v1.ProlongValueResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ProlongValue is not implemented"))
 }
 
+func (UnimplementedKvStoreServiceHandler) ProlongDag(context.Context, *connect.Request[v1.ProlongDagRequest]) (*connect.Response[v1.ProlongDagResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ProlongDag is not implemented"))
+}
+
+func (UnimplementedKvStoreServiceHandler) ChallengeValue(context.Context, *connect.Request[v1.ChallengeValueRequest]) (*connect.Response[v1.ChallengeValueResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ChallengeValue is not implemented"))
+}
+
 func (UnimplementedKvStoreServiceHandler) SearchCid(context.Context, *connect.Request[v1.SearchCidRequest]) (*connect.Response[v1.SearchCidResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.SearchCid is not implemented"))
 }
@@ -413,6 +703,14 @@ func (UnimplementedKvStoreServiceHandler) CreateSession(context.Context, *connec
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.CreateSession is not implemented"))
 }
 
+func (UnimplementedKvStoreServiceHandler) RevokeSession(context.Context, *connect.Request[v1.RevokeSessionRequest]) (*connect.Response[v1.RevokeSessionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.RevokeSession is not implemented"))
+}
+
+func (UnimplementedKvStoreServiceHandler) CloseSession(context.Context, *connect.Request[v1.CloseSessionRequest]) (*connect.Response[v1.CloseSessionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.CloseSession is not implemented"))
+}
+
 func (UnimplementedKvStoreServiceHandler) RegisterInstance(context.Context, *connect.Request[v1.RegisterInstanceRequest]) (*connect.Response[v1.RegisterInstanceResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.RegisterInstance is not implemented"))
 }
@@ -421,6 +719,14 @@ func (UnimplementedKvStoreServiceHandler) Ping(context.Context, *connect.Request
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.Ping is not implemented"))
 }
 
+func (UnimplementedKvStoreServiceHandler) ImportCar(context.Context, *connect.ClientStream[v1.ImportCarRequest]) (*connect.Response[v1.ImportCarResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ImportCar is not implemented"))
+}
+
+func (UnimplementedKvStoreServiceHandler) ExportCar(context.Context, *connect.Request[v1.ExportCarRequest], *connect.ServerStream[v1.ExportCarResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.ExportCar is not implemented"))
+}
+
 func (UnimplementedKvStoreServiceHandler) DelegatedRouting(context.Context, *connect.Request[v1.DelegatedRoutingRequest]) (*connect.Response[v1.DelegatedRoutingResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("kvstore.v1.KvStoreService.DelegatedRouting is not implemented"))
 }
@@ -0,0 +1,86 @@
+// Package receipt signs the storage receipts returned by a node when values
+// are stored or prolonged, and verifies them for exchanges or reputation
+// systems the receipts are presented to.
+package receipt
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/mr-tron/base58"
+	"google.golang.org/protobuf/proto"
+)
+
+// SIGNATURE_DOMAIN prefixes the signed bytes so that receipt signatures made
+// with the libp2p identity key cannot be mistaken for other records.
+const SIGNATURE_DOMAIN = "pkv-storage-receipt:"
+
+// SignerDid returns the did:key identifying publicKey as receipt signer.
+func SignerDid(publicKey ed25519.PublicKey) string {
+	return "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+}
+
+func parseSignerDid(did string) (ed25519.PublicKey, error) {
+	base58Str, found := strings.CutPrefix(did, "did:key:z")
+	if !found {
+		return nil, fmt.Errorf("invalid did key: %s", did)
+	}
+	didBytes, err := base58.Decode(base58Str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %v", err)
+	}
+	if len(didBytes) != 2+ed25519.PublicKeySize || didBytes[0] != 0xed || didBytes[1] != 0x01 {
+		return nil, fmt.Errorf("did %s is not ed25519", did)
+	}
+	return ed25519.PublicKey(didBytes[2:]), nil
+}
+
+func signedBytes(receipt []byte) []byte {
+	return append([]byte(SIGNATURE_DOMAIN), receipt...)
+}
+
+// Sign serializes receipt with its signer set to the did:key of key.
+func Sign(receipt *pb.StorageReceipt, key ed25519.PrivateKey) (*pb.SignedStorageReceipt, error) {
+	receipt = proto.Clone(receipt).(*pb.StorageReceipt)
+	receipt.Signer = SignerDid(key.Public().(ed25519.PublicKey))
+	serialized, err := proto.MarshalOptions{Deterministic: true}.Marshal(receipt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize receipt: %v", err)
+	}
+	return &pb.SignedStorageReceipt{
+		Receipt:   serialized,
+		Signature: ed25519.Sign(key, signedBytes(serialized)),
+	}, nil
+}
+
+// Verify checks the signature of a receipt against its signer and returns
+// the receipt. Callers still check that the signer is the key of the node
+// they expect, like the did:key of its advertised peer id.
+func Verify(signed *pb.SignedStorageReceipt) (*pb.StorageReceipt, error) {
+	receipt := &pb.StorageReceipt{}
+	if err := proto.Unmarshal(signed.GetReceipt(), receipt); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %v", err)
+	}
+	publicKey, err := parseSignerDid(receipt.GetSigner())
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt signer: %v", err)
+	}
+	if !ed25519.Verify(publicKey, signedBytes(signed.GetReceipt()), signed.GetSignature()) {
+		return nil, fmt.Errorf("invalid signature of receipt by %s", receipt.GetSigner())
+	}
+	return receipt, nil
+}
+
+// VerifyFrom verifies a receipt and that it was signed by signerDid.
+func VerifyFrom(signed *pb.SignedStorageReceipt, signerDid string) (*pb.StorageReceipt, error) {
+	receipt, err := Verify(signed)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.GetSigner() != signerDid {
+		return nil, fmt.Errorf("receipt signed by %s instead of %s", receipt.GetSigner(), signerDid)
+	}
+	return receipt, nil
+}
@@ -0,0 +1,52 @@
+package receipt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+
+	pb "github.com/atticplaygroup/pkv/pkg/proto/gen/go/kvstore/v1"
+	"github.com/atticplaygroup/pkv/pkg/receipt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("Test storage receipts", func() {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).To(BeNil())
+	signerDid := receipt.SignerDid(publicKey)
+
+	sign := func() *pb.SignedStorageReceipt {
+		signed, err := receipt.Sign(&pb.StorageReceipt{
+			Cid:     "bafkreigh2akiscaildcqabsyg3dfr6chu3fgpregiymsck7e7aqa4s52zy",
+			Size:    12,
+			Price:   13,
+			NodeDid: "did:example:pkv",
+		}, privateKey)
+		Expect(err).To(BeNil())
+		return signed
+	}
+
+	It("Should verify receipts signed by the expected signer", func() {
+		verified, err := receipt.VerifyFrom(sign(), signerDid)
+		Expect(err).To(BeNil())
+		Expect(verified.GetSigner()).To(Equal(signerDid))
+		Expect(verified.GetPrice()).To(Equal(int64(13)))
+
+		otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		_, err = receipt.VerifyFrom(sign(), receipt.SignerDid(otherPublicKey))
+		Expect(err).To(Not(BeNil()))
+	})
+
+	It("Should reject altered receipts", func() {
+		signed := sign()
+		altered := &pb.StorageReceipt{}
+		Expect(proto.Unmarshal(signed.GetReceipt(), altered)).To(Succeed())
+		altered.Size = 1
+		signed.Receipt, err = proto.Marshal(altered)
+		Expect(err).To(BeNil())
+		_, err = receipt.Verify(signed)
+		Expect(err).To(Not(BeNil()))
+	})
+})